@@ -0,0 +1,128 @@
+package attachments
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	thumbnailSize = 256
+	// maxThumbnailPixels caps decoded image size, a small file may declare huge dimensions
+	maxThumbnailPixels = 50_000_000
+)
+
+var (
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrImageTooLarge       = errors.New("image dimensions are too large for a thumbnail")
+)
+
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"text/csv":        true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":       true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+}
+
+// officeContentTypes maps zip based documents, content sniffing only recognizes them as zip archives
+var officeContentTypes = map[string]string{
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// DetectContentType sniffs file content instead of trusting the type declared by the client
+func DetectContentType(data []byte, filename string) (string, error) {
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch {
+	case contentType == "application/zip" && officeContentTypes[ext] != "":
+		contentType = officeContentTypes[ext]
+	case contentType == "text/plain" && ext == ".csv":
+		contentType = "text/csv"
+	}
+
+	if !allowedContentTypes[contentType] {
+		return "", ErrUnsupportedFileType
+	}
+
+	return contentType, nil
+}
+
+// CanThumbnail reports whether the image format can be decoded for a thumbnail
+func CanThumbnail(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/gif"
+}
+
+// MakeThumbnail scales an image down to fit the thumbnail box and encodes it as JPEG. Dimensions are read from
+// the header first so images above maxThumbnailPixels are refused before being decoded.
+func MakeThumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxThumbnailPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			width, height = thumbnailSize, max(1, height*thumbnailSize/width)
+		} else {
+			width, height = max(1, width*thumbnailSize/height), thumbnailSize
+		}
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+			thumbnail.Set(x, y, averageColor(src, x0, y0, x1, y1))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func averageColor(src image.Image, x0, y0, x1, y1 int) color.Color {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			cr, cg, cb, ca := src.At(x, y).RGBA()
+			r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+			n++
+		}
+	}
+
+	return color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)}
+}
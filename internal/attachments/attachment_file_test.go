@@ -0,0 +1,90 @@
+package attachments
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 10, B: 10, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDetectContentType(t *testing.T) {
+	contentType, err := DetectContentType(encodePNG(t, 2, 2), "photo.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	contentType, err = DetectContentType([]byte("%PDF-1.7\n"), "invoice.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", contentType)
+
+	contentType, err = DetectContentType([]byte("a,b\n1,2\n"), "list.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv", contentType)
+
+	contentType, err = DetectContentType([]byte("PK\x03\x04rest-of-archive"), "report.xlsx")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", contentType)
+
+	_, err = DetectContentType([]byte("PK\x03\x04rest-of-archive"), "archive.zip")
+	assert.ErrorIs(t, err, ErrUnsupportedFileType)
+
+	_, err = DetectContentType([]byte("MZ\x90\x00\x03\x00\x00\x00"), "tool.exe")
+	assert.ErrorIs(t, err, ErrUnsupportedFileType)
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	thumbnail, err := MakeThumbnail(encodePNG(t, 1024, 512))
+	assert.NoError(t, err)
+
+	img, err := jpeg.Decode(bytes.NewReader(thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, thumbnailSize, img.Bounds().Dx())
+	assert.Equal(t, thumbnailSize/2, img.Bounds().Dy())
+
+	r, _, _, _ := img.At(10, 10).RGBA()
+	assert.InDelta(t, 200, r>>8, 8)
+}
+
+func TestMakeThumbnailKeepsSmallImages(t *testing.T) {
+	thumbnail, err := MakeThumbnail(encodePNG(t, 40, 30))
+	assert.NoError(t, err)
+
+	img, err := jpeg.Decode(bytes.NewReader(thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, 40, img.Bounds().Dx())
+	assert.Equal(t, 30, img.Bounds().Dy())
+}
+
+func TestMakeThumbnailInvalidImage(t *testing.T) {
+	_, err := MakeThumbnail([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+func TestMakeThumbnailRefusesHugeDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black}), nil))
+	data := buf.Bytes()
+	// Logical screen size from the header claims 65535x65535 pixels
+	copy(data[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+
+	_, err := MakeThumbnail(data)
+
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
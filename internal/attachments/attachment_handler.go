@@ -0,0 +1,249 @@
+package attachments
+
+import (
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+	"warehouse/pkg/security"
+	"warehouse/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultMaxAttachmentSize = 10 << 20
+
+type AttachmentHandler struct {
+	Service *AttachmentService
+}
+
+func NewHandler(r *repository.Repository, s storage.Storage, a *auditlog.Auditlog) *AttachmentHandler {
+	return &AttachmentHandler{
+		Service: NewService(NewRepository(r), s, a, maxAttachmentSize()),
+	}
+}
+
+func (h *AttachmentHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/assets/:id/attachments", security.Authorize("user"), h.UploadAttachment(models.AttachmentResourceAsset))
+	router.GET("/assets/:id/attachments", security.Authorize("user"), h.GetAttachments(models.AttachmentResourceAsset))
+	router.POST("/transfers/:id/attachments", security.Authorize("user"), h.UploadAttachment(models.AttachmentResourceTransfer))
+	router.GET("/transfers/:id/attachments", security.Authorize("user"), h.GetAttachments(models.AttachmentResourceTransfer))
+	router.POST("/service-desk/requests/:id/attachments", security.Authorize("user"), h.UploadAttachment(models.AttachmentResourceServiceDeskRequest))
+	router.GET("/service-desk/requests/:id/attachments", security.Authorize("user"), h.GetAttachments(models.AttachmentResourceServiceDeskRequest))
+	router.GET("/attachments/:id", security.Authorize("user"), h.GetAttachment)
+	router.GET("/attachments/:id/download", security.Authorize("user"), h.DownloadAttachment(false))
+	router.GET("/attachments/:id/thumbnail", security.Authorize("user"), h.DownloadAttachment(true))
+	router.DELETE("/attachments/:id", security.Authorize("user"), h.DeleteAttachment)
+}
+
+// UploadAttachment accepts a single file in the "file" form field
+func (h *AttachmentHandler) UploadAttachment(resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resourceID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+			return
+		}
+
+		var req UploadAttachmentRequest
+		if err := c.ShouldBind(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+			return
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Brak pliku", "details": err.Error()})
+			return
+		}
+		if fileHeader.Size > h.Service.maxSize {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Plik jest zbyt duży"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nie udało się odczytać pliku", "details": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		attachment := &models.Attachment{
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			Kind:         req.Kind,
+			Filename:     fileHeader.Filename,
+			MinRole:      req.MinRole,
+		}
+		if attachment.Kind == "" {
+			attachment.Kind = models.AttachmentKindDocument
+		}
+		if attachment.MinRole == "" {
+			attachment.MinRole = "user"
+		}
+		if !security.IsAllowed(c, attachment.MinRole) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Nie można ustawić wyższej roli niż własna"})
+			return
+		}
+		if userID, err := security.GetUserIDFromContext(c); err == nil {
+			attachment.UploadedByID = &userID
+		}
+
+		created, err := h.Service.Upload(attachment, file)
+		if err != nil {
+			h.handleError(c, "Nie udało się dodać załącznika", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+// GetAttachments lists attachments of a resource, hiding those restricted to higher roles
+func (h *AttachmentHandler) GetAttachments(resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resourceID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+			return
+		}
+
+		attachments, err := h.Service.GetAttachments(resourceType, resourceID)
+		if err != nil {
+			h.handleError(c, "Błąd pobierania załączników", err)
+			return
+		}
+
+		visible := []models.Attachment{}
+		for _, attachment := range attachments {
+			if canAccess(c, &attachment) {
+				visible = append(visible, attachment)
+			}
+		}
+
+		c.JSON(http.StatusOK, visible)
+	}
+}
+
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	attachment, ok := h.getAccessibleAttachment(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+func (h *AttachmentHandler) DownloadAttachment(thumbnail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attachment, ok := h.getAccessibleAttachment(c)
+		if !ok {
+			return
+		}
+
+		content, err := h.Service.Open(attachment, thumbnail)
+		if err != nil {
+			h.handleError(c, "Błąd pobierania załącznika", err)
+			return
+		}
+		defer content.Close()
+
+		if thumbnail {
+			c.DataFromReader(http.StatusOK, -1, "image/jpeg", content, nil)
+			return
+		}
+
+		disposition := "attachment"
+		if c.Query("inline") == "true" {
+			disposition = "inline"
+		}
+		c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+			"Content-Disposition": mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}),
+		})
+	}
+}
+
+// DeleteAttachment is allowed for the uploader and moderators
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	attachment, ok := h.getAccessibleAttachment(c)
+	if !ok {
+		return
+	}
+
+	uploadedByID := 0
+	if attachment.UploadedByID != nil {
+		uploadedByID = *attachment.UploadedByID
+	}
+	if !security.IsOwnerOrAllowed(c, uploadedByID, "moderator") {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Brak uprawnień do usunięcia załącznika"})
+		return
+	}
+
+	if err := h.Service.DeleteAttachment(attachment); err != nil {
+		h.handleError(c, "Nie udało się usunąć załącznika", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AttachmentHandler) getAccessibleAttachment(c *gin.Context) (*models.Attachment, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return nil, false
+	}
+
+	attachment, err := h.Service.GetAttachment(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania załącznika", err)
+		return nil, false
+	}
+	if !canAccess(c, attachment) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Brak uprawnień do załącznika"})
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+// canAccess lets the uploader and users holding at least the attachment's minimal role see it
+func canAccess(c *gin.Context, attachment *models.Attachment) bool {
+	if attachment.UploadedByID != nil {
+		return security.IsOwnerOrAllowed(c, *attachment.UploadedByID, attachment.MinRole)
+	}
+
+	return security.IsAllowed(c, attachment.MinRole)
+}
+
+func (h *AttachmentHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrResourceNotFound), errors.Is(err, ErrAttachmentNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrFileTooLarge):
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrUnsupportedFileType):
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrEmptyFile):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
+
+// maxAttachmentSize reads the upload limit in megabytes from ATTACHMENTS_MAX_SIZE_MB
+func maxAttachmentSize() int64 {
+	if value := os.Getenv("ATTACHMENTS_MAX_SIZE_MB"); value != "" {
+		if mb, err := strconv.ParseInt(value, 10, 64); err == nil && mb > 0 {
+			return mb << 20
+		}
+		log.Printf("Nieprawidłowa wartość ATTACHMENTS_MAX_SIZE_MB: %s", value)
+	}
+
+	return defaultMaxAttachmentSize
+}
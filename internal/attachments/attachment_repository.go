@@ -0,0 +1,129 @@
+package attachments
+
+import (
+	"fmt"
+	"warehouse/internal/repository"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// resourceTables maps attachment resource types to tables holding the resources
+var resourceTables = map[string]string{
+	models.AttachmentResourceAsset:              "items",
+	models.AttachmentResourceTransfer:           "transfers",
+	models.AttachmentResourceServiceDeskRequest: "service_desk_requests",
+}
+
+type AttachmentRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *AttachmentRepository {
+	return &AttachmentRepository{
+		repository: r,
+	}
+}
+
+func (r *AttachmentRepository) ResourceExists(resourceType string, resourceID int) (bool, error) {
+	table, ok := resourceTables[resourceType]
+	if !ok {
+		return false, fmt.Errorf("unknown attachment resource type: %s", resourceType)
+	}
+
+	var count int
+	_, err := r.repository.GoquDBWrapper.Select(goqu.COUNT("*")).
+		From(table).
+		Where(goqu.Ex{"id": resourceID}).
+		Executor().
+		ScanVal(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s existence: %w", resourceType, err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *AttachmentRepository) InsertAttachment(attachment *models.Attachment) error {
+	record := goqu.Record{
+		"resource_type":  attachment.ResourceType,
+		"resource_id":    attachment.ResourceID,
+		"kind":           attachment.Kind,
+		"filename":       attachment.Filename,
+		"content_type":   attachment.ContentType,
+		"size":           attachment.Size,
+		"storage_key":    attachment.StorageKey,
+		"thumbnail_key":  attachment.ThumbnailKey,
+		"min_role":       attachment.MinRole,
+		"uploaded_by_id": attachment.UploadedByID,
+	}
+
+	_, err := r.repository.GoquDBWrapper.Insert("attachments").
+		Rows(record).
+		Returning("id").
+		Executor().
+		ScanVal(&attachment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) GetAttachment(id int) (*models.Attachment, error) {
+	var attachment models.Attachment
+
+	found, err := r.getAttachmentQuery().Where(goqu.Ex{"id": id}).Executor().ScanStruct(&attachment)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	attachment.HasThumbnail = attachment.ThumbnailKey != nil
+
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) GetAttachments(resourceType string, resourceID int) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+
+	err := r.getAttachmentQuery().
+		Where(goqu.Ex{"resource_type": resourceType, "resource_id": resourceID}).
+		Order(goqu.I("created_at").Desc(), goqu.I("id").Desc()).
+		Executor().
+		ScanStructs(&attachments)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	for i := range attachments {
+		attachments[i].HasThumbnail = attachments[i].ThumbnailKey != nil
+	}
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) DeleteAttachment(id int) error {
+	if _, err := r.repository.GoquDBWrapper.Delete("attachments").Where(goqu.Ex{"id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) getAttachmentQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"id",
+		"resource_type",
+		"resource_id",
+		"kind",
+		"filename",
+		"content_type",
+		"size",
+		"storage_key",
+		"thumbnail_key",
+		"min_role",
+		"uploaded_by_id",
+		"created_at",
+	).From("attachments")
+}
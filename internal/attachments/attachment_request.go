@@ -0,0 +1,6 @@
+package attachments
+
+type UploadAttachmentRequest struct {
+	Kind    string `form:"kind" binding:"omitempty,oneof=document damage delivery_proof invoice"`
+	MinRole string `form:"min_role" binding:"omitempty,oneof=user moderator admin"`
+}
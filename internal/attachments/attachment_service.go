@@ -0,0 +1,188 @@
+package attachments
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+	"warehouse/pkg/storage"
+)
+
+var (
+	ErrResourceNotFound   = errors.New("resource not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrFileTooLarge       = errors.New("file too large")
+	ErrEmptyFile          = errors.New("file is empty")
+)
+
+type AttachmentService struct {
+	ar      *AttachmentRepository
+	storage storage.Storage
+	a       *auditlog.Auditlog
+	maxSize int64
+}
+
+func NewService(ar *AttachmentRepository, s storage.Storage, a *auditlog.Auditlog, maxSize int64) *AttachmentService {
+	return &AttachmentService{
+		ar:      ar,
+		storage: s,
+		a:       a,
+		maxSize: maxSize,
+	}
+}
+
+// Upload validates file size and type, stores it together with a thumbnail for images and registers the attachment
+func (s *AttachmentService) Upload(attachment *models.Attachment, r io.Reader) (*models.Attachment, error) {
+	exists, err := s.ar.ResourceExists(attachment.ResourceType, attachment.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrResourceNotFound
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrFileTooLarge
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	if attachment.ContentType, err = DetectContentType(data, attachment.Filename); err != nil {
+		return nil, err
+	}
+	attachment.Size = int64(len(data))
+
+	if attachment.StorageKey, err = newStorageKey(attachment.ResourceType, attachment.ResourceID, attachment.Filename); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Save(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	if CanThumbnail(attachment.ContentType) {
+		if thumbnail, err := MakeThumbnail(data); err != nil {
+			log.Printf("Nie udało się wygenerować miniatury dla %s: %v", attachment.StorageKey, err)
+		} else {
+			thumbnailKey := attachment.StorageKey + ".thumb.jpg"
+			if err := s.storage.Save(thumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+				log.Printf("Nie udało się zapisać miniatury dla %s: %v", attachment.StorageKey, err)
+			} else {
+				attachment.ThumbnailKey = &thumbnailKey
+			}
+		}
+	}
+
+	if err := s.ar.InsertAttachment(attachment); err != nil {
+		s.removeFiles(attachment)
+		return nil, err
+	}
+
+	created, err := s.ar.GetAttachment(attachment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(
+		"create",
+		map[string]interface{}{
+			"resource_type": created.ResourceType,
+			"resource_id":   created.ResourceID,
+			"filename":      created.Filename,
+			"msg":           "Dodano załącznik",
+		},
+		created,
+	)
+
+	return created, nil
+}
+
+func (s *AttachmentService) GetAttachment(id int) (*models.Attachment, error) {
+	attachment, err := s.ar.GetAttachment(id)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetAttachments(resourceType string, resourceID int) ([]models.Attachment, error) {
+	return s.ar.GetAttachments(resourceType, resourceID)
+}
+
+// Open returns the attachment content, or its thumbnail when requested
+func (s *AttachmentService) Open(attachment *models.Attachment, thumbnail bool) (io.ReadCloser, error) {
+	key := attachment.StorageKey
+	if thumbnail {
+		if attachment.ThumbnailKey == nil {
+			return nil, ErrAttachmentNotFound
+		}
+		key = *attachment.ThumbnailKey
+	}
+
+	r, err := s.storage.Open(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return r, err
+}
+
+func (s *AttachmentService) DeleteAttachment(attachment *models.Attachment) error {
+	if err := s.ar.DeleteAttachment(attachment.ID); err != nil {
+		return err
+	}
+	s.removeFiles(attachment)
+
+	go s.a.Log(
+		"remove",
+		map[string]interface{}{
+			"resource_type": attachment.ResourceType,
+			"resource_id":   attachment.ResourceID,
+			"filename":      attachment.Filename,
+			"msg":           "Usunięto załącznik",
+		},
+		attachment,
+	)
+
+	return nil
+}
+
+func (s *AttachmentService) removeFiles(attachment *models.Attachment) {
+	if err := s.storage.Delete(attachment.StorageKey); err != nil {
+		log.Printf("Nie udało się usunąć pliku %s: %v", attachment.StorageKey, err)
+	}
+	if attachment.ThumbnailKey != nil {
+		if err := s.storage.Delete(*attachment.ThumbnailKey); err != nil {
+			log.Printf("Nie udało się usunąć miniatury %s: %v", *attachment.ThumbnailKey, err)
+		}
+	}
+}
+
+func newStorageKey(resourceType string, resourceID int, filename string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate storage key: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if len(ext) > 10 {
+		ext = ""
+	}
+
+	return fmt.Sprintf("%s/%d/%s%s", resourceType, resourceID, hex.EncodeToString(random), ext), nil
+}
@@ -2,36 +2,70 @@ package container
 
 import (
 	"database/sql"
+	"log"
+	"os"
+	"warehouse/internal/attachments"
 	auditLogRepo "warehouse/internal/auditlog"
 	"warehouse/internal/integrations/googlesheets"
 	"warehouse/internal/integrations/jira"
 	"warehouse/internal/inventory/assets"
 	"warehouse/internal/inventory/category"
+	"warehouse/internal/inventory/consumptions"
+	"warehouse/internal/inventory/imports"
 	"warehouse/internal/inventory/items"
+	"warehouse/internal/inventory/kits"
+	"warehouse/internal/inventory/labels"
+	"warehouse/internal/inventory/loans"
+	"warehouse/internal/inventory/origins"
+	"warehouse/internal/inventory/rentals"
+	"warehouse/internal/inventory/repairs"
+	"warehouse/internal/inventory/reservations"
+	"warehouse/internal/inventory/retirements"
+	"warehouse/internal/inventory/snapshots"
 	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/inventory/stocktakes"
+	"warehouse/internal/inventory/stockthresholds"
 	"warehouse/internal/inventory/transfers"
+	"warehouse/internal/inventory/transfertemplates"
 	"warehouse/internal/locations"
 	"warehouse/internal/repository"
 	"warehouse/internal/service_desk"
 	"warehouse/internal/users"
 	"warehouse/pkg/auditlog"
+	"warehouse/pkg/notify"
 	"warehouse/pkg/security"
+	"warehouse/pkg/storage"
 )
 
 type Container struct {
-	Repository          *repository.Repository
-	AuditLog            *auditlog.Auditlog
-	LoginHandler        *security.LoginHandler
-	AssetHandler        *assets.ItemHandler
-	StockHandler        *stocks.StockHandler
-	LocationHandler     *locations.LocationHandler
-	TransferHandler     *transfers.TransferHandler
-	UserHandler         *users.UsersHandler
-	ItemHandler         *items.ItemHandler
-	GoogleSheetsHandler *googlesheets.GoogleSheetsHandler
-	ItemCategoryHandler *category.ItemCategoryHandler
-	JiraHandler         *jira.JiraHandler
-	ServiceDeskHandler  *service_desk.Handler
+	Repository              *repository.Repository
+	AuditLog                *auditlog.Auditlog
+	LoginHandler            *security.LoginHandler
+	AssetHandler            *assets.ItemHandler
+	StockHandler            *stocks.StockHandler
+	LocationHandler         *locations.LocationHandler
+	TransferHandler         *transfers.TransferHandler
+	UserHandler             *users.UsersHandler
+	ItemHandler             *items.ItemHandler
+	GoogleSheetsHandler     *googlesheets.GoogleSheetsHandler
+	ItemCategoryHandler     *category.ItemCategoryHandler
+	JiraHandler             *jira.JiraHandler
+	ServiceDeskHandler      *service_desk.Handler
+	RepairHandler           *repairs.RepairHandler
+	LoanHandler             *loans.LoanHandler
+	LabelHandler            *labels.LabelHandler
+	StocktakeHandler        *stocktakes.StocktakeHandler
+	ImportHandler           *imports.ImportHandler
+	KitHandler              *kits.KitHandler
+	AttachmentHandler       *attachments.AttachmentHandler
+	RetirementHandler       *retirements.RetirementHandler
+	RentalHandler           *rentals.RentalHandler
+	OriginHandler           *origins.OriginHandler
+	StockThresholdHandler   *stockthresholds.StockThresholdHandler
+	ConsumptionHandler      *consumptions.ConsumptionHandler
+	SnapshotHandler         *snapshots.SnapshotHandler
+	ReservationHandler      *reservations.ReservationHandler
+	TransferTemplateHandler *transfertemplates.TransferTemplateHandler
 }
 
 func NewAppContainer(db *sql.DB) *Container {
@@ -43,15 +77,38 @@ func NewAppContainer(db *sql.DB) *Container {
 	userHandler := users.NewHandler(userRepo)
 	loginHandler := security.NewLoginHandler(repo)
 	assetHandler := assets.NewAssetHandler(repo, assetRepo, auditLog)
-	stockRepo := stocks.NewRepository(repo)
+	stockRepo := stocks.NewRepository(repo, newStockAlertNotifier(repo))
 	stockHandler := stocks.NewStockHandler(repo, stockRepo, auditLog)
 	itemCategoryHandler := category.NewItemCategoryHandler(repo, assetRepo, stockRepo, auditLog)
 	locationRepository := locations.NewLocationRepository(repo)
 	locationHandler := locations.NewLocationHandler(locationRepository)
 	transferRepository := transfers.NewRepository(repo)
-	transferHandler := transfers.NewHandler(repo, transferRepository, assetRepo, userRepo, auditLog)
+	reservationRepository := reservations.NewRepository(repo)
+	transferHandler := transfers.NewHandler(repo, transferRepository, assetRepo, stockRepo, userRepo, reservationRepository, auditLog)
 	itemsHandler := items.NewItemHandler(repo, stockRepo, assetRepo, auditLogRepo)
 	serviceDeskHandler := service_desk.NewHandler(repo)
+	repairHandler := repairs.NewHandler(repo, assetRepo, auditLog)
+	loanHandler := loans.NewHandler(repo, assetRepo, userRepo, auditLog)
+	labelHandler := labels.NewHandler(assetRepo)
+	stocktakeHandler := stocktakes.NewHandler(repo, assetRepo, stockRepo, locationRepository, auditLog)
+	importHandler := imports.NewHandler(repo, assetRepo, stockRepo, locationRepository, auditLog)
+	kitHandler := kits.NewHandler(repo, assetRepo, auditLog)
+	retirementHandler := retirements.NewHandler(repo, assetRepo, auditLog)
+	rentalHandler := rentals.NewHandler(repo, assetRepo, stockRepo, auditLog)
+	originHandler := origins.NewHandler(repo, auditLog)
+	stockThresholdHandler := stockthresholds.NewHandler(repo, stockRepo, auditLog)
+	consumptionHandler := consumptions.NewHandler(repo, stockRepo, auditLog)
+	snapshotHandler := snapshots.NewHandler(repo)
+	reservationHandler := reservations.NewHandler(repo, reservationRepository, auditLog)
+	transferTemplateHandler := transfertemplates.NewHandler(repo, reservationRepository, auditLog)
+
+	// Inicjalizacja magazynu załączników
+	var attachmentHandler *attachments.AttachmentHandler
+	if attachmentStorage, err := storage.NewFromEnv(); err != nil {
+		log.Printf("Załączniki wyłączone: %v", err)
+	} else {
+		attachmentHandler = attachments.NewHandler(repo, attachmentStorage, auditLog)
+	}
 
 	// Inicjalizacja handlera Google Sheets
 	googleSheetsHandler, err := googlesheets.NewGoogleSheetsHandler()
@@ -66,18 +123,50 @@ func NewAppContainer(db *sql.DB) *Container {
 	}
 
 	return &Container{
-		Repository:          repo,
-		AuditLog:            auditLog,
-		LoginHandler:        loginHandler,
-		AssetHandler:        assetHandler,
-		StockHandler:        stockHandler,
-		LocationHandler:     locationHandler,
-		TransferHandler:     transferHandler,
-		UserHandler:         userHandler,
-		ItemHandler:         itemsHandler,
-		GoogleSheetsHandler: googleSheetsHandler,
-		ItemCategoryHandler: itemCategoryHandler,
-		JiraHandler:         jiraHandler,
-		ServiceDeskHandler:  serviceDeskHandler,
+		Repository:              repo,
+		AuditLog:                auditLog,
+		LoginHandler:            loginHandler,
+		AssetHandler:            assetHandler,
+		StockHandler:            stockHandler,
+		LocationHandler:         locationHandler,
+		TransferHandler:         transferHandler,
+		UserHandler:             userHandler,
+		ItemHandler:             itemsHandler,
+		GoogleSheetsHandler:     googleSheetsHandler,
+		ItemCategoryHandler:     itemCategoryHandler,
+		JiraHandler:             jiraHandler,
+		ServiceDeskHandler:      serviceDeskHandler,
+		RepairHandler:           repairHandler,
+		LoanHandler:             loanHandler,
+		LabelHandler:            labelHandler,
+		StocktakeHandler:        stocktakeHandler,
+		ImportHandler:           importHandler,
+		KitHandler:              kitHandler,
+		AttachmentHandler:       attachmentHandler,
+		RetirementHandler:       retirementHandler,
+		RentalHandler:           rentalHandler,
+		OriginHandler:           originHandler,
+		StockThresholdHandler:   stockThresholdHandler,
+		ConsumptionHandler:      consumptionHandler,
+		SnapshotHandler:         snapshotHandler,
+		ReservationHandler:      reservationHandler,
+		TransferTemplateHandler: transferTemplateHandler,
+	}
+}
+
+// newStockAlertNotifier picks low stock alert channels from STOCK_ALERTS_SERVICE_DESK and STOCK_ALERTS_WEBHOOK_URL,
+// alerts are only logged when none is configured
+func newStockAlertNotifier(repo *repository.Repository) notify.Notifier {
+	notifiers := notify.Multi{}
+	if os.Getenv("STOCK_ALERTS_SERVICE_DESK") == "true" {
+		notifiers = append(notifiers, service_desk.NewNotifier(repo))
+	}
+	if url := os.Getenv("STOCK_ALERTS_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(url))
 	}
+	if len(notifiers) == 0 {
+		return notify.LogNotifier{}
+	}
+
+	return notifiers
 }
@@ -28,6 +28,23 @@ func RegisterProtectedRoutes(router *gin.Engine, container *container.Container)
 	container.TransferHandler.RegisterRoutes(protectedRoutes)
 	container.LocationHandler.RegisterRoutes(protectedRoutes)
 	container.ServiceDeskHandler.RegisterRoutes(protectedRoutes)
+	container.RepairHandler.RegisterRoutes(protectedRoutes)
+	container.LoanHandler.RegisterRoutes(protectedRoutes)
+	container.LabelHandler.RegisterRoutes(protectedRoutes)
+	container.StocktakeHandler.RegisterRoutes(protectedRoutes)
+	container.ImportHandler.RegisterRoutes(protectedRoutes)
+	container.KitHandler.RegisterRoutes(protectedRoutes)
+	container.RetirementHandler.RegisterRoutes(protectedRoutes)
+	container.RentalHandler.RegisterRoutes(protectedRoutes)
+	container.OriginHandler.RegisterRoutes(protectedRoutes)
+	container.StockThresholdHandler.RegisterRoutes(protectedRoutes)
+	container.ConsumptionHandler.RegisterRoutes(protectedRoutes)
+	container.SnapshotHandler.RegisterRoutes(protectedRoutes)
+	container.ReservationHandler.RegisterRoutes(protectedRoutes)
+	container.TransferTemplateHandler.RegisterRoutes(protectedRoutes)
+	if container.AttachmentHandler != nil {
+		container.AttachmentHandler.RegisterRoutes(protectedRoutes)
+	}
 	if container.GoogleSheetsHandler != nil {
 		container.GoogleSheetsHandler.RegisterRoutes(protectedRoutes)
 		log.Println("Google Sheets API routes registered successfully")
@@ -1,16 +1,24 @@
 package assets
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"warehouse/internal/repository"
 	"warehouse/pkg/auditlog"
 	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/metadata"
 	"warehouse/pkg/models"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
+var (
+	ErrAssetNotFound     = errors.New("asset not found")
+	ErrNoAssetsToReissue = errors.New("no assets match the reissue request")
+)
+
 type AssetService struct {
 	assetsRepo *AssetsRepository
 	repo       *repository.Repository
@@ -36,6 +44,7 @@ func (s *AssetService) CreateAssetsWithoutSerial(req models.EmergencyAssetReques
 				Status:     req.Status,
 				CategoryId: req.CategoryId,
 				Origin:     req.Origin,
+				Attributes: req.Attributes,
 			}
 
 			asset, err := s.assetsRepo.PersistItem(itemReq)
@@ -92,6 +101,7 @@ func (s *AssetService) CreateBulkAssets(req models.BulkItemRequest) ([]models.As
 			Status:     req.Status,
 			CategoryId: req.CategoryId,
 			Origin:     req.Origin,
+			Attributes: req.Attributes,
 		}
 
 		asset, err := s.assetsRepo.PersistItem(itemReq)
@@ -180,3 +190,139 @@ func (s *AssetService) UpdateAssetLocation(assetID int, req models.DeliveryLocat
 
 	return nil
 }
+
+// ValidateAttributes checks asset attribute values against the schema of its category
+func (s *AssetService) ValidateAttributes(categoryID int, values models.AssetAttributes, requireAll bool) (models.AssetAttributes, error) {
+	schema, err := s.repo.GetCategoryAttributes(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.ValidateAttributes(schema, values, requireAll)
+}
+
+// UpdateAssetAttributes merges given values into asset attributes, null value removes the attribute
+func (s *AssetService) UpdateAssetAttributes(assetID int, values map[string]interface{}) (*models.Asset, error) {
+	asset, err := s.assetsRepo.GetAsset(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+
+	merged := models.AssetAttributes{}
+	for key, value := range asset.Attributes {
+		merged[key] = value
+	}
+	for key, value := range values {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+
+	attributes, err := s.ValidateAttributes(asset.Category.ID, merged, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.assetsRepo.UpdateAssetAttributes(assetID, attributes); err != nil {
+		return nil, err
+	}
+	asset.Attributes = attributes
+
+	go s.auditLog.Log(
+		"update",
+		map[string]interface{}{
+			"attributes": values,
+			"msg":        "Zaktualizowano atrybuty zasobu",
+		},
+		asset,
+	)
+
+	return asset, nil
+}
+
+// ReissuePyrCodes gives the selected assets new codes in the current format, labels of these assets have to be reprinted
+func (s *AssetService) ReissuePyrCodes(req models.ReissuePyrCodesRequest) ([]models.PyrCodeReissue, error) {
+	conditions := repository.NewQueryBuilder()
+	if len(req.AssetIDs) > 0 {
+		conditions.AddCondition("asset_ids", req.AssetIDs)
+	}
+	if req.CategoryID != 0 {
+		conditions.AddCondition("category_id", req.CategoryID)
+	}
+	if !conditions.HasConditions() {
+		return nil, ErrNoAssetsToReissue
+	}
+
+	assets, err := s.assetsRepo.GetAssetsBy(conditions)
+	if err != nil {
+		return nil, err
+	}
+	if len(*assets) == 0 {
+		return nil, ErrNoAssetsToReissue
+	}
+
+	reissued := make([]models.PyrCodeReissue, 0, len(*assets))
+	err = repository.WithTransaction(s.repo.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		for _, asset := range *assets {
+			pyrCode, err := s.assetsRepo.ReissuePyrCode(tx, asset.ID, asset.Category.ID, asset.Category.PyrID)
+			if err != nil {
+				return err
+			}
+			reissued = append(reissued, models.PyrCodeReissue{AssetID: asset.ID, OldPyrCode: asset.PyrCode, NewPyrCode: pyrCode})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range reissued {
+		go s.auditLog.Log(
+			"update",
+			map[string]interface{}{
+				"old_pyr_code": change.OldPyrCode,
+				"pyr_code":     change.NewPyrCode,
+				"msg":          "Nadano nowy kod PYR",
+			},
+			&models.Asset{ID: change.AssetID},
+		)
+	}
+
+	return reissued, nil
+}
+
+// LookupPyrCode tries an exact match first, then tolerates typos. A single candidate equal to the input after
+// normalization is returned as the asset, otherwise ranked suggestions are returned.
+func (s *AssetService) LookupPyrCode(value string) (*models.Asset, []models.PyrCodeSuggestion, error) {
+	value = strings.TrimSpace(value)
+
+	asset, err := s.assetsRepo.FindItemByPyrCode(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if asset.ID != 0 {
+		return asset, nil, nil
+	}
+
+	prefix := metadata.CurrentPyrCodeFormat().Prefix
+	candidates, err := s.assetsRepo.GetPyrCodeCandidates(candidateKeyLengths(value, prefix))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	suggestions := RankPyrCodeSuggestions(value, candidates, prefix)
+	if len(suggestions) > 0 && suggestions[0].Distance == 0 && (len(suggestions) == 1 || suggestions[1].Distance > 0) {
+		asset, err := s.assetsRepo.GetAsset(suggestions[0].AssetID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return asset, nil, nil
+	}
+
+	return nil, suggestions, nil
+}
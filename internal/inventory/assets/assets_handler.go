@@ -2,6 +2,7 @@ package assets
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -37,8 +38,10 @@ func (h *ItemHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/assets", security.Authorize("user"), h.CreateAsset)
 	router.POST("/assets/bulk", security.Authorize("user"), h.CreateBulkAssets)
 	router.POST("/assets/without-serial", security.Authorize("user"), h.CreateAssetWithoutSerial)
-	router.DELETE("/assets/:id", security.Authorize("moderator"), h.RemoveAsset)
+	router.DELETE("/assets/:id", security.Authorize("admin"), h.RemoveAsset)
 	router.PATCH("/assets/:id/serial", security.Authorize("moderator"), h.UpdateAssetSerial)
+	router.POST("/assets/pyrcodes/reissue", security.Authorize("admin"), h.ReissuePyrCodes)
+	router.PATCH("/assets/:id/attributes", security.Authorize("user"), h.UpdateAssetAttributes)
 	router.PATCH("/assets/:id/logs/location", security.Authorize("user"), h.UpdateAssetLocation)
 	router.GET("/assets/report", security.Authorize("moderator"), h.GetAssetsReport)
 	router.GET("/stocks/report", security.Authorize("moderator"), h.GetStockReport)
@@ -52,16 +55,32 @@ func (h *ItemHandler) GetItemByPyrCode(c *gin.Context) {
 		return
 	}
 
-	asset, err := h.r.FindItemByPyrCode(serial)
+	asset, suggestions, err := h.assetService.LookupPyrCode(serial)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to get asset", "details": err.Error()})
 		return
-	} else if asset.ID == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Unable to locate status with given pyr_code"})
+	} else if asset == nil {
+		response := gin.H{"error": "Unable to locate status with given pyr_code"}
+		if err := metadata.ValidatePyrCode(serial); err != nil {
+			response["details"] = err.Error()
+		}
+		if len(suggestions) == 0 {
+			c.JSON(http.StatusNotFound, response)
+			return
+		}
+		response["suggestions"] = suggestions
+		c.JSON(http.StatusMultipleChoices, response)
 		return
 	}
 
+	if asset.Status == metadata.StatusOnLoan {
+		if asset.Holder, err = h.r.GetCurrentHolder(asset.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to get asset holder", "details": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, asset)
 }
 
@@ -81,15 +100,12 @@ func (h *ItemHandler) CreateAsset(c *gin.Context) {
 		return
 	}
 
-	origin, err := metadata.NewOrigin(req.Origin)
+	origin, err := h.repository.ResolveOrigin(req.Origin)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid asset origin",
-			"details": err.Error(),
-		})
+		abortWithOriginError(c, err)
 		return
 	}
-	req.Origin = origin.String()
+	req.Origin = origin.Slug
 
 	categoryType, err := h.repository.GetCategoryType(req.CategoryId)
 	if err != nil {
@@ -102,6 +118,11 @@ func (h *ItemHandler) CreateAsset(c *gin.Context) {
 		return
 	}
 
+	if req.Attributes, err = h.assetService.ValidateAttributes(req.CategoryId, req.Attributes, true); err != nil {
+		h.abortWithAttributesError(c, err)
+		return
+	}
+
 	asset, err := h.r.PersistItem(req)
 
 	if err != nil {
@@ -155,6 +176,7 @@ func (h *ItemHandler) CreateAsset(c *gin.Context) {
 	c.JSON(http.StatusCreated, asset)
 }
 
+// RemoveAsset hard deletes an asset registered by mistake, assets leaving the inventory should be retired instead
 func (h *ItemHandler) RemoveAsset(c *gin.Context) {
 	var asset models.Asset
 	var err error
@@ -225,6 +247,11 @@ func (h *ItemHandler) CreateBulkAssets(c *gin.Context) {
 		return
 	}
 
+	if req.Attributes, err = h.assetService.ValidateAttributes(req.CategoryId, req.Attributes, true); err != nil {
+		h.abortWithAttributesError(c, err)
+		return
+	}
+
 	createdAssets, errors, err := h.assetService.CreateBulkAssets(req)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Wystąpił nieoczekiwany błąd podczas tworzenia zasobów zbiorczo", "details": err.Error()})
@@ -270,6 +297,12 @@ func (h *ItemHandler) CreateAssetWithoutSerial(c *gin.Context) {
 		return
 	}
 
+	// emergency assets are registered in a hurry, required attributes can be filled in later
+	if req.Attributes, err = h.assetService.ValidateAttributes(req.CategoryId, req.Attributes, false); err != nil {
+		h.abortWithAttributesError(c, err)
+		return
+	}
+
 	createdAssets, errors, err := h.assetService.CreateAssetsWithoutSerial(req)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie udało się utworzyć zasobów awaryjnych", "details": err.Error()})
@@ -286,6 +319,60 @@ func (h *ItemHandler) CreateAssetWithoutSerial(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
+func (h *ItemHandler) UpdateAssetAttributes(c *gin.Context) {
+	var req models.PatchAssetAttributesRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format żądania", "details": err.Error()})
+		return
+	}
+
+	asset, err := h.assetService.UpdateAssetAttributes(req.ID, req.Attributes)
+	if err != nil {
+		if errors.Is(err, ErrAssetNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Nie znaleziono zasobu", "details": err.Error()})
+			return
+		}
+		h.abortWithAttributesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
+}
+
+func (h *ItemHandler) ReissuePyrCodes(c *gin.Context) {
+	var req models.ReissuePyrCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format żądania", "details": err.Error()})
+		return
+	}
+
+	reissued, err := h.assetService.ReissuePyrCodes(req)
+	if err != nil {
+		if errors.Is(err, ErrNoAssetsToReissue) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Wymagane asset_ids lub category_id pasujące do zasobów", "details": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie udało się nadać nowych kodów PYR", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, reissued)
+}
+
+func (h *ItemHandler) abortWithAttributesError(c *gin.Context, err error) {
+	var validationErr *models.AttributeValidationError
+	if errors.As(err, &validationErr) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe atrybuty zasobu", "details": validationErr.Errors})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie udało się sprawdzić atrybutów zasobu", "details": err.Error()})
+}
+
 func (h *ItemHandler) getRequestDefaults(locationId int, status string, origin string) (int, string, string, error) {
 
 	if locationId == 0 {
@@ -296,11 +383,11 @@ func (h *ItemHandler) getRequestDefaults(locationId int, status string, origin s
 		status = "available"
 	}
 
-	o, err := metadata.NewOrigin(origin)
+	o, err := h.repository.ResolveOrigin(origin)
 	if err != nil {
 		return 0, "", "", err
 	}
-	origin = o.String()
+	origin = o.Slug
 
 	return locationId, status, origin, nil
 }
@@ -368,10 +455,14 @@ func (h *ItemHandler) GetAssetsReport(c *gin.Context) {
 	}
 
 	csvData := [][]string{
-		{"ID", "Kategoria", "Numer seryjny", "Kod PYR", "Pochodzenie", "Status", "Typ kategorii", "Lokalizacja"},
+		{"ID", "Kategoria", "Numer seryjny", "Kod PYR", "Pochodzenie", "Status", "Typ kategorii", "Lokalizacja", "Data wycofania", "Powód wycofania"},
 	}
 
 	for _, asset := range assets {
+		retiredAt := ""
+		if asset.RetiredAt != nil {
+			retiredAt = asset.RetiredAt.Format("2006-01-02")
+		}
 		csvData = append(csvData, []string{
 			fmt.Sprintf("%d", asset.ID),
 			asset.CategoryLabel,
@@ -381,6 +472,8 @@ func (h *ItemHandler) GetAssetsReport(c *gin.Context) {
 			asset.Status,
 			asset.CategoryType,
 			asset.LocationName,
+			retiredAt,
+			asset.RetirementReason.String,
 		})
 	}
 
@@ -456,3 +549,12 @@ func (h *ItemHandler) UpdateAssetLocation(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Lokalizacja zasobu zaktualizowana"})
 }
+
+func abortWithOriginError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrUnknownOrigin) || errors.Is(err, repository.ErrInactiveOrigin) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid asset origin", "details": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Unable to check asset origin", "details": err.Error()})
+}
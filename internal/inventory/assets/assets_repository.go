@@ -1,18 +1,22 @@
 package assets
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
+	"time"
 	"warehouse/internal/repository"
 	custom_error "warehouse/pkg/errors"
 	"warehouse/pkg/metadata"
 	"warehouse/pkg/models"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/lib/pq"
 )
 
+// maxPyrCodeAttempts bounds skipping over codes that were assigned by hand or under a previous pattern
+const maxPyrCodeAttempts = 100
+
 type AssetsRepository struct {
 	repository *repository.Repository
 }
@@ -48,9 +52,12 @@ func (r *AssetsRepository) GetAssetList() (*[]models.Asset, error) {
 
 func (r *AssetsRepository) GetAssetsBy(conditions repository.QueryBuilder) (*[]models.Asset, error) {
 	aliases := map[string]string{
+		"asset_ids":      "i.id",
 		"location_ids":   "i.location_id",
 		"category_id":    "i.item_category_id",
 		"category_label": "c.label",
+		"status":         "i.status",
+		"reason":         "i.retirement_reason",
 	}
 
 	query := r.getAssetQuery()
@@ -58,6 +65,16 @@ func (r *AssetsRepository) GetAssetsBy(conditions repository.QueryBuilder) (*[]m
 		Where(conditions.BuildConditions(aliases)).
 		Order(goqu.I("i.id").Asc())
 
+	if filter, ok := conditions.(repository.RetiredFilter); !ok || !filter.IncludeRetired() {
+		query = query.Where(goqu.I("i.retired_at").IsNull())
+	}
+
+	if filter, ok := conditions.(repository.AttributeFilter); ok {
+		for key, value := range filter.AttributeFilters() {
+			query = query.Where(goqu.L("i.attributes ->> ? = ?", key, value))
+		}
+	}
+
 	var flatAssets []models.FlatAssetRecord
 	err := query.Executor().ScanStructs(&flatAssets)
 
@@ -78,6 +95,33 @@ func (r *AssetsRepository) FindItemByPyrCode(pyrCode string) (*models.Asset, err
 	return r.fetchFlatAssetByCondition(goqu.Ex{"i.pyr_code": pyrCode})
 }
 
+// pyrCodeLookupKeySQL mirrors metadata.PyrCodeLookupKey, the length of it is indexed (idx_items_pyr_code_key_length)
+const pyrCodeLookupKeySQL = `regexp_replace(translate(upper(pyr_code), 'OI', '01'), '[^A-Z0-9]', '', 'g')`
+
+// GetPyrCodeCandidates lists codes of assets still in the inventory whose lookup key has one of the lengths,
+// used to suggest matches for mistyped codes
+func (r *AssetsRepository) GetPyrCodeCandidates(keyLengths []int) ([]models.PyrCodeSuggestion, error) {
+	candidates := []models.PyrCodeSuggestion{}
+	if len(keyLengths) == 0 {
+		return candidates, nil
+	}
+
+	err := r.repository.GoquDBWrapper.Select("id", "pyr_code").
+		From("items").
+		Where(
+			goqu.I("pyr_code").IsNotNull(),
+			goqu.I("retired_at").IsNull(),
+			goqu.L("LENGTH("+pyrCodeLookupKeySQL+")").In(keyLengths),
+		).
+		Executor().
+		ScanStructs(&candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pyr codes: %w", err)
+	}
+
+	return candidates, nil
+}
+
 func (r *AssetsRepository) HasRelatedItems(categoryID string) bool {
 	query := `SELECT COUNT(*) FROM items WHERE item_category_id = $1`
 	var count int
@@ -117,21 +161,29 @@ func (r *AssetsRepository) PersistItem(itemRequest models.ItemRequest) (*models.
 		"item_category_id": itemRequest.CategoryId,
 		"status":           itemRequest.Status,
 		"origin":           itemRequest.Origin,
+		"attributes":       itemRequest.Attributes,
 	}
 
 	if itemRequest.Serial != nil {
 		record["item_serial"] = *itemRequest.Serial
 	}
 
-	query := r.repository.GoquDBWrapper.Insert("items").
-		Rows(record).
-		Returning("id")
+	err := repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		query := tx.Insert("items").
+			Rows(record).
+			Returning("id")
 
-	if _, err := query.Executor().ScanVal(&assetID); err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			return nil, custom_error.WrapDBError("Duplicate serial number for asset", string(pqErr.Code))
+		if _, err := query.Executor().ScanVal(&assetID); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				return custom_error.WrapDBError("Duplicate serial number for asset", string(pqErr.Code))
+			}
+			return fmt.Errorf("failed to insert asset record: %w", err)
 		}
-		return nil, fmt.Errorf("failed to insert asset record: %w", err)
+
+		return r.repository.RecordAssetHistory(tx, []int{assetID})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	asset, err := r.GetAsset(assetID)
@@ -170,12 +222,18 @@ func (r *AssetsRepository) CanRemoveAsset(assetID int) (bool, error) {
 
 func (r *AssetsRepository) RemoveAsset(assetID int) (int, error) {
 	var id int
-	query := r.repository.GoquDBWrapper.
-		Delete("items").
-		Where(goqu.Ex{"id": assetID}).
-		Returning("id")
+	err := repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		query := tx.Delete("items").
+			Where(goqu.Ex{"id": assetID}).
+			Returning("id")
+
+		found, err := query.Executor().ScanVal(&id)
+		if err != nil || !found {
+			return err
+		}
 
-	_, err := query.Executor().ScanVal(&id)
+		return r.repository.RecordAssetRemoval(tx, assetID)
+	})
 
 	if err != nil {
 		log.Fatal("failed to delete asset category: ", err)
@@ -217,12 +275,17 @@ func (r *AssetsRepository) UpdateAssetLocation(tx *goqu.TxDatabase, itemID int,
 		return fmt.Errorf("failed to update asset location: %w", err)
 	}
 
-	return nil
+	return r.repository.RecordAssetHistory(tx, []int{itemID})
 }
 
-func (r *AssetsRepository) UpdateAssetStatusAndLocation(tx *goqu.TxDatabase, itemID int, locationID int, status metadata.Status) error {
+func (r *AssetsRepository) UpdateAssetStatusAndLocation(tx *goqu.TxDatabase, itemID int, locationID int, status metadata.Status) (*models.AssetStatusChange, error) {
 	if tx == nil {
-		return fmt.Errorf("transaction is required for UpdateAssetStatusAndLocation")
+		return nil, fmt.Errorf("transaction is required for UpdateAssetStatusAndLocation")
+	}
+
+	changes, err := r.CheckStatusTransition(tx, []int{itemID}, status)
+	if err != nil {
+		return nil, err
 	}
 
 	// Aktualizujemy status i lokalizację w jednym zapytaniu
@@ -236,68 +299,107 @@ func (r *AssetsRepository) UpdateAssetStatusAndLocation(tx *goqu.TxDatabase, ite
 		Exec()
 
 	if err != nil {
-		return fmt.Errorf("failed to update asset status and location: %w", err)
+		return nil, fmt.Errorf("failed to update asset status and location: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no asset found with id: %d", itemID)
+		return nil, fmt.Errorf("no asset found with id: %d", itemID)
 	}
 
-	return nil
+	if err := r.repository.RecordAssetHistory(tx, []int{itemID}); err != nil {
+		return nil, err
+	}
+
+	return &changes[0], nil
 }
 
-func (r *AssetsRepository) RemoveAssetFromTransfer(transferID int, itemID int, locationID int) error {
-	return repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
-		// Najpierw sprawdzamy czy asset istnieje
-		var count int
-		_, err := tx.Select(goqu.COUNT("*")).
-			From("items").
-			Where(goqu.Ex{"id": itemID}).
-			Executor().
-			ScanVal(&count)
+// CheckStatusTransition locks given assets and verifies that each of them may move into status
+func (r *AssetsRepository) CheckStatusTransition(tx *goqu.TxDatabase, assetIDs []int, status metadata.Status) ([]models.AssetStatusChange, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction is required for CheckStatusTransition")
+	}
+	if len(assetIDs) == 0 {
+		return nil, nil
+	}
 
-		if err != nil {
-			return fmt.Errorf("failed to check if asset exists: %w", err)
-		}
+	var rows []struct {
+		ID     int    `db:"id"`
+		Status string `db:"status"`
+	}
+	err := tx.Select("id", goqu.COALESCE(goqu.C("status"), string(metadata.StatusInStock)).As("status")).
+		From("items").
+		Where(goqu.Ex{"id": assetIDs}).
+		Order(goqu.C("id").Asc()).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock assets: %w", err)
+	}
+
+	if len(rows) != len(assetIDs) {
+		return nil, fmt.Errorf("expected %d assets, found %d", len(assetIDs), len(rows))
+	}
 
-		if count == 0 {
-			return fmt.Errorf("asset with id %d does not exist", itemID)
+	changes := make([]models.AssetStatusChange, 0, len(rows))
+	for _, row := range rows {
+		current := metadata.Status(row.Status)
+		if err := metadata.ValidateAssetTransition(row.ID, current, status); err != nil {
+			return nil, err
 		}
+		changes = append(changes, models.AssetStatusChange{AssetID: row.ID, From: current, To: status})
+	}
 
-		// Usuwamy z transferu
-		result, err := tx.Delete("serialized_transfers").
-			Where(goqu.Ex{
-				"transfer_id": transferID,
-				"item_id":     itemID,
-			}).
-			Executor().
-			Exec()
+	return changes, nil
+}
 
-		if err != nil {
-			return fmt.Errorf("failed to remove asset from transfer: %w", err)
-		}
+// DetachAssetFromTransfer removes asset from transfer and leaves it at given location
+func (r *AssetsRepository) DetachAssetFromTransfer(tx *goqu.TxDatabase, transferID int, itemID int, locationID int) (*models.AssetStatusChange, error) {
+	// Najpierw sprawdzamy czy asset istnieje
+	var count int
+	_, err := tx.Select(goqu.COUNT("*")).
+		From("items").
+		Where(goqu.Ex{"id": itemID}).
+		Executor().
+		ScanVal(&count)
 
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("failed to get rows affected: %w", err)
-		}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if asset exists: %w", err)
+	}
 
-		if rowsAffected == 0 {
-			return fmt.Errorf("no transfer record found for asset %d and transfer %d", itemID, transferID)
-		}
+	if count == 0 {
+		return nil, fmt.Errorf("asset with id %d does not exist", itemID)
+	}
 
-		// Aktualizujemy status i lokalizację w jednym zapytaniu
-		if err := r.UpdateAssetStatusAndLocation(tx, itemID, locationID, metadata.StatusAvailable); err != nil {
-			return err
-		}
+	// Usuwamy z transferu
+	result, err := tx.Delete("serialized_transfers").
+		Where(goqu.Ex{
+			"transfer_id": transferID,
+			"item_id":     itemID,
+		}).
+		Executor().
+		Exec()
 
-		return nil
-	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove asset from transfer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("no transfer record found for asset %d and transfer %d", itemID, transferID)
+	}
+
+	// Aktualizujemy status i lokalizację w jednym zapytaniu
+	return r.UpdateAssetStatusAndLocation(tx, itemID, locationID, models.AssetStatusAtLocation(locationID))
 }
 
 func (r *AssetsRepository) GetTransferAssets(transferID int) (*[]models.Asset, error) {
@@ -359,42 +461,49 @@ func (r *AssetsRepository) UpdatePyrCode(assetID int, pyrCode string) error {
 	return nil
 }
 
-func (r *AssetsRepository) UpdateItemStatus(assetIDs []int, status metadata.Status, tx *goqu.TxDatabase) error {
+func (r *AssetsRepository) UpdateItemStatus(assetIDs []int, status metadata.Status, tx *goqu.TxDatabase) ([]models.AssetStatusChange, error) {
 	if len(assetIDs) == 0 {
-		return nil
+		return nil, nil
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction is required for UpdateItemStatus")
 	}
 
-	record := goqu.Record{"status": string(status)}
-	condition := goqu.Ex{"id": assetIDs}
-
-	var result sql.Result
-	var err error
-
-	if tx != nil {
-		result, err = tx.Update("items").
-			Set(record).
-			Where(condition).
-			Executor().
-			Exec()
-	} else {
-		result, err = r.repository.GoquDBWrapper.Update("items").
-			Set(record).
-			Where(condition).
-			Executor().
-			Exec()
+	changes, err := r.CheckStatusTransition(tx, assetIDs, status)
+	if err != nil {
+		return nil, err
 	}
 
+	result, err := tx.Update("items").
+		Set(goqu.Record{"status": string(status)}).
+		Where(goqu.Ex{"id": assetIDs}).
+		Executor().
+		Exec()
+
 	if err != nil {
-		return fmt.Errorf("failed to update asset status: %w", err)
+		return nil, fmt.Errorf("failed to update asset status: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if int(rowsAffected) != len(assetIDs) {
-		return fmt.Errorf("expected to update %d records, but updated %d", len(assetIDs), rowsAffected)
+		return nil, fmt.Errorf("expected to update %d records, but updated %d", len(assetIDs), rowsAffected)
+	}
+
+	if err := r.repository.RecordAssetHistory(tx, assetIDs); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (r *AssetsRepository) UpdateAssetAttributes(assetID int, attributes models.AssetAttributes) error {
+	record := goqu.Record{"attributes": attributes}
+	if err := r.updateAsset(record, goqu.Ex{"id": assetID}); err != nil {
+		return fmt.Errorf("failed to update asset attributes: %w", err)
 	}
 
 	return nil
@@ -432,6 +541,11 @@ func (r *AssetsRepository) getAssetQuery() *goqu.SelectDataset {
 		goqu.I("i.item_serial").As("item_serial"),
 		goqu.I("i.pyr_code").As("pyr_code"),
 		goqu.I("i.origin").As("origin"),
+		"i.attributes",
+		"i.retired_at",
+		"i.retirement_reason",
+		"i.retirement_notes",
+		"i.retired_by_id",
 		goqu.I("c.id").As("category_id"),
 		goqu.I("c.item_category").As("category_type"),
 		goqu.I("c.label").As("category_label"),
@@ -468,24 +582,65 @@ func (r *AssetsRepository) CountAssetsInCategory(categoryID int) (int, error) {
 	return count, nil
 }
 
+// GenerateUniquePyrCode reserves a code outside of any other transaction, the reserved number is never handed out again
 func (r *AssetsRepository) GenerateUniquePyrCode(categoryID int, categoryPyrID string) (string, error) {
-	var nextNumber int
+	var pyrCode string
+	err := repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		pyrCode, err = r.NextPyrCode(tx, categoryID, categoryPyrID)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
 
-	// Pobieramy największy numer dla danej kategorii
-	query := r.repository.GoquDBWrapper.Select(
-		goqu.L("COALESCE(MAX(CAST(REGEXP_REPLACE(pyr_code, '^PYR-" + categoryPyrID + "(\\d+)(-\\d+)?$', '\\1') AS INTEGER)), 0)"),
-	).
-		From("items").
-		Where(goqu.L("pyr_code ~ ?", "^PYR-"+categoryPyrID+"\\d+(-\\d+)?$"))
+	return pyrCode, nil
+}
+
+// NextPyrCode takes the next number from the category sequence, the sequence row stays locked until the
+// transaction ends so concurrent inserts never share a number. Numbers whose code is already taken are skipped.
+func (r *AssetsRepository) NextPyrCode(tx *goqu.TxDatabase, categoryID int, categoryPyrID string) (string, error) {
+	format := metadata.CurrentPyrCodeFormat()
+	now := time.Now()
+	scope := format.SequenceScope(now)
+
+	for attempt := 0; attempt < maxPyrCodeAttempts; attempt++ {
+		var number int
+		_, err := tx.Insert("pyr_code_sequences").
+			Rows(goqu.Record{"category_id": categoryID, "scope": scope, "last_value": 1}).
+			OnConflict(goqu.DoUpdate("category_id, scope", goqu.Record{"last_value": goqu.L("pyr_code_sequences.last_value + 1")})).
+			Returning("last_value").
+			Executor().
+			ScanVal(&number)
+		if err != nil {
+			return "", fmt.Errorf("failed to get next number: %w", err)
+		}
+
+		pyrCode := format.Render(categoryPyrID, number, now)
+		var taken bool
+		if _, err := tx.Select(goqu.L("EXISTS (SELECT 1 FROM items WHERE pyr_code = ?)", pyrCode)).Executor().ScanVal(&taken); err != nil {
+			return "", fmt.Errorf("failed to check pyr code: %w", err)
+		}
+		if !taken {
+			return pyrCode, nil
+		}
+	}
 
-	_, err := query.Executor().ScanVal(&nextNumber)
+	return "", fmt.Errorf("no free PYR code found for category %s after %d attempts", categoryPyrID, maxPyrCodeAttempts)
+}
+
+// ReissuePyrCode assigns a fresh code from the sequence, the old code stops resolving
+func (r *AssetsRepository) ReissuePyrCode(tx *goqu.TxDatabase, assetID int, categoryID int, categoryPyrID string) (string, error) {
+	pyrCode, err := r.NextPyrCode(tx, categoryID, categoryPyrID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get next number: %w", err)
+		return "", err
+	}
+
+	if _, err := tx.Update("items").Set(goqu.Record{"pyr_code": pyrCode}).Where(goqu.Ex{"id": assetID}).Executor().Exec(); err != nil {
+		return "", fmt.Errorf("failed to update asset pyrcode: %w", err)
 	}
 
-	nextNumber++
-	pyrCode := metadata.NewPyrCode(categoryPyrID, nextNumber)
-	return pyrCode.GeneratePyrCode(), nil
+	return pyrCode, nil
 }
 
 func (r *AssetsRepository) UpdateAssetSerial(assetID int, serial string) error {
@@ -556,3 +711,127 @@ func (r *AssetsRepository) GetStockForReport() ([]models.FlatStockRecord, error)
 
 	return flatStocks, nil
 }
+
+func (r *AssetsRepository) GetCurrentHolder(assetID int) (*models.AssetHolder, error) {
+	var holder models.AssetHolder
+
+	found, err := r.repository.GoquDBWrapper.Select(
+		"al.id",
+		"al.user_id",
+		"u.username",
+		"u.fullname",
+		"al.recipient_name",
+		"al.due_at",
+	).
+		From(goqu.T("asset_loans").As("al")).
+		LeftJoin(goqu.T("users").As("u"), goqu.On(goqu.Ex{"al.user_id": goqu.I("u.id")})).
+		Where(goqu.Ex{"al.item_id": assetID, "al.returned_at": nil}).
+		Executor().
+		ScanStruct(&holder)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch asset holder: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &holder, nil
+}
+
+// RelocateAsset corrects the location of an asset that was physically found elsewhere (e.g. during stocktake).
+// The status is aligned with the new location, metadata.ValidateAssetRelocation decides which moves are allowed.
+func (r *AssetsRepository) RelocateAsset(tx *goqu.TxDatabase, itemID int, locationID int) (*models.AssetStatusChange, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction is required for RelocateAsset")
+	}
+
+	var current string
+	found, err := tx.Select(goqu.COALESCE(goqu.C("status"), string(metadata.StatusInStock))).
+		From("items").
+		Where(goqu.Ex{"id": itemID}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanVal(&current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock asset: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("no asset found with id: %d", itemID)
+	}
+
+	from := metadata.Status(current)
+	to := models.AssetStatusAtLocation(locationID)
+	if err := metadata.ValidateAssetRelocation(itemID, from, to); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Update("items").
+		Set(goqu.Record{"location_id": locationID, "status": string(to)}).
+		Where(goqu.Ex{"id": itemID}).
+		Executor().
+		Exec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to relocate asset: %w", err)
+	}
+	if err := r.repository.RecordAssetHistory(tx, []int{itemID}); err != nil {
+		return nil, err
+	}
+
+	if from == to {
+		return nil, nil
+	}
+
+	return &models.AssetStatusChange{AssetID: itemID, From: from, To: to}, nil
+}
+
+func (r *AssetsRepository) FindExistingSerials(serials []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	if len(serials) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := r.repository.GoquDBWrapper.Select("item_serial").
+		From("items").
+		Where(goqu.Ex{"item_serial": serials}).
+		Executor().
+		ScanVals(&found)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check serials: %w", err)
+	}
+
+	for _, serial := range found {
+		existing[serial] = true
+	}
+
+	return existing, nil
+}
+
+// PersistItemWithPyrCode inserts an asset with an already assigned PYR code inside a transaction
+func (r *AssetsRepository) PersistItemWithPyrCode(tx *goqu.TxDatabase, itemRequest models.ItemRequest, pyrCode string) (int, error) {
+	record := goqu.Record{
+		"location_id":      itemRequest.LocationId,
+		"item_category_id": itemRequest.CategoryId,
+		"status":           itemRequest.Status,
+		"origin":           itemRequest.Origin,
+		"attributes":       itemRequest.Attributes,
+		"pyr_code":         pyrCode,
+	}
+	if itemRequest.Serial != nil {
+		record["item_serial"] = *itemRequest.Serial
+	}
+
+	var assetID int
+	if _, err := tx.Insert("items").Rows(record).Returning("id").Executor().ScanVal(&assetID); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			return 0, custom_error.WrapDBError("Duplicate serial number for asset", string(pqErr.Code))
+		}
+		return 0, fmt.Errorf("failed to insert asset record: %w", err)
+	}
+
+	if err := r.repository.RecordAssetHistory(tx, []int{assetID}); err != nil {
+		return 0, err
+	}
+
+	return assetID, nil
+}
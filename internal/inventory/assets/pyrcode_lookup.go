@@ -0,0 +1,98 @@
+package assets
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+)
+
+const (
+	maxSuggestionDistance = 2
+	maxSuggestions        = 10
+)
+
+// RankPyrCodeSuggestions orders candidates by edit distance between lookup keys, a candidate also matches
+// when the input skipped the configured prefix. Distance 0 means the codes differ only by tolerated typos.
+func RankPyrCodeSuggestions(input string, candidates []models.PyrCodeSuggestion, prefix string) []models.PyrCodeSuggestion {
+	inputKey := metadata.PyrCodeLookupKey(input)
+	prefixKey := metadata.PyrCodeLookupKey(prefix)
+	if inputKey == "" {
+		return nil
+	}
+
+	suggestions := []models.PyrCodeSuggestion{}
+	for _, candidate := range candidates {
+		key := metadata.PyrCodeLookupKey(candidate.PyrCode)
+		distance := levenshtein(inputKey, key)
+		if prefixKey != "" && strings.HasPrefix(key, prefixKey) && !strings.HasPrefix(inputKey, prefixKey) {
+			distance = min(distance, levenshtein(inputKey, strings.TrimPrefix(key, prefixKey)))
+		}
+		if distance > maxSuggestionDistance {
+			continue
+		}
+		candidate.Distance = distance
+		suggestions = append(suggestions, candidate)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		return suggestions[i].PyrCode < suggestions[j].PyrCode
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	return suggestions
+}
+
+// candidateKeyLengths lists lookup key lengths a candidate may have to stay within maxSuggestionDistance,
+// edits cannot change the length by more than the distance. Nil means no candidate can match.
+func candidateKeyLengths(input string, prefix string) []int {
+	inputKey := metadata.PyrCodeLookupKey(input)
+	prefixKey := metadata.PyrCodeLookupKey(prefix)
+	if inputKey == "" {
+		return nil
+	}
+
+	lengths := []int{}
+	add := func(length int) {
+		for l := max(1, length-maxSuggestionDistance); l <= length+maxSuggestionDistance; l++ {
+			if !slices.Contains(lengths, l) {
+				lengths = append(lengths, l)
+			}
+		}
+	}
+	add(len(inputKey))
+	if prefixKey != "" && !strings.HasPrefix(inputKey, prefixKey) {
+		add(len(inputKey) + len(prefixKey))
+	}
+	sort.Ints(lengths)
+
+	return lengths
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
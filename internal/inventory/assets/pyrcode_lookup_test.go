@@ -0,0 +1,79 @@
+package assets
+
+import (
+	"testing"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankPyrCodeSuggestions(t *testing.T) {
+	candidates := []models.PyrCodeSuggestion{
+		{AssetID: 1, PyrCode: "PYR-LT10"},
+		{AssetID: 2, PyrCode: "PYR-LT101"},
+		{AssetID: 3, PyrCode: "PYR-MON1"},
+		{AssetID: 4, PyrCode: "PYR-KB7"},
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []int
+	}{
+		{"Lowercase and whitespace", " pyr-lt10 ", []int{1, 2}},
+		{"Missing prefix", "LT10", []int{1, 2}},
+		{"Letter O and I instead of digits", "PYR-LTIO", []int{1, 2}},
+		{"Digit 0 instead of letter O", "m0n1", []int{3}},
+		{"Nothing close", "PYR-XYZ999", []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := RankPyrCodeSuggestions(tt.input, candidates, "PYR")
+
+			ids := []int{}
+			for _, suggestion := range suggestions {
+				ids = append(ids, suggestion.AssetID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestRankPyrCodeSuggestionsExactKeyFirst(t *testing.T) {
+	candidates := []models.PyrCodeSuggestion{
+		{AssetID: 2, PyrCode: "PYR-LT11"},
+		{AssetID: 1, PyrCode: "PYR-LT1"},
+	}
+
+	suggestions := RankPyrCodeSuggestions("pyrlt1", candidates, "PYR")
+
+	assert.Equal(t, 1, suggestions[0].AssetID)
+	assert.Equal(t, 0, suggestions[0].Distance)
+	assert.Equal(t, 1, suggestions[1].Distance)
+}
+
+func TestCandidateKeyLengths(t *testing.T) {
+	assert.Equal(t, []int{5, 6, 7, 8, 9}, candidateKeyLengths(" pyr-lt10 ", "PYR"))
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9}, candidateKeyLengths("LT10", "PYR"))
+	assert.Equal(t, []int{1, 2, 3}, candidateKeyLengths("A", ""))
+	assert.Nil(t, candidateKeyLengths(" - ", "PYR"))
+}
+
+func TestCandidateKeyLengthsKeepRankedSuggestions(t *testing.T) {
+	candidates := []models.PyrCodeSuggestion{
+		{AssetID: 1, PyrCode: "PYR-LT10"},
+		{AssetID: 2, PyrCode: "PYR-LT101"},
+		{AssetID: 3, PyrCode: "PYR-MON1"},
+		{AssetID: 4, PyrCode: "PYR-KB7"},
+		{AssetID: 5, PyrCode: "PYR-PROJ1024"},
+	}
+
+	for _, input := range []string{"pyr-lt10", "LT10", "LT1O1", "mon", "PYR-PR0J102", "KB"} {
+		lengths := candidateKeyLengths(input, "PYR")
+		for _, suggestion := range RankPyrCodeSuggestions(input, candidates, "PYR") {
+			assert.Contains(t, lengths, len(metadata.PyrCodeLookupKey(suggestion.PyrCode)), "%s suggests %s", input, suggestion.PyrCode)
+		}
+	}
+}
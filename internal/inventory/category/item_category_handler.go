@@ -1,6 +1,7 @@
 package category
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"warehouse/internal/inventory/assets"
@@ -35,6 +36,8 @@ func (h *ItemCategoryHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/assets/categories", security.Authorize("moderator"), h.CreateItemCategory)
 	router.DELETE("/assets/categories/:id", security.Authorize("moderator"), h.RemoveItemCategory)
 	router.PATCH("/assets/categories/:id", security.Authorize("admin"), h.UpdateItemCategory)
+	router.GET("/assets/categories/:id/attributes", security.Authorize("user"), h.GetCategoryAttributes)
+	router.PUT("/assets/categories/:id/attributes", security.Authorize("moderator"), h.ReplaceCategoryAttributes)
 }
 
 func (h *ItemCategoryHandler) GetItemCategories(c *gin.Context) {
@@ -169,3 +172,59 @@ func (h *ItemCategoryHandler) UpdateItemCategory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Kategoria została zaktualizowana pomyślnie"})
 }
+
+func (h *ItemCategoryHandler) GetCategoryAttributes(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID, musi być liczbą"})
+		return
+	}
+
+	attributes, err := h.service.GetCategoryAttributes(categoryID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie udało się pobrać atrybutów kategorii", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attributes)
+}
+
+func (h *ItemCategoryHandler) ReplaceCategoryAttributes(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID, musi być liczbą"})
+		return
+	}
+
+	var req models.ReplaceCategoryAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe dane żądania", "details": err.Error()})
+		return
+	}
+
+	attributes, err := h.service.ReplaceCategoryAttributes(categoryID, req.Attributes)
+	if err != nil {
+		if errors.Is(err, ErrCategoryNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Nie znaleziono kategorii", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrInvalidAttributeSchema) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowa definicja atrybutów", "details": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie udało się zapisać atrybutów kategorii", "details": err.Error()})
+		return
+	}
+
+	go h.AuditLog.Log(
+		"update",
+		map[string]interface{}{
+			"category_id": categoryID,
+			"attributes":  attributes,
+			"msg":         "Zaktualizowano atrybuty kategorii",
+		},
+		&models.ItemCategory{ID: categoryID},
+	)
+
+	c.JSON(http.StatusOK, attributes)
+}
@@ -1,11 +1,17 @@
 package category
 
 import (
+	"errors"
 	"fmt"
 	"warehouse/internal/repository"
 	"warehouse/pkg/models"
 )
 
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrInvalidAttributeSchema = errors.New("invalid attribute schema")
+)
+
 type ItemCategoryService struct {
 	repository *repository.Repository
 }
@@ -106,3 +112,34 @@ func (s *ItemCategoryService) generateUniquePyrID(category *models.ItemCategory)
 
 	return nil
 }
+
+func (s *ItemCategoryService) GetCategoryAttributes(categoryID int) ([]models.CategoryAttribute, error) {
+	return s.repository.GetCategoryAttributes(categoryID)
+}
+
+func (s *ItemCategoryService) ReplaceCategoryAttributes(categoryID int, attributes []models.CategoryAttribute) ([]models.CategoryAttribute, error) {
+	categoryType, err := s.repository.GetCategoryType(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if categoryType == "" {
+		return nil, ErrCategoryNotFound
+	}
+
+	seen := make(map[string]bool, len(attributes))
+	for i := range attributes {
+		if err := attributes[i].Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAttributeSchema, err)
+		}
+		if seen[attributes[i].Key] {
+			return nil, fmt.Errorf("%w: duplicated key %q", ErrInvalidAttributeSchema, attributes[i].Key)
+		}
+		seen[attributes[i].Key] = true
+	}
+
+	if err := s.repository.ReplaceCategoryAttributes(categoryID, attributes); err != nil {
+		return nil, err
+	}
+
+	return s.repository.GetCategoryAttributes(categoryID)
+}
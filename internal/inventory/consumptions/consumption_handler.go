@@ -0,0 +1,104 @@
+package consumptions
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	"warehouse/internal/service_desk"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConsumptionHandler struct {
+	Service *ConsumptionService
+}
+
+func NewHandler(r *repository.Repository, sr *stocks.StockRepository, a *auditlog.Auditlog) *ConsumptionHandler {
+	return &ConsumptionHandler{
+		Service: NewService(r, NewRepository(r), sr, service_desk.NewServiceDeskRepository(r), a),
+	}
+}
+
+func (h *ConsumptionHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/stocks/consumptions", security.Authorize("user"), h.GetConsumptions)
+	router.GET("/stocks/consumptions/report", security.Authorize("moderator"), h.GetConsumptionReport)
+	router.POST("/stocks/:id/consume", security.Authorize("user"), h.ConsumeStock)
+}
+
+func (h *ConsumptionHandler) ConsumeStock(c *gin.Context) {
+	var req ConsumeStockRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.ConsumedByID = &userID
+	}
+
+	consumption, err := h.Service.Consume(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zarejestrować zużycia", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, consumption)
+}
+
+func (h *ConsumptionHandler) GetConsumptions(c *gin.Context) {
+	var query RetrieveConsumptionListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	consumptions, err := h.Service.GetConsumptions(query)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania zużycia", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, consumptions)
+}
+
+func (h *ConsumptionHandler) GetConsumptionReport(c *gin.Context) {
+	var query ConsumptionReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	report, err := h.Service.GetConsumptionReport(query)
+	if err != nil {
+		h.handleError(c, "Błąd generowania raportu zużycia", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ConsumptionHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrStockNotFound), errors.Is(err, ErrServiceDeskRequestNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrInvalidPeriod):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, stocks.ErrInsufficientQuantity):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package consumptions
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+)
+
+type ConsumptionRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *ConsumptionRepository {
+	return &ConsumptionRepository{
+		repository: r,
+	}
+}
+
+func (r *ConsumptionRepository) InsertConsumption(tx *goqu.TxDatabase, req ConsumeStockRequest, stock *models.StockItem) (int, error) {
+	record := goqu.Record{
+		"stock_item_id":           stock.ID,
+		"item_category_id":        stock.Category.ID,
+		"location_id":             stock.Location.ID,
+		"origin":                  stock.Origin,
+		"quantity":                req.Quantity,
+		"reason":                  req.Reason,
+		"used_at_location_id":     req.UsedAtLocationID,
+		"service_desk_request_id": req.ServiceDeskRequestID,
+		"consumed_by_id":          req.ConsumedByID,
+	}
+
+	var id int
+	if _, err := tx.Insert("stock_consumptions").Rows(record).Returning("id").Executor().ScanVal(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return 0, custom_error.WrapDBError("Nieprawidłowa lokalizacja użycia", string(pqErr.Code))
+		}
+		return 0, fmt.Errorf("failed to insert stock consumption: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *ConsumptionRepository) GetConsumption(id int) (*models.StockConsumption, error) {
+	var consumption models.StockConsumption
+	found, err := r.getConsumptionQuery().Where(goqu.Ex{"sc.id": id}).Executor().ScanStruct(&consumption)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &consumption, nil
+}
+
+func (r *ConsumptionRepository) GetConsumptions(query RetrieveConsumptionListQuery, from, to *time.Time) ([]models.StockConsumption, error) {
+	conditions := goqu.Ex{}
+	if query.CategoryID != 0 {
+		conditions["sc.item_category_id"] = query.CategoryID
+	}
+	if query.LocationID != 0 {
+		conditions["sc.location_id"] = query.LocationID
+	}
+	if query.ServiceDeskRequestID != 0 {
+		conditions["sc.service_desk_request_id"] = query.ServiceDeskRequestID
+	}
+
+	consumptions := []models.StockConsumption{}
+	err := r.getConsumptionQuery().
+		Where(conditions).
+		Where(periodConditions(from, to)...).
+		Order(goqu.I("sc.consumed_at").Desc()).
+		Executor().
+		ScanStructs(&consumptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return consumptions, nil
+}
+
+// GetConsumptionReport sums consumed quantities per category, optionally split per day
+func (r *ConsumptionRepository) GetConsumptionReport(groupBy string, categoryID int, from, to *time.Time) ([]models.ConsumptionReportRow, error) {
+	rows := []models.ConsumptionReportRow{}
+	query := consumptionReportQuery(r.repository.GoquDBWrapper.From(goqu.T("stock_consumptions").As("sc")), groupBy, categoryID, from, to)
+	if err := query.Executor().ScanStructs(&rows); err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return rows, nil
+}
+
+// consumptionReportQuery builds the report on top of the stock_consumptions dataset aliased as sc
+func consumptionReportQuery(consumptions *goqu.SelectDataset, groupBy string, categoryID int, from, to *time.Time) *goqu.SelectDataset {
+	day := goqu.L("NULL::date")
+	groupColumns := []interface{}{goqu.I("sc.item_category_id"), goqu.I("c.label")}
+	order := []exp.OrderedExpression{goqu.I("c.label").Asc()}
+	if groupBy == models.ConsumptionGroupByDay {
+		day = goqu.L("DATE(sc.consumed_at)")
+		groupColumns = append([]interface{}{day}, groupColumns...)
+		order = append([]exp.OrderedExpression{day.Asc()}, order...)
+	}
+
+	conditions := goqu.Ex{}
+	if categoryID != 0 {
+		conditions["sc.item_category_id"] = categoryID
+	}
+
+	return consumptions.Select(
+		day.As("day"),
+		goqu.I("sc.item_category_id"),
+		goqu.I("c.label").As("category_label"),
+		goqu.SUM("sc.quantity").As("quantity"),
+		goqu.COUNT("*").As("entries"),
+	).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"sc.item_category_id": goqu.I("c.id")})).
+		Where(conditions).
+		Where(periodConditions(from, to)...).
+		GroupBy(groupColumns...).
+		Order(order...)
+}
+
+// periodConditions filters by consumption date, to is inclusive
+func periodConditions(from, to *time.Time) []exp.Expression {
+	var conditions []exp.Expression
+	if from != nil {
+		conditions = append(conditions, goqu.I("sc.consumed_at").Gte(*from))
+	}
+	if to != nil {
+		conditions = append(conditions, goqu.I("sc.consumed_at").Lt(to.AddDate(0, 0, 1)))
+	}
+	return conditions
+}
+
+func (r *ConsumptionRepository) getConsumptionQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"sc.id",
+		"sc.stock_item_id",
+		"sc.item_category_id",
+		goqu.I("c.label").As("category_label"),
+		"sc.location_id",
+		goqu.I("l.name").As("location_name"),
+		"sc.origin",
+		"sc.quantity",
+		"sc.reason",
+		"sc.used_at_location_id",
+		"sc.service_desk_request_id",
+		"sc.consumed_by_id",
+		"sc.consumed_at",
+	).
+		From(goqu.T("stock_consumptions").As("sc")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"sc.item_category_id": goqu.I("c.id")})).
+		Join(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"sc.location_id": goqu.I("l.id")}))
+}
@@ -0,0 +1,25 @@
+package consumptions
+
+type ConsumeStockRequest struct {
+	StockID              int    `uri:"id" binding:"required"`
+	Quantity             int    `json:"quantity" binding:"required,min=1"`
+	Reason               string `json:"reason" binding:"required"`
+	UsedAtLocationID     *int   `json:"location_id"`
+	ServiceDeskRequestID *int   `json:"service_desk_request_id"`
+	ConsumedByID         *int   `json:"-"`
+}
+
+type RetrieveConsumptionListQuery struct {
+	CategoryID           int    `form:"category_id"`
+	LocationID           int    `form:"location_id"`
+	ServiceDeskRequestID int    `form:"service_desk_request_id"`
+	From                 string `form:"from"`
+	To                   string `form:"to"`
+}
+
+type ConsumptionReportQuery struct {
+	GroupBy    string `form:"group_by" binding:"omitempty,oneof=category day"`
+	CategoryID int    `form:"category_id"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+}
@@ -0,0 +1,159 @@
+package consumptions
+
+import (
+	"errors"
+	"time"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	"warehouse/internal/service_desk"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrStockNotFound              = errors.New("stock item not found")
+	ErrServiceDeskRequestNotFound = errors.New("service desk request not found")
+	ErrInvalidPeriod              = errors.New("from and to must be in YYYY-MM-DD format")
+)
+
+type ConsumptionService struct {
+	r         *repository.Repository
+	cr        *ConsumptionRepository
+	stockRepo *stocks.StockRepository
+	sdr       *service_desk.ServiceDeskRepository
+	a         *auditlog.Auditlog
+}
+
+func NewService(
+	r *repository.Repository,
+	cr *ConsumptionRepository,
+	stockRepo *stocks.StockRepository,
+	sdr *service_desk.ServiceDeskRepository,
+	a *auditlog.Auditlog,
+) *ConsumptionService {
+	return &ConsumptionService{
+		r:         r,
+		cr:        cr,
+		stockRepo: stockRepo,
+		sdr:       sdr,
+		a:         a,
+	}
+}
+
+// Consume takes used up stock out of its location, unlike a transfer it does not land anywhere else
+func (s *ConsumptionService) Consume(req ConsumeStockRequest) (*models.StockConsumption, error) {
+	stock, err := s.stockRepo.GetStockItem(req.StockID)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil || stock.ID == 0 {
+		return nil, ErrStockNotFound
+	}
+
+	if req.ServiceDeskRequestID != nil {
+		exists, err := s.sdr.RequestsExists(*req.ServiceDeskRequestID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrServiceDeskRequestNotFound
+		}
+	}
+
+	var consumptionID int
+	var lowStock []models.StockLevel
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		lowStock, err = s.stockRepo.DecreaseStockItemsQuantity(
+			tx,
+			[]models.StockItemRequest{{ID: stock.ID, Quantity: req.Quantity}},
+			stock.Location.ID,
+			models.AdjustmentReasonConsumption,
+		)
+		if err != nil {
+			return err
+		}
+		if consumptionID, err = s.cr.InsertConsumption(tx, req, stock); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	consumption, err := s.cr.GetConsumption(consumptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.stockRepo.NotifyLowStock(lowStock)
+	go s.a.Log(
+		"consume",
+		map[string]interface{}{
+			"stock_item_id":           stock.ID,
+			"quantity":                req.Quantity,
+			"reason":                  req.Reason,
+			"service_desk_request_id": req.ServiceDeskRequestID,
+			"msg":                     "Zużycie materiałów",
+		},
+		consumption,
+	)
+
+	return consumption, nil
+}
+
+func (s *ConsumptionService) GetConsumptions(query RetrieveConsumptionListQuery) ([]models.StockConsumption, error) {
+	from, to, err := parsePeriod(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cr.GetConsumptions(query, from, to)
+}
+
+func (s *ConsumptionService) GetConsumptionReport(query ConsumptionReportQuery) ([]models.ConsumptionReportRow, error) {
+	from, to, err := parsePeriod(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	groupBy := query.GroupBy
+	if groupBy == "" {
+		groupBy = models.ConsumptionGroupByCategory
+	}
+
+	return s.cr.GetConsumptionReport(groupBy, query.CategoryID, from, to)
+}
+
+func parsePeriod(from, to string) (*time.Time, *time.Time, error) {
+	fromDate, err := parseDate(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	toDate, err := parseDate(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fromDate != nil && toDate != nil && toDate.Before(*fromDate) {
+		return nil, nil, ErrInvalidPeriod
+	}
+
+	return fromDate, toDate, nil
+}
+
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, ErrInvalidPeriod
+	}
+
+	return &date, nil
+}
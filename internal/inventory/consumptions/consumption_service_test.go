@@ -0,0 +1,91 @@
+package consumptions
+
+import (
+	"testing"
+	"time"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func datePtr(value string) *time.Time {
+	date, _ := time.Parse("2006-01-02", value)
+	return &date
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom *time.Time
+		wantTo   *time.Time
+		wantErr  error
+	}{
+		{name: "no period"},
+		{name: "only from", from: "2024-03-01", wantFrom: datePtr("2024-03-01")},
+		{name: "only to", to: "2024-03-31", wantTo: datePtr("2024-03-31")},
+		{name: "full period", from: "2024-03-01", to: "2024-03-31", wantFrom: datePtr("2024-03-01"), wantTo: datePtr("2024-03-31")},
+		{name: "single day", from: "2024-03-01", to: "2024-03-01", wantFrom: datePtr("2024-03-01"), wantTo: datePtr("2024-03-01")},
+		{name: "to before from", from: "2024-03-31", to: "2024-03-01", wantErr: ErrInvalidPeriod},
+		{name: "invalid from", from: "01.03.2024", wantErr: ErrInvalidPeriod},
+		{name: "timestamp instead of date", to: "2024-03-31T10:00:00Z", wantErr: ErrInvalidPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := parsePeriod(tt.from, tt.to)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantFrom, from)
+			assert.Equal(t, tt.wantTo, to)
+		})
+	}
+}
+
+func TestConsumptionReportQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		groupBy    string
+		categoryID int
+		from, to   *time.Time
+		contains   []string
+		excludes   []string
+	}{
+		{
+			name:     "per category",
+			groupBy:  models.ConsumptionGroupByCategory,
+			contains: []string{`NULL::date AS "day"`, `GROUP BY "sc"."item_category_id", "c"."label"`, `ORDER BY "c"."label" ASC`},
+			excludes: []string{"DATE(sc.consumed_at)", "WHERE"},
+		},
+		{
+			name:     "per day",
+			groupBy:  models.ConsumptionGroupByDay,
+			contains: []string{`DATE(sc.consumed_at) AS "day"`, `GROUP BY DATE(sc.consumed_at), "sc"."item_category_id", "c"."label"`, `ORDER BY DATE(sc.consumed_at) ASC, "c"."label" ASC`},
+		},
+		{
+			name:       "category and inclusive period",
+			groupBy:    models.ConsumptionGroupByCategory,
+			categoryID: 7,
+			from:       datePtr("2024-03-01"),
+			to:         datePtr("2024-03-31"),
+			contains:   []string{`"sc"."item_category_id" = 7`, `"sc"."consumed_at" >= '2024-03-01`, `"sc"."consumed_at" < '2024-04-01`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := goqu.Dialect("postgres").From(goqu.T("stock_consumptions").As("sc"))
+
+			sql, _, err := consumptionReportQuery(base, tt.groupBy, tt.categoryID, tt.from, tt.to).ToSQL()
+
+			assert.NoError(t, err)
+			for _, fragment := range tt.contains {
+				assert.Contains(t, sql, fragment)
+			}
+			for _, fragment := range tt.excludes {
+				assert.NotContains(t, sql, fragment)
+			}
+		})
+	}
+}
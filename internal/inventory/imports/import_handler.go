@@ -0,0 +1,100 @@
+package imports
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/locations"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportFileSize = 10 << 20
+
+type ImportHandler struct {
+	Service *ImportService
+}
+
+func NewHandler(
+	r *repository.Repository,
+	ar *assets.AssetsRepository,
+	sr *stocks.StockRepository,
+	lr *locations.LocationRepository,
+	a *auditlog.Auditlog,
+) *ImportHandler {
+	return &ImportHandler{
+		Service: NewService(r, ar, sr, lr, a),
+	}
+}
+
+func (h *ImportHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/items/import", security.Authorize("moderator"), h.ImportItems)
+}
+
+// ImportItems accepts a CSV or XLSX file in the "file" form field, dry run is the default
+func (h *ImportHandler) ImportItems(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "true"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowa wartość dry_run"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Brak pliku do importu", "details": err.Error()})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Plik jest zbyt duży"})
+		return
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	if format != FormatCSV && format != FormatXLSX {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieobsługiwany format pliku", "details": "only .csv and .xlsx files are supported"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nie udało się odczytać pliku", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rows, err := ParseFile(file, format)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nie udało się odczytać pliku", "details": err.Error()})
+		return
+	}
+
+	report, err := h.Service.Import(rows, dryRun)
+	if err != nil {
+		switch err.(type) {
+		case *custom_error.UniqueViolationError:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Numer seryjny lub kod PYR jest już zarejestrowany", "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Import nie powiódł się", "details": err.Error()})
+		}
+		return
+	}
+
+	if !dryRun && report.Invalid > 0 {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	if !dryRun {
+		c.JSON(http.StatusCreated, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
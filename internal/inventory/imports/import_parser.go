@@ -0,0 +1,163 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ImportRow is a raw spreadsheet row, Line is the 1-based row number in the source file
+type ImportRow struct {
+	Line     int
+	Category string
+	Serial   string
+	Origin   string
+	Location string
+	Quantity string
+	// Attributes holds non-empty attribute columns keyed by the attribute key
+	Attributes map[string]string
+}
+
+// headerAliases maps accepted column headers (English and Polish) to ImportRow fields
+var headerAliases = map[string]string{
+	"category":      "category",
+	"kategoria":     "category",
+	"serial":        "serial",
+	"numer_seryjny": "serial",
+	"origin":        "origin",
+	"pochodzenie":   "origin",
+	"location":      "location",
+	"lokalizacja":   "location",
+	"quantity":      "quantity",
+	"ilosc":         "quantity",
+	"ilość":         "quantity",
+}
+
+// attributePrefixes mark columns holding category attributes, e.g. "attr:ram_gb" or "atrybut:ram_gb"
+var attributePrefixes = []string{"attr:", "atrybut:"}
+
+func ParseFile(r io.Reader, format string) ([]ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatXLSX:
+		return ParseXLSX(r)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %s", format)
+	}
+}
+
+func ParseCSV(r io.Reader) ([]ImportRow, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV file: %w", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	// Arkusze eksportowane z Excela w PL często używają średnika
+	firstLine, _, _ := bytes.Cut(content, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+
+	return mapRecords(records)
+}
+
+func ParseXLSX(r io.Reader) ([]ImportRow, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX file: %w", err)
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX file has no sheets")
+	}
+
+	records, err := file.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("unable to read XLSX sheet: %w", err)
+	}
+
+	return mapRecords(records)
+}
+
+func mapRecords(records [][]string) ([]ImportRow, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	columns := map[string]int{}
+	attributeColumns := map[string]int{}
+	for i, header := range records[0] {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+		if field, ok := headerAliases[key]; ok {
+			columns[field] = i
+			continue
+		}
+		for _, prefix := range attributePrefixes {
+			if attribute, ok := strings.CutPrefix(key, prefix); ok && attribute != "" {
+				attributeColumns[strings.TrimSpace(attribute)] = i
+			}
+		}
+	}
+
+	if _, ok := columns["category"]; !ok {
+		return nil, fmt.Errorf("missing required column: category")
+	}
+	if _, ok := columns["origin"]; !ok {
+		return nil, fmt.Errorf("missing required column: origin")
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]ImportRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := ImportRow{
+			Line:     i + 2,
+			Category: value(record, "category"),
+			Serial:   value(record, "serial"),
+			Origin:   value(record, "origin"),
+			Location: value(record, "location"),
+			Quantity: value(record, "quantity"),
+		}
+		for attribute, i := range attributeColumns {
+			if i >= len(record) || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			if row.Attributes == nil {
+				row.Attributes = map[string]string{}
+			}
+			row.Attributes[attribute] = strings.TrimSpace(record[i])
+		}
+		if row.Category == "" && row.Serial == "" && row.Origin == "" && row.Location == "" && row.Quantity == "" && len(row.Attributes) == 0 {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
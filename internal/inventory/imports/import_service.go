@@ -0,0 +1,188 @@
+package imports
+
+import (
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/locations"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type ImportReport struct {
+	DryRun        bool        `json:"dry_run"`
+	Total         int         `json:"total"`
+	Valid         int         `json:"valid"`
+	Invalid       int         `json:"invalid"`
+	CreatedAssets int         `json:"created_assets"`
+	UpdatedStock  int         `json:"updated_stock"`
+	Rows          []RowResult `json:"rows"`
+}
+
+type ImportService struct {
+	r         *repository.Repository
+	ar        *assets.AssetsRepository
+	stockRepo *stocks.StockRepository
+	lr        *locations.LocationRepository
+	a         *auditlog.Auditlog
+}
+
+func NewService(
+	r *repository.Repository,
+	ar *assets.AssetsRepository,
+	stockRepo *stocks.StockRepository,
+	lr *locations.LocationRepository,
+	a *auditlog.Auditlog,
+) *ImportService {
+	return &ImportService{
+		r:         r,
+		ar:        ar,
+		stockRepo: stockRepo,
+		lr:        lr,
+		a:         a,
+	}
+}
+
+// Import validates all rows and, unless dryRun is set or any row is invalid, creates everything in one transaction
+func (s *ImportService) Import(rows []ImportRow, dryRun bool) (*ImportReport, error) {
+	catalog, err := s.loadCatalog(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	results := ValidateRows(rows, catalog)
+	report := &ImportReport{DryRun: dryRun, Total: len(results), Rows: results}
+	for _, result := range results {
+		if result.IsValid() {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+	}
+
+	if dryRun || report.Invalid > 0 || report.Total == 0 {
+		return report, nil
+	}
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		return s.persistRows(tx, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.logImport(report)
+
+	return report, nil
+}
+
+func (s *ImportService) persistRows(tx *goqu.TxDatabase, report *ImportReport) error {
+	for i := range report.Rows {
+		row := &report.Rows[i]
+
+		if row.Kind == RowKindStock {
+			stockID, err := s.stockRepo.AddStockQuantity(tx, stocks.StockItemRequest{
+				CategoryID: row.CategoryID,
+				LocationID: row.LocationID,
+				Quantity:   row.Quantity,
+				Origin:     row.Origin,
+			}, models.AdjustmentReasonImport)
+			if err != nil {
+				return err
+			}
+			row.ID = stockID
+			report.UpdatedStock++
+			continue
+		}
+
+		pyrCode, err := s.ar.NextPyrCode(tx, row.CategoryID, row.categoryPyrID)
+		if err != nil {
+			return err
+		}
+
+		assetID, err := s.ar.PersistItemWithPyrCode(tx, models.ItemRequest{
+			Serial:     row.Serial,
+			LocationId: row.LocationID,
+			Status:     string(models.AssetStatusAtLocation(row.LocationID)),
+			CategoryId: row.CategoryID,
+			Origin:     row.Origin,
+			Attributes: row.Attributes,
+		}, pyrCode)
+		if err != nil {
+			return err
+		}
+		row.ID = assetID
+		row.PyrCode = pyrCode
+		report.CreatedAssets++
+	}
+
+	return nil
+}
+
+func (s *ImportService) loadCatalog(rows []ImportRow) (*Catalog, error) {
+	categories, err := s.r.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.lr.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	origins, err := s.r.GetOrigins(true)
+	if err != nil {
+		return nil, err
+	}
+
+	existingSerials, err := s.ar.FindExistingSerials(collectSerials(rows))
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{
+		Categories:      *categories,
+		Locations:       *locations,
+		Origins:         origins,
+		ExistingSerials: existingSerials,
+		Attributes:      map[int][]models.CategoryAttribute{},
+	}
+
+	for _, row := range rows {
+		category := catalog.findCategory(row.Category)
+		if category == nil || category.Type != RowKindAsset {
+			continue
+		}
+		if _, ok := catalog.Attributes[category.ID]; ok {
+			continue
+		}
+		if catalog.Attributes[category.ID], err = s.r.GetCategoryAttributes(category.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return catalog, nil
+}
+
+func (s *ImportService) logImport(report *ImportReport) {
+	for _, row := range report.Rows {
+		if row.Kind == RowKindStock {
+			s.a.Log("import", map[string]interface{}{
+				"quantity":    row.Quantity,
+				"location_id": row.LocationID,
+				"origin":      row.Origin,
+				"msg":         "Zwiększono stan z importu",
+			}, models.StockItem{ID: row.ID})
+			continue
+		}
+
+		s.a.Log("create", map[string]interface{}{
+			"serial":      row.Serial,
+			"pyr_code":    row.PyrCode,
+			"location_id": row.LocationID,
+			"msg":         "Utworzono zasób z importu",
+		}, &models.Asset{ID: row.ID})
+	}
+}
@@ -0,0 +1,201 @@
+package imports
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+)
+
+const (
+	RowKindAsset = "asset"
+	RowKindStock = "stock"
+)
+
+type RowResult struct {
+	Line       int      `json:"line"`
+	Kind       string   `json:"kind,omitempty"`
+	CategoryID int      `json:"category_id,omitempty"`
+	LocationID int      `json:"location_id,omitempty"`
+	Serial     *string  `json:"serial,omitempty"`
+	Origin     string   `json:"origin,omitempty"`
+	Quantity   int      `json:"quantity,omitempty"`
+	ID         int      `json:"id,omitempty"`
+	PyrCode    string   `json:"pyr_code,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+
+	Attributes models.AssetAttributes `json:"attributes,omitempty"`
+
+	categoryPyrID string
+}
+
+func (r *RowResult) IsValid() bool {
+	return len(r.Errors) == 0
+}
+
+// Catalog holds the reference data rows are validated against
+type Catalog struct {
+	Categories      []models.ItemCategory
+	Locations       []models.Location
+	Origins         []models.Origin
+	ExistingSerials map[string]bool
+	// Attributes holds the attribute schema of every asset category used in the rows
+	Attributes map[int][]models.CategoryAttribute
+}
+
+func (c *Catalog) findCategory(value string) *models.ItemCategory {
+	id, idErr := strconv.Atoi(value)
+	for i := range c.Categories {
+		category := &c.Categories[i]
+		if idErr == nil && category.ID == id {
+			return category
+		}
+		if strings.EqualFold(category.PyrID, value) || strings.EqualFold(category.Name, value) || strings.EqualFold(category.Label, value) {
+			return category
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) findLocation(value string) *models.Location {
+	id, idErr := strconv.Atoi(value)
+	for i := range c.Locations {
+		location := &c.Locations[i]
+		if (idErr == nil && location.ID == id) || strings.EqualFold(location.Name, value) {
+			return location
+		}
+	}
+	return nil
+}
+
+// findOrigin matches the normalized slug against active origins only
+func (c *Catalog) findOrigin(value string) *models.Origin {
+	slug := metadata.NewOrigin(value).String()
+	for i := range c.Origins {
+		origin := &c.Origins[i]
+		if origin.Active && origin.Slug == slug {
+			return origin
+		}
+	}
+	return nil
+}
+
+// ValidateRows resolves references of every row and collects per-row validation errors
+func ValidateRows(rows []ImportRow, catalog *Catalog) []RowResult {
+	results := make([]RowResult, 0, len(rows))
+	seenSerials := map[string]int{}
+
+	for _, row := range rows {
+		result := RowResult{Line: row.Line}
+
+		category := catalog.findCategory(row.Category)
+		if row.Category == "" {
+			result.Errors = append(result.Errors, "brak kategorii")
+		} else if category == nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("nieznana kategoria: %s", row.Category))
+		} else {
+			result.CategoryID = category.ID
+			result.Kind = category.Type
+			result.categoryPyrID = category.PyrID
+		}
+
+		if origin := catalog.findOrigin(row.Origin); origin == nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("nieprawidłowe pochodzenie: %s", row.Origin))
+		} else {
+			result.Origin = origin.Slug
+		}
+
+		result.LocationID = models.DefaultEquipmentLocationID
+		if row.Location != "" {
+			if location := catalog.findLocation(row.Location); location != nil {
+				result.LocationID = location.ID
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("nieznana lokalizacja: %s", row.Location))
+			}
+		}
+
+		quantity := 1
+		if row.Quantity != "" {
+			var err error
+			if quantity, err = strconv.Atoi(row.Quantity); err != nil || quantity < 1 {
+				result.Errors = append(result.Errors, fmt.Sprintf("nieprawidłowa ilość: %s", row.Quantity))
+			}
+		}
+		result.Quantity = quantity
+
+		switch result.Kind {
+		case RowKindAsset:
+			if quantity != 1 {
+				result.Errors = append(result.Errors, "zasób z numerem seryjnym musi mieć ilość 1")
+			}
+			if row.Serial != "" {
+				serial := row.Serial
+				result.Serial = &serial
+				if catalog.ExistingSerials[serial] {
+					result.Errors = append(result.Errors, fmt.Sprintf("numer seryjny %s jest już zarejestrowany", serial))
+				} else if line, ok := seenSerials[serial]; ok {
+					result.Errors = append(result.Errors, fmt.Sprintf("numer seryjny %s powtarza się w wierszu %d", serial, line))
+				}
+				seenSerials[serial] = row.Line
+			}
+			result.Attributes, result.Errors = validateRowAttributes(row, catalog.Attributes[result.CategoryID], result.Errors)
+		case RowKindStock:
+			if row.Serial != "" {
+				result.Errors = append(result.Errors, "pozycje magazynowe nie mają numeru seryjnego")
+			}
+			if row.Quantity == "" {
+				result.Errors = append(result.Errors, "brak ilości dla pozycji magazynowej")
+			}
+			if len(row.Attributes) > 0 {
+				result.Errors = append(result.Errors, "pozycje magazynowe nie mają atrybutów")
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// validateRowAttributes checks attribute columns against the category schema, all required attributes must be given
+func validateRowAttributes(row ImportRow, schema []models.CategoryAttribute, errs []string) (models.AssetAttributes, []string) {
+	values := make(map[string]interface{}, len(row.Attributes))
+	for key, value := range row.Attributes {
+		values[key] = value
+	}
+
+	attributes, err := models.ValidateAttributes(schema, values, true)
+	var attrErr *models.AttributeValidationError
+	if errors.As(err, &attrErr) {
+		keys := make([]string, 0, len(attrErr.Errors))
+		for key := range attrErr.Errors {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			errs = append(errs, fmt.Sprintf("atrybut %s: %s", key, attrErr.Errors[key]))
+		}
+		return nil, errs
+	}
+	if err != nil {
+		return nil, append(errs, err.Error())
+	}
+	if len(attributes) == 0 {
+		return nil, errs
+	}
+
+	return attributes, errs
+}
+
+func collectSerials(rows []ImportRow) []string {
+	serials := []string{}
+	for _, row := range rows {
+		if row.Serial != "" {
+			serials = append(serials, row.Serial)
+		}
+	}
+	return serials
+}
@@ -0,0 +1,111 @@
+package imports
+
+import (
+	"strings"
+	"testing"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCatalog() *Catalog {
+	return &Catalog{
+		Categories: []models.ItemCategory{
+			{ID: 1, Name: "laptop", Label: "Laptop", PyrID: "LT", Type: "asset"},
+			{ID: 2, Name: "przedluzacz", Label: "Przedłużacz", PyrID: "PR", Type: "stock"},
+		},
+		Locations: []models.Location{
+			{ID: 1, Name: "Magazyn"},
+			{ID: 4, Name: "Hala A"},
+		},
+		Origins: []models.Origin{
+			{ID: 1, Slug: "probis", Name: "Probis", IsRental: true, Active: true},
+			{ID: 2, Slug: "netland", Name: "Netland", IsRental: true, Active: true},
+			{ID: 3, Slug: "stara-firma", Name: "Stara firma", Active: false},
+		},
+		ExistingSerials: map[string]bool{"SN-OLD": true},
+		Attributes:      map[int][]models.CategoryAttribute{},
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	content := "Kategoria;Numer_seryjny;Pochodzenie;Lokalizacja;Ilość\nLT;SN1;probis;Hala A;\n;;;;\nPR;;netland;;5\n"
+
+	rows, err := ParseCSV(strings.NewReader(content))
+
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, ImportRow{Line: 2, Category: "LT", Serial: "SN1", Origin: "probis", Location: "Hala A"}, rows[0])
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Equal(t, "5", rows[1].Quantity)
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("serial,origin\nSN1,probis\n"))
+
+	assert.Error(t, err)
+}
+
+func TestValidateRows(t *testing.T) {
+	rows := []ImportRow{
+		{Line: 2, Category: "lt", Serial: "SN1", Origin: "Probis", Location: "hala a"},
+		{Line: 3, Category: "Laptop", Serial: "SN1", Origin: "probis"},
+		{Line: 4, Category: "LT", Serial: "SN-OLD", Origin: "probis"},
+		{Line: 5, Category: "XX", Origin: "unknown"},
+		{Line: 6, Category: "2", Origin: "netland", Quantity: "10"},
+		{Line: 7, Category: "PR", Origin: "netland"},
+		{Line: 8, Category: "PR", Origin: "Stara firma", Quantity: "1"},
+	}
+
+	results := ValidateRows(rows, testCatalog())
+
+	assert.True(t, results[0].IsValid())
+	assert.Equal(t, RowKindAsset, results[0].Kind)
+	assert.Equal(t, 4, results[0].LocationID)
+	assert.Equal(t, "probis", results[0].Origin)
+
+	assert.Contains(t, results[1].Errors[0], "powtarza się w wierszu 2")
+	assert.Contains(t, results[2].Errors[0], "już zarejestrowany")
+	assert.Len(t, results[3].Errors, 2)
+
+	assert.True(t, results[4].IsValid())
+	assert.Equal(t, RowKindStock, results[4].Kind)
+	assert.Equal(t, 10, results[4].Quantity)
+	assert.Equal(t, 1, results[4].LocationID)
+
+	assert.False(t, results[5].IsValid())
+
+	assert.Equal(t, []string{"nieprawidłowe pochodzenie: Stara firma"}, results[6].Errors)
+}
+
+func TestParseCSVAttributeColumns(t *testing.T) {
+	content := "category,origin,attr:ram_gb,Atrybut:Color\nLT,probis,16,\n"
+
+	rows, err := ParseCSV(strings.NewReader(content))
+
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, map[string]string{"ram_gb": "16"}, rows[0].Attributes)
+}
+
+func TestValidateRowsAttributes(t *testing.T) {
+	catalog := testCatalog()
+	catalog.Attributes[1] = []models.CategoryAttribute{
+		{Key: "ram_gb", Type: models.AttributeTypeNumber, Required: true},
+		{Key: "color", Type: models.AttributeTypeString},
+	}
+	rows := []ImportRow{
+		{Line: 2, Category: "LT", Origin: "probis", Attributes: map[string]string{"ram_gb": "16"}},
+		{Line: 3, Category: "LT", Origin: "probis", Attributes: map[string]string{"color": "black"}},
+		{Line: 4, Category: "LT", Origin: "probis", Attributes: map[string]string{"ram_gb": "dużo", "cpu": "i7"}},
+		{Line: 5, Category: "PR", Origin: "netland", Quantity: "1", Attributes: map[string]string{"ram_gb": "16"}},
+	}
+
+	results := ValidateRows(rows, catalog)
+
+	assert.True(t, results[0].IsValid())
+	assert.Equal(t, models.AssetAttributes{"ram_gb": 16.0}, results[0].Attributes)
+	assert.Equal(t, []string{"atrybut ram_gb: atrybut jest wymagany"}, results[1].Errors)
+	assert.Equal(t, []string{"atrybut cpu: nieznany atrybut", "atrybut ram_gb: wartość musi być liczbą"}, results[2].Errors)
+	assert.Equal(t, []string{"pozycje magazynowe nie mają atrybutów"}, results[3].Errors)
+}
@@ -31,6 +31,23 @@ func (s *InventoryLog) CreateDeliveryLocationAssetLog(action string, asset *mode
 	)
 }
 
+// CreateDeliveryGeofenceLogEntry flags a delivery recorded farther from the destination than its geofence allows
+func (s *InventoryLog) CreateDeliveryGeofenceLogEntry(transferID int, toLocationID int, location models.DeliveryLocation) {
+	s.a.Log(
+		"delivery_outside_geofence",
+		map[string]interface{}{
+			"transfer_id":    transferID,
+			"to_location_id": toLocationID,
+			"latitude":       location.Lat,
+			"longitude":      location.Lng,
+			"timestamp":      location.Timestamp,
+			"distance":       location.Distance,
+			"msg":            "Dostawa zarejestrowana poza obszarem lokalizacji docelowej",
+		},
+		&models.Transfer{ID: transferID},
+	)
+}
+
 func (s *InventoryLog) CreateAssetAuditLogEntry(action string, asset *models.Asset, msg string) {
 	s.a.Log(
 		action,
@@ -50,6 +67,16 @@ func (s *InventoryLog) CreateTransferAuditLogEntry(action string, ts *models.Tra
 			"assetsMessage":   "Asset moved to transfer locations",
 			"stocksMessage":   "Stock Items moved to transfer locations",
 		},
+		"partially_delivered": {
+			"transferMessage": "Transfer partially delivered",
+			"assetsMessage":   "Assets delivered in part, missing ones wait for confirmation",
+			"stocksMessage":   "Stock Items delivered in part, shortfalls wait for confirmation",
+		},
+		"delivery_written_off": {
+			"transferMessage": "Transfer shortfalls written off",
+			"assetsMessage":   "Missing assets retired as lost",
+			"stocksMessage":   "Missing Stock Items written off",
+		},
 		"in_transfer": {
 			"transferMessage": "Transfer registered",
 			"assetsMessage":   "Assets in transport",
@@ -110,6 +137,32 @@ func (s *InventoryLog) CreateTransferAuditLogEntry(action string, ts *models.Tra
 	}
 }
 
+func (s *InventoryLog) CreateShipmentLogEntry(action string, shipment *models.Shipment) {
+	messages := map[string]string{
+		"create":      "Przesyłka wieloetapowa utworzona",
+		"leg_started": "Rozpoczęto kolejny etap przesyłki",
+		"completed":   "Przesyłka dotarła do miejsca docelowego",
+		"cancelled":   "Przesyłka anulowana",
+	}
+
+	msg, ok := messages[action]
+	if !ok {
+		return
+	}
+
+	s.a.Log(
+		action,
+		map[string]interface{}{
+			"shipment_id":      shipment.ID,
+			"from_location_id": shipment.FromLocationID,
+			"to_location_id":   shipment.ToLocationID,
+			"current_leg":      shipment.CurrentLeg,
+			"msg":              msg,
+		},
+		shipment,
+	)
+}
+
 func (s *InventoryLog) CreateTransferUserLogEntry(action string, transferID int, user *models.TransferUser) {
 	s.a.Log(
 		action,
@@ -121,3 +174,20 @@ func (s *InventoryLog) CreateTransferUserLogEntry(action string, transferID int,
 		user,
 	)
 }
+
+func (s *InventoryLog) CreateAssetStatusChangeLogEntries(changes []models.AssetStatusChange, transferID int) {
+	for _, change := range changes {
+		asset := models.Asset{ID: change.AssetID}
+		data := map[string]interface{}{
+			"asset_id": change.AssetID,
+			"from":     change.From,
+			"to":       change.To,
+			"msg":      "Zmiana statusu zasobu",
+		}
+		if transferID != 0 {
+			data["transfer_id"] = transferID
+		}
+
+		s.a.Log("status_change", data, &asset)
+	}
+}
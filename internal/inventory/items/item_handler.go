@@ -54,6 +54,7 @@ func (h *ItemHandler) RetrieveItemList(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	fetchItemsQuery.Attributes = c.QueryMap("attributes")
 
 	items, err := h.service.fetchItemList(fetchItemsQuery)
 
@@ -1,6 +1,8 @@
 package items
 
 import (
+	"warehouse/pkg/metadata"
+
 	"github.com/doug-martin/goqu/v9"
 )
 
@@ -14,6 +16,11 @@ type retrieveItemListQuery struct {
 	CategoryID    *int   `form:"category_id" binding:"omitempty,number"`
 	CategoryType  string `form:"category_type"`
 	CategoryLabel string `form:"category_label"`
+	// Attributes filters assets by custom attribute values, passed as attributes[key]=value
+	Attributes map[string]string `form:"-"`
+	// Retired lists only retired assets, optionally narrowed down by retirement reason
+	Retired          bool   `form:"retired"`
+	RetirementReason string `form:"retirement_reason" binding:"omitempty,oneof=lost stolen broken returned_to_origin"`
 }
 
 func (q *retrieveItemListQuery) AddCondition(key string, value interface{}) {
@@ -45,10 +52,28 @@ func (q *retrieveItemListQuery) BuildConditions(aliases map[string]string) goqu.
 	if q.CategoryLabel != "" {
 		conditions[aliases["category_label"]] = q.CategoryLabel
 	}
+	if q.OnlyRetired() {
+		conditions[aliases["status"]] = string(metadata.StatusRetired)
+		if q.RetirementReason != "" {
+			conditions[aliases["reason"]] = q.RetirementReason
+		}
+	}
 
 	return conditions
 }
 
 func (q *retrieveItemListQuery) HasConditions() bool {
-	return len(q.LocationIDs) > 0 || q.CategoryID != nil || q.CategoryLabel != ""
+	return len(q.LocationIDs) > 0 || q.CategoryID != nil || q.CategoryLabel != "" || len(q.Attributes) > 0 || q.OnlyRetired()
+}
+
+func (q *retrieveItemListQuery) AttributeFilters() map[string]string {
+	return q.Attributes
+}
+
+func (q *retrieveItemListQuery) IncludeRetired() bool {
+	return q.OnlyRetired()
+}
+
+func (q *retrieveItemListQuery) OnlyRetired() bool {
+	return q.Retired || q.RetirementReason != ""
 }
@@ -57,8 +57,16 @@ func (s *ItemService) fetchItemList(conditions retrieveItemListQuery) ([]interfa
 	case "asset":
 		return s.fetchByCategory(conditions, "asset")
 	case "stock":
+		// stock rows are never retired
+		if conditions.OnlyRetired() {
+			return nil, nil
+		}
 		return s.fetchByCategory(conditions, "stock")
 	default:
+		// only assets carry custom attributes and can be retired
+		if len(conditions.Attributes) > 0 || conditions.OnlyRetired() {
+			return s.fetchByCategory(conditions, "asset")
+		}
 		return s.fetchCombinedItems(conditions)
 	}
 }
@@ -0,0 +1,110 @@
+package kits
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KitHandler struct {
+	Service *KitService
+}
+
+func NewHandler(r *repository.Repository, ar *assets.AssetsRepository, a *auditlog.Auditlog) *KitHandler {
+	return &KitHandler{
+		Service: NewService(r, NewRepository(r), ar, a),
+	}
+}
+
+func (h *KitHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/kits", security.Authorize("user"), h.GetKits)
+	router.GET("/kits/:id", security.Authorize("user"), h.GetKit)
+	router.PUT("/kits/:id", security.Authorize("moderator"), h.UpdateKit)
+	router.DELETE("/kits/:id", security.Authorize("moderator"), h.DeleteKit)
+}
+
+func (h *KitHandler) GetKits(c *gin.Context) {
+	kits, err := h.Service.GetKits()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania zestawów", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, kits)
+}
+
+func (h *KitHandler) GetKit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	kit, err := h.Service.GetKit(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania zestawu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kit)
+}
+
+func (h *KitHandler) UpdateKit(c *gin.Context) {
+	var req UpdateKitRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	kit, err := h.Service.UpdateKit(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zapisać zestawu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kit)
+}
+
+func (h *KitHandler) DeleteKit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	if err := h.Service.DeleteKit(id); err != nil {
+		h.handleError(c, "Nie udało się rozwiązać zestawu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Zestaw został rozwiązany"})
+}
+
+func (h *KitHandler) handleError(c *gin.Context, msg string, err error) {
+	if _, ok := err.(*custom_error.UniqueViolationError); ok {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrAssetNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrNestedKit):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrInvalidKitComponent), errors.Is(err, ErrInvalidStockCategory):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
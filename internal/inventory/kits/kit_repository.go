@@ -0,0 +1,165 @@
+package kits
+
+import (
+	"fmt"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+)
+
+type KitRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *KitRepository {
+	return &KitRepository{
+		repository: r,
+	}
+}
+
+// GetKitIDs returns IDs of all assets which have components attached
+func (r *KitRepository) GetKitIDs() ([]int, error) {
+	ids := []int{}
+	query := r.repository.GoquDBWrapper.
+		From(
+			r.repository.GoquDBWrapper.Select("kit_item_id").From("kit_components").
+				Union(r.repository.GoquDBWrapper.Select("kit_item_id").From("kit_stock_components")).
+				As("k"),
+		).
+		Select("kit_item_id").
+		Order(goqu.I("kit_item_id").Asc())
+
+	if err := query.Executor().ScanVals(&ids); err != nil {
+		return nil, fmt.Errorf("failed to query kits: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *KitRepository) GetKitComponents(kitIDs []int) ([]models.KitComponent, error) {
+	if len(kitIDs) == 0 {
+		return []models.KitComponent{}, nil
+	}
+	return r.getKitComponentsBy(goqu.Ex{"kc.kit_item_id": kitIDs})
+}
+
+// GetKitMemberships returns kit components rows of given assets, used to find kits they belong to
+func (r *KitRepository) GetKitMemberships(assetIDs []int) ([]models.KitComponent, error) {
+	if len(assetIDs) == 0 {
+		return []models.KitComponent{}, nil
+	}
+	return r.getKitComponentsBy(goqu.Ex{"kc.component_item_id": assetIDs})
+}
+
+func (r *KitRepository) GetKitStockComponents(kitIDs []int) ([]models.KitStockComponent, error) {
+	components := []models.KitStockComponent{}
+	if len(kitIDs) == 0 {
+		return components, nil
+	}
+
+	err := r.repository.GoquDBWrapper.Select(
+		"ksc.kit_item_id",
+		"ksc.item_category_id",
+		goqu.I("c.label").As("category_label"),
+		"ksc.quantity",
+	).
+		From(goqu.T("kit_stock_components").As("ksc")).
+		InnerJoin(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"ksc.item_category_id": goqu.I("c.id")})).
+		Where(goqu.Ex{"ksc.kit_item_id": kitIDs}).
+		Order(goqu.I("ksc.id").Asc()).
+		Executor().
+		ScanStructs(&components)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query kit stock components: %w", err)
+	}
+
+	return components, nil
+}
+
+// ReplaceKit swaps all components of a kit, an empty request dissolves the kit
+func (r *KitRepository) ReplaceKit(kitID int, assetIDs []int, stocks []KitStockComponentRequest) error {
+	return repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		if _, err := tx.Delete("kit_components").Where(goqu.Ex{"kit_item_id": kitID}).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to clear kit components: %w", err)
+		}
+		if _, err := tx.Delete("kit_stock_components").Where(goqu.Ex{"kit_item_id": kitID}).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to clear kit stock components: %w", err)
+		}
+
+		if len(assetIDs) > 0 {
+			rows := make([]interface{}, 0, len(assetIDs))
+			for _, assetID := range assetIDs {
+				rows = append(rows, goqu.Record{"kit_item_id": kitID, "component_item_id": assetID})
+			}
+			if _, err := tx.Insert("kit_components").Rows(rows...).Executor().Exec(); err != nil {
+				if pqErr, ok := err.(*pq.Error); ok {
+					return custom_error.WrapDBError("Zasób należy już do innego zestawu", string(pqErr.Code))
+				}
+				return fmt.Errorf("failed to insert kit components: %w", err)
+			}
+		}
+
+		if len(stocks) > 0 {
+			rows := make([]interface{}, 0, len(stocks))
+			for _, stock := range stocks {
+				rows = append(rows, goqu.Record{
+					"kit_item_id":      kitID,
+					"item_category_id": stock.CategoryID,
+					"quantity":         stock.Quantity,
+				})
+			}
+			if _, err := tx.Insert("kit_stock_components").Rows(rows...).Executor().Exec(); err != nil {
+				if pqErr, ok := err.(*pq.Error); ok {
+					return custom_error.WrapDBError("Zduplikowana kategoria w zestawie", string(pqErr.Code))
+				}
+				return fmt.Errorf("failed to insert kit stock components: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// RemoveAssetFromKits drops kit membership of an asset leaving the inventory, a kit parent loses all of its components
+func (r *KitRepository) RemoveAssetFromKits(tx *goqu.TxDatabase, assetID int) error {
+	componentsQuery := tx.Delete("kit_components").
+		Where(goqu.Or(goqu.Ex{"kit_item_id": assetID}, goqu.Ex{"component_item_id": assetID}))
+	if _, err := componentsQuery.Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to remove kit components: %w", err)
+	}
+	if _, err := tx.Delete("kit_stock_components").Where(goqu.Ex{"kit_item_id": assetID}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to remove kit stock components: %w", err)
+	}
+
+	return nil
+}
+
+func (r *KitRepository) getKitComponentsBy(condition goqu.Ex) ([]models.KitComponent, error) {
+	components := []models.KitComponent{}
+
+	err := r.repository.GoquDBWrapper.Select(
+		"kc.kit_item_id",
+		goqu.I("kp.pyr_code").As("kit_pyr_code"),
+		goqu.I("i.id").As("item_id"),
+		"i.pyr_code",
+		goqu.I("c.label").As("category_label"),
+		"i.status",
+		"i.location_id",
+	).
+		From(goqu.T("kit_components").As("kc")).
+		InnerJoin(goqu.T("items").As("i"), goqu.On(goqu.Ex{"kc.component_item_id": goqu.I("i.id")})).
+		InnerJoin(goqu.T("items").As("kp"), goqu.On(goqu.Ex{"kc.kit_item_id": goqu.I("kp.id")})).
+		LeftJoin(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"i.item_category_id": goqu.I("c.id")})).
+		Where(condition).
+		Order(goqu.I("kc.id").Asc()).
+		Executor().
+		ScanStructs(&components)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query kit components: %w", err)
+	}
+
+	return components, nil
+}
@@ -0,0 +1,12 @@
+package kits
+
+type KitStockComponentRequest struct {
+	CategoryID int `json:"category_id" binding:"required"`
+	Quantity   int `json:"quantity" binding:"required,gte=1"`
+}
+
+type UpdateKitRequest struct {
+	ID     int                        `uri:"id" binding:"required"`
+	Assets []int                      `json:"assets"`
+	Stocks []KitStockComponentRequest `json:"stocks" binding:"dive"`
+}
@@ -0,0 +1,213 @@
+package kits
+
+import (
+	"errors"
+	"fmt"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+)
+
+var (
+	ErrAssetNotFound        = errors.New("asset not found")
+	ErrNestedKit            = errors.New("kits cannot be nested")
+	ErrInvalidKitComponent  = errors.New("kit cannot contain itself")
+	ErrInvalidStockCategory = errors.New("stock components must use stock categories")
+)
+
+type KitService struct {
+	r  *repository.Repository
+	kr *KitRepository
+	ar *assets.AssetsRepository
+	a  *auditlog.Auditlog
+}
+
+func NewService(r *repository.Repository, kr *KitRepository, ar *assets.AssetsRepository, a *auditlog.Auditlog) *KitService {
+	return &KitService{
+		r:  r,
+		kr: kr,
+		ar: ar,
+		a:  a,
+	}
+}
+
+func (s *KitService) GetKit(kitID int) (*models.Kit, error) {
+	asset, err := s.ar.GetAsset(kitID)
+	if err != nil {
+		return nil, err
+	}
+	if asset.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+
+	kits, err := s.buildKits([]models.Asset{*asset})
+	if err != nil {
+		return nil, err
+	}
+
+	return &kits[0], nil
+}
+
+func (s *KitService) GetKits() ([]models.Kit, error) {
+	kitIDs, err := s.kr.GetKitIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(kitIDs) == 0 {
+		return []models.Kit{}, nil
+	}
+
+	conditions := repository.NewQueryBuilder()
+	conditions.AddCondition("asset_ids", kitIDs)
+	parents, err := s.ar.GetAssetsBy(conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildKits(*parents)
+}
+
+// UpdateKit replaces kit contents, components must be plain assets which do not belong to another kit
+func (s *KitService) UpdateKit(req UpdateKitRequest) (*models.Kit, error) {
+	parent, err := s.ar.GetAsset(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+
+	memberships, err := s.kr.GetKitMemberships([]int{req.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(memberships) > 0 {
+		return nil, fmt.Errorf("%w: asset %d is a component of kit %d", ErrNestedKit, req.ID, memberships[0].KitID)
+	}
+
+	assetIDs := uniqueIDs(req.Assets)
+	for _, assetID := range assetIDs {
+		if assetID == req.ID {
+			return nil, ErrInvalidKitComponent
+		}
+	}
+
+	if len(assetIDs) > 0 {
+		conditions := repository.NewQueryBuilder()
+		conditions.AddCondition("asset_ids", assetIDs)
+		components, err := s.ar.GetAssetsBy(conditions)
+		if err != nil {
+			return nil, err
+		}
+		if components == nil || len(*components) != len(assetIDs) {
+			return nil, ErrAssetNotFound
+		}
+
+		nested, err := s.kr.GetKitComponents(assetIDs)
+		if err != nil {
+			return nil, err
+		}
+		nestedStocks, err := s.kr.GetKitStockComponents(assetIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(nested) > 0 || len(nestedStocks) > 0 {
+			return nil, fmt.Errorf("%w: component is a kit itself", ErrNestedKit)
+		}
+	}
+
+	for _, stock := range req.Stocks {
+		categoryType, err := s.r.GetCategoryType(stock.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if categoryType != "stock" {
+			return nil, fmt.Errorf("%w: category %d", ErrInvalidStockCategory, stock.CategoryID)
+		}
+	}
+
+	if err := s.kr.ReplaceKit(req.ID, assetIDs, req.Stocks); err != nil {
+		return nil, err
+	}
+
+	kit, err := s.GetKit(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(
+		"update",
+		map[string]interface{}{
+			"assets": assetIDs,
+			"stocks": req.Stocks,
+			"msg":    "Zaktualizowano skład zestawu",
+		},
+		kit,
+	)
+
+	return kit, nil
+}
+
+func (s *KitService) DeleteKit(kitID int) error {
+	if err := s.kr.ReplaceKit(kitID, nil, nil); err != nil {
+		return err
+	}
+
+	go s.a.Log(
+		"remove",
+		map[string]interface{}{
+			"msg": "Rozwiązano zestaw",
+		},
+		&models.Kit{Asset: models.Asset{ID: kitID}},
+	)
+
+	return nil
+}
+
+func (s *KitService) buildKits(parents []models.Asset) ([]models.Kit, error) {
+	kitIDs := make([]int, len(parents))
+	for i, parent := range parents {
+		kitIDs[i] = parent.ID
+	}
+
+	components, err := s.kr.GetKitComponents(kitIDs)
+	if err != nil {
+		return nil, err
+	}
+	stockComponents, err := s.kr.GetKitStockComponents(kitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	kits := make([]models.Kit, len(parents))
+	index := make(map[int]*models.Kit, len(parents))
+	for i, parent := range parents {
+		kits[i] = models.Kit{
+			Asset:           parent,
+			Components:      []models.KitComponent{},
+			StockComponents: []models.KitStockComponent{},
+		}
+		index[parent.ID] = &kits[i]
+	}
+	for _, component := range components {
+		index[component.KitID].Components = append(index[component.KitID].Components, component)
+	}
+	for _, component := range stockComponents {
+		index[component.KitID].StockComponents = append(index[component.KitID].StockComponents, component)
+	}
+
+	return kits, nil
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,81 @@
+package labels
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LabelHandler struct {
+	Service *LabelService
+}
+
+func NewHandler(ar *assets.AssetsRepository) *LabelHandler {
+	return &LabelHandler{
+		Service: NewService(ar),
+	}
+}
+
+func (h *LabelHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/assets/:id/label", security.Authorize("user"), h.GetAssetLabel)
+	router.POST("/assets/labels", security.Authorize("user"), h.GenerateLabels)
+}
+
+func (h *LabelHandler) GetAssetLabel(c *gin.Context) {
+	assetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	req := GenerateLabelsRequest{
+		AssetIDs:  []int{assetID},
+		Symbology: c.DefaultQuery("symbology", SymbologyQR),
+		Layout:    LabelLayout{Columns: 1, Rows: 1},
+	}
+
+	h.render(c, req)
+}
+
+func (h *LabelHandler) GenerateLabels(c *gin.Context) {
+	var req GenerateLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if !req.HasSelection() {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Wymagane asset_ids, category_id lub location_id"})
+		return
+	}
+
+	h.render(c, req)
+}
+
+func (h *LabelHandler) render(c *gin.Context, req GenerateLabelsRequest) {
+	if req.Symbology == "" {
+		req.Symbology = SymbologyQR
+	}
+	if req.Symbology != SymbologyQR && req.Symbology != SymbologyCode128 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieobsługiwany typ kodu", "details": "symbology must be qr or code128"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.Service.RenderAssetLabels(&buf, req); err != nil {
+		if errors.Is(err, ErrNoLabels) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Brak zasobów do wydruku", "details": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nie udało się wygenerować etykiet", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename=labels.pdf")
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
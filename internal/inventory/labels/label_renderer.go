@@ -0,0 +1,205 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	SymbologyQR      = "qr"
+	SymbologyCode128 = "code128"
+)
+
+// pixels per label millimeter used when rasterizing codes, enough for thermal and laser printers
+const rasterDensity = 12
+
+type Label struct {
+	PyrCode       string
+	CategoryLabel string
+}
+
+// LabelLayout describes the label grid on a single page, sizes are in millimeters
+type LabelLayout struct {
+	PageSize string  `json:"page_size"`
+	Columns  int     `json:"columns"`
+	Rows     int     `json:"rows"`
+	Margin   float64 `json:"margin_mm"`
+	Gap      float64 `json:"gap_mm"`
+}
+
+func DefaultLabelLayout() LabelLayout {
+	return LabelLayout{
+		PageSize: "A4",
+		Columns:  3,
+		Rows:     8,
+		Margin:   8,
+		Gap:      2,
+	}
+}
+
+// WithDefaults fills empty fields of the layout with DefaultLabelLayout values
+func (l LabelLayout) WithDefaults() LabelLayout {
+	d := DefaultLabelLayout()
+	if l.PageSize == "" {
+		l.PageSize = d.PageSize
+	}
+	if l.Columns == 0 {
+		l.Columns = d.Columns
+	}
+	if l.Rows == 0 {
+		l.Rows = d.Rows
+	}
+	if l.Margin == 0 {
+		l.Margin = d.Margin
+	}
+	if l.Gap == 0 {
+		l.Gap = d.Gap
+	}
+	return l
+}
+
+func (l LabelLayout) Validate() error {
+	if l.PageSize != "A4" && l.PageSize != "A5" && l.PageSize != "Letter" {
+		return fmt.Errorf("unsupported page size: %s", l.PageSize)
+	}
+	if l.Columns < 1 || l.Columns > 10 || l.Rows < 1 || l.Rows > 20 {
+		return fmt.Errorf("label grid must be between 1x1 and 10x20")
+	}
+	if l.Margin < 0 || l.Gap < 0 {
+		return fmt.Errorf("margin and gap cannot be negative")
+	}
+	return nil
+}
+
+// cellSize returns width and height of a single label for the given page dimensions
+func (l LabelLayout) cellSize(pageW, pageH float64) (float64, float64) {
+	w := (pageW - 2*l.Margin - float64(l.Columns-1)*l.Gap) / float64(l.Columns)
+	h := (pageH - 2*l.Margin - float64(l.Rows-1)*l.Gap) / float64(l.Rows)
+	return w, h
+}
+
+func RenderLabels(w io.Writer, labels []Label, layout LabelLayout, symbology string) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+
+	pdf := gofpdf.New("P", "mm", layout.PageSize, "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageW, pageH := pdf.GetPageSize()
+	cellW, cellH := layout.cellSize(pageW, pageH)
+	if cellW < 15 || cellH < 10 {
+		return fmt.Errorf("labels too small (%.1fx%.1f mm), reduce the grid", cellW, cellH)
+	}
+
+	perPage := layout.Columns * layout.Rows
+	for i, label := range labels {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+
+		pos := i % perPage
+		x := layout.Margin + float64(pos%layout.Columns)*(cellW+layout.Gap)
+		y := layout.Margin + float64(pos/layout.Columns)*(cellH+layout.Gap)
+
+		if err := drawLabel(pdf, tr, label, symbology, x, y, cellW, cellH); err != nil {
+			return fmt.Errorf("unable to render label %s: %w", label.PyrCode, err)
+		}
+	}
+
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	return pdf.Output(w)
+}
+
+func drawLabel(pdf *gofpdf.Fpdf, tr func(string) string, label Label, symbology string, x, y, w, h float64) error {
+	const padding = 1.5
+	const textHeight = 4.0
+
+	innerW := w - 2*padding
+	innerH := h - 2*padding
+
+	pdf.SetFont("Helvetica", "B", 9)
+
+	if symbology == SymbologyCode128 {
+		codeH := innerH - 2*textHeight
+		if err := placeCode(pdf, label.PyrCode, symbology, x+padding, y+padding, innerW, codeH); err != nil {
+			return err
+		}
+		pdf.SetXY(x+padding, y+padding+codeH)
+		pdf.CellFormat(innerW, textHeight, tr(label.PyrCode), "", 2, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(innerW, textHeight, tr(label.CategoryLabel), "", 0, "C", false, 0, "")
+		return nil
+	}
+
+	size := innerH
+	if size > innerW/2 {
+		size = innerW / 2
+	}
+	if err := placeCode(pdf, label.PyrCode, symbology, x+padding, y+padding, size, size); err != nil {
+		return err
+	}
+
+	textX := x + padding + size + padding
+	textW := innerW - size - padding
+	pdf.SetXY(textX, y+padding)
+	pdf.MultiCell(textW, textHeight, tr(label.PyrCode), "", "L", false)
+	pdf.SetX(textX)
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.MultiCell(textW, textHeight-0.5, tr(label.CategoryLabel), "", "L", false)
+
+	return nil
+}
+
+// placeCode rasterizes the code and places it in the given box
+func placeCode(pdf *gofpdf.Fpdf, payload string, symbology string, x, y, w, h float64) error {
+	var code barcode.Barcode
+	var err error
+
+	switch symbology {
+	case SymbologyCode128:
+		code, err = code128.Encode(payload)
+	default:
+		code, err = qr.Encode(payload, qr.M, qr.Auto)
+	}
+	if err != nil {
+		return err
+	}
+
+	pxW, pxH := int(w*rasterDensity), int(h*rasterDensity)
+	if symbology != SymbologyCode128 {
+		pxH = pxW
+	}
+	if code, err = barcode.Scale(code, pxW, pxH); err != nil {
+		return err
+	}
+
+	// gofpdf does not support 16-bit PNG, which is what barcode images encode to
+	gray := image.NewGray(code.Bounds())
+	draw.Draw(gray, gray.Bounds(), code, code.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gray); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s", symbology, payload)
+	pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG"}, &buf)
+	pdf.ImageOptions(name, x, y, w, h, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	return pdf.Error()
+}
@@ -0,0 +1,47 @@
+package labels
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelLayoutWithDefaults(t *testing.T) {
+	layout := LabelLayout{Columns: 2}.WithDefaults()
+
+	assert.Equal(t, "A4", layout.PageSize)
+	assert.Equal(t, 2, layout.Columns)
+	assert.Equal(t, 8, layout.Rows)
+	assert.NoError(t, layout.Validate())
+}
+
+func TestLabelLayoutValidate(t *testing.T) {
+	assert.Error(t, LabelLayout{PageSize: "B7", Columns: 1, Rows: 1}.Validate())
+	assert.Error(t, LabelLayout{PageSize: "A4", Columns: 11, Rows: 1}.Validate())
+	assert.Error(t, LabelLayout{PageSize: "A4", Columns: 1, Rows: 1, Margin: -1}.Validate())
+}
+
+func TestRenderLabels(t *testing.T) {
+	labels := []Label{
+		{PyrCode: "PYR-LT1", CategoryLabel: "Laptop"},
+		{PyrCode: "PYR-LT2", CategoryLabel: "Laptop"},
+	}
+
+	for _, symbology := range []string{SymbologyQR, SymbologyCode128} {
+		t.Run(symbology, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := RenderLabels(&buf, labels, DefaultLabelLayout(), symbology)
+
+			assert.NoError(t, err)
+			assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF")))
+		})
+	}
+}
+
+func TestRenderLabelsTooSmall(t *testing.T) {
+	var buf bytes.Buffer
+	err := RenderLabels(&buf, []Label{{PyrCode: "PYR-LT1"}}, LabelLayout{PageSize: "A5", Columns: 10, Rows: 20}, SymbologyQR)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,13 @@
+package labels
+
+type GenerateLabelsRequest struct {
+	AssetIDs   []int       `json:"asset_ids"`
+	CategoryID int         `json:"category_id"`
+	LocationID int         `json:"location_id"`
+	Symbology  string      `json:"symbology"`
+	Layout     LabelLayout `json:"layout"`
+}
+
+func (r *GenerateLabelsRequest) HasSelection() bool {
+	return len(r.AssetIDs) > 0 || r.CategoryID != 0 || r.LocationID != 0
+}
@@ -0,0 +1,60 @@
+package labels
+
+import (
+	"errors"
+	"io"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/repository"
+	"warehouse/pkg/models"
+)
+
+var ErrNoLabels = errors.New("no assets with PYR code match the selection")
+
+type LabelService struct {
+	ar *assets.AssetsRepository
+}
+
+func NewService(ar *assets.AssetsRepository) *LabelService {
+	return &LabelService{ar: ar}
+}
+
+func (s *LabelService) RenderAssetLabels(w io.Writer, req GenerateLabelsRequest) error {
+	conditions := repository.NewQueryBuilder()
+	if len(req.AssetIDs) > 0 {
+		conditions.AddCondition("asset_ids", req.AssetIDs)
+	}
+	if req.CategoryID != 0 {
+		conditions.AddCondition("category_id", req.CategoryID)
+	}
+	if req.LocationID != 0 {
+		conditions.AddCondition("location_ids", req.LocationID)
+	}
+
+	assets, err := s.ar.GetAssetsBy(conditions)
+	if err != nil {
+		return err
+	}
+
+	labels := buildLabels(*assets)
+	if len(labels) == 0 {
+		return ErrNoLabels
+	}
+
+	return RenderLabels(w, labels, req.Layout.WithDefaults(), req.Symbology)
+}
+
+// buildLabels skips assets which have no PYR code yet, the label would not be resolvable
+func buildLabels(assets []models.Asset) []Label {
+	labels := make([]Label, 0, len(assets))
+	for _, asset := range assets {
+		if asset.PyrCode == "" {
+			continue
+		}
+		labels = append(labels, Label{
+			PyrCode:       asset.PyrCode,
+			CategoryLabel: asset.Category.Label,
+		})
+	}
+
+	return labels
+}
@@ -0,0 +1,151 @@
+package loans
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/repository"
+	"warehouse/internal/users"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoanHandler struct {
+	Service *LoanService
+}
+
+func NewHandler(r *repository.Repository, ar *assets.AssetsRepository, ur users.UserRepository, a *auditlog.Auditlog) *LoanHandler {
+	return &LoanHandler{
+		Service: NewService(r, NewRepository(r), ar, ur, a),
+	}
+}
+
+func (h *LoanHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/loans", security.Authorize("user"), h.GetLoans)
+	router.GET("/loans/overdue", security.Authorize("user"), h.GetOverdueLoans)
+	router.GET("/loans/:id", security.Authorize("user"), h.GetLoan)
+	router.POST("/loans", security.Authorize("user"), h.CheckOut)
+	router.POST("/loans/check-in", security.Authorize("user"), h.CheckInByPyrCode)
+	router.PATCH("/loans/:id/check-in", security.Authorize("user"), h.CheckIn)
+}
+
+func (h *LoanHandler) GetLoans(c *gin.Context) {
+	var query RetrieveLoanListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	loans, err := h.Service.GetLoans(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania wypożyczeń", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
+
+func (h *LoanHandler) GetOverdueLoans(c *gin.Context) {
+	loans, err := h.Service.GetOverdueLoans()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania przeterminowanych wypożyczeń", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loans)
+}
+
+func (h *LoanHandler) GetLoan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	loan, err := h.Service.GetLoan(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania wypożyczenia", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+func (h *LoanHandler) CheckOut(c *gin.Context) {
+	var req CheckOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CheckedOutByID = &userID
+	}
+
+	loan, err := h.Service.CheckOut(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się wydać zasobu", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, loan)
+}
+
+func (h *LoanHandler) CheckIn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	loan, err := h.Service.CheckIn(id, h.currentUserID(c))
+	if err != nil {
+		h.handleError(c, "Nie udało się przyjąć zwrotu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+func (h *LoanHandler) CheckInByPyrCode(c *gin.Context) {
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	loan, err := h.Service.CheckInByPyrCode(req.PyrCode, h.currentUserID(c))
+	if err != nil {
+		h.handleError(c, "Nie udało się przyjąć zwrotu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loan)
+}
+
+func (h *LoanHandler) currentUserID(c *gin.Context) *int {
+	userID, err := security.GetUserIDFromContext(c)
+	if err != nil {
+		return nil
+	}
+
+	return &userID
+}
+
+func (h *LoanHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrAssetNotFound), errors.Is(err, ErrLoanNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrHolderRequired),
+		errors.Is(err, ErrDueDateInvalid), errors.Is(err, ErrAssetIdentifier):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
@@ -0,0 +1,163 @@
+package loans
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+type LoanRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *LoanRepository {
+	return &LoanRepository{
+		repository: r,
+	}
+}
+
+func (r *LoanRepository) InsertLoan(tx *goqu.TxDatabase, req CheckOutRequest) (int, error) {
+	record := goqu.Record{
+		"item_id":           req.AssetID,
+		"user_id":           req.UserID,
+		"recipient_name":    req.RecipientName,
+		"notes":             req.Notes,
+		"checked_out_by_id": req.CheckedOutByID,
+		"due_at":            req.DueAt,
+	}
+
+	var loanID int
+	if _, err := tx.Insert("asset_loans").Rows(record).Returning("id").Executor().ScanVal(&loanID); err != nil {
+		return 0, fmt.Errorf("failed to insert loan: %w", err)
+	}
+
+	return loanID, nil
+}
+
+// LockActiveLoan locks a loan that has not been returned yet, returns nil when there is none
+func (r *LoanRepository) LockActiveLoan(tx *goqu.TxDatabase, loanID int) (*models.AssetLoan, error) {
+	var loan models.AssetLoan
+
+	found, err := tx.Select("id", "item_id", "due_at", "checked_out_at").
+		From("asset_loans").
+		Where(goqu.Ex{"id": loanID, "returned_at": nil}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&loan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock loan: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &loan, nil
+}
+
+func (r *LoanRepository) MarkLoanReturned(tx *goqu.TxDatabase, loanID int, returnedByID *int) error {
+	_, err := tx.Update("asset_loans").
+		Set(goqu.Record{
+			"returned_at":    time.Now(),
+			"returned_by_id": returnedByID,
+		}).
+		Where(goqu.Ex{"id": loanID}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to return loan: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LoanRepository) FindActiveLoanID(assetID int) (int, error) {
+	var loanID int
+
+	found, err := r.repository.GoquDBWrapper.Select("id").
+		From("asset_loans").
+		Where(goqu.Ex{"item_id": assetID, "returned_at": nil}).
+		Executor().
+		ScanVal(&loanID)
+	if err != nil {
+		return 0, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return 0, nil
+	}
+
+	return loanID, nil
+}
+
+func (r *LoanRepository) GetLoan(id int) (*models.AssetLoan, error) {
+	var loan models.AssetLoan
+
+	found, err := r.getLoanQuery().Where(goqu.Ex{"al.id": id}).Executor().ScanStruct(&loan)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &loan, nil
+}
+
+func (r *LoanRepository) GetLoans(query RetrieveLoanListQuery) ([]models.AssetLoan, error) {
+	conditions := goqu.Ex{}
+	if query.Active {
+		conditions["al.returned_at"] = nil
+	}
+	if query.UserID != 0 {
+		conditions["al.user_id"] = query.UserID
+	}
+	if query.AssetID != 0 {
+		conditions["al.item_id"] = query.AssetID
+	}
+
+	return r.scanLoans(r.getLoanQuery().Where(conditions).Order(goqu.I("al.checked_out_at").Desc()))
+}
+
+func (r *LoanRepository) GetOverdueLoans(now time.Time) ([]models.AssetLoan, error) {
+	query := r.getLoanQuery().
+		Where(
+			goqu.Ex{"al.returned_at": nil},
+			goqu.I("al.due_at").Lt(now),
+		).
+		Order(goqu.I("al.due_at").Asc())
+
+	return r.scanLoans(query)
+}
+
+func (r *LoanRepository) scanLoans(query *goqu.SelectDataset) ([]models.AssetLoan, error) {
+	loans := []models.AssetLoan{}
+	if err := query.Executor().ScanStructs(&loans); err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return loans, nil
+}
+
+func (r *LoanRepository) getLoanQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"al.id",
+		"al.item_id",
+		goqu.I("i.pyr_code").As("pyr_code"),
+		"al.user_id",
+		"u.username",
+		"u.fullname",
+		"al.recipient_name",
+		"al.notes",
+		"al.checked_out_by_id",
+		"al.checked_out_at",
+		"al.due_at",
+		"al.returned_by_id",
+		"al.returned_at",
+	).
+		From(goqu.T("asset_loans").As("al")).
+		LeftJoin(goqu.T("items").As("i"), goqu.On(goqu.Ex{"al.item_id": goqu.I("i.id")})).
+		LeftJoin(goqu.T("users").As("u"), goqu.On(goqu.Ex{"al.user_id": goqu.I("u.id")}))
+}
@@ -0,0 +1,23 @@
+package loans
+
+import "time"
+
+type CheckOutRequest struct {
+	AssetID        int       `json:"asset_id"`
+	PyrCode        string    `json:"pyr_code"`
+	UserID         *int      `json:"user_id"`
+	RecipientName  *string   `json:"recipient_name"`
+	DueAt          time.Time `json:"due_at" binding:"required"`
+	Notes          *string   `json:"notes"`
+	CheckedOutByID *int      `json:"-"`
+}
+
+type CheckInRequest struct {
+	PyrCode string `json:"pyr_code" binding:"required"`
+}
+
+type RetrieveLoanListQuery struct {
+	Active  bool `form:"active"`
+	UserID  int  `form:"user_id"`
+	AssetID int  `form:"asset_id"`
+}
@@ -0,0 +1,216 @@
+package loans
+
+import (
+	"errors"
+	"time"
+	"warehouse/internal/inventory/assets"
+	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/repository"
+	"warehouse/internal/users"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrAssetNotFound   = errors.New("asset not found")
+	ErrLoanNotFound    = errors.New("active loan not found")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrHolderRequired  = errors.New("user_id or recipient_name is required")
+	ErrDueDateInvalid  = errors.New("due_at must be in the future")
+	ErrAssetIdentifier = errors.New("asset_id or pyr_code is required")
+)
+
+type LoanService struct {
+	r  *repository.Repository
+	lr *LoanRepository
+	ar *assets.AssetsRepository
+	ur users.UserRepository
+	a  *auditlog.Auditlog
+	il *inventorylog.InventoryLog
+}
+
+func NewService(
+	r *repository.Repository,
+	lr *LoanRepository,
+	ar *assets.AssetsRepository,
+	ur users.UserRepository,
+	a *auditlog.Auditlog,
+) *LoanService {
+	return &LoanService{
+		r:  r,
+		lr: lr,
+		ar: ar,
+		ur: ur,
+		a:  a,
+		il: inventorylog.NewInventoryLog(a),
+	}
+}
+
+func (s *LoanService) CheckOut(req CheckOutRequest) (*models.AssetLoan, error) {
+	if err := s.validateCheckOut(&req); err != nil {
+		return nil, err
+	}
+
+	var loanID int
+	var statusChanges []models.AssetStatusChange
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if statusChanges, err = s.ar.UpdateItemStatus([]int{req.AssetID}, metadata.StatusOnLoan, tx); err != nil {
+			return err
+		}
+		loanID, err = s.lr.InsertLoan(tx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := s.lr.GetLoan(loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, 0)
+	go s.a.Log(
+		"check_out",
+		map[string]interface{}{
+			"asset_id":       loan.AssetID,
+			"user_id":        loan.UserID,
+			"recipient_name": loan.RecipientName,
+			"due_at":         loan.DueAt,
+			"msg":            "Zasób wydany osobie",
+		},
+		loan,
+	)
+
+	return loan, nil
+}
+
+// CheckIn closes the loan and restores the asset status matching its location
+func (s *LoanService) CheckIn(loanID int, returnedByID *int) (*models.AssetLoan, error) {
+	var statusChanges []models.AssetStatusChange
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		loan, err := s.lr.LockActiveLoan(tx, loanID)
+		if err != nil {
+			return err
+		}
+		if loan == nil {
+			return ErrLoanNotFound
+		}
+
+		asset, err := s.ar.GetAsset(loan.AssetID)
+		if err != nil {
+			return err
+		}
+
+		status := models.AssetStatusAtLocation(asset.Location.ID)
+		if statusChanges, err = s.ar.UpdateItemStatus([]int{loan.AssetID}, status, tx); err != nil {
+			return err
+		}
+
+		return s.lr.MarkLoanReturned(tx, loanID, returnedByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loan, err := s.lr.GetLoan(loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, 0)
+	go s.a.Log(
+		"check_in",
+		map[string]interface{}{
+			"asset_id": loan.AssetID,
+			"overdue":  loan.ReturnedAt != nil && loan.ReturnedAt.After(loan.DueAt),
+			"msg":      "Zasób zwrócony",
+		},
+		loan,
+	)
+
+	return loan, nil
+}
+
+func (s *LoanService) CheckInByPyrCode(pyrCode string, returnedByID *int) (*models.AssetLoan, error) {
+	asset, err := s.ar.FindItemByPyrCode(pyrCode)
+	if err != nil {
+		return nil, err
+	}
+	if asset.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+
+	loanID, err := s.lr.FindActiveLoanID(asset.ID)
+	if err != nil {
+		return nil, err
+	}
+	if loanID == 0 {
+		return nil, ErrLoanNotFound
+	}
+
+	return s.CheckIn(loanID, returnedByID)
+}
+
+func (s *LoanService) GetLoan(id int) (*models.AssetLoan, error) {
+	loan, err := s.lr.GetLoan(id)
+	if err != nil {
+		return nil, err
+	}
+	if loan == nil {
+		return nil, ErrLoanNotFound
+	}
+
+	return loan, nil
+}
+
+func (s *LoanService) GetLoans(query RetrieveLoanListQuery) ([]models.AssetLoan, error) {
+	return s.lr.GetLoans(query)
+}
+
+func (s *LoanService) GetOverdueLoans() ([]models.AssetLoan, error) {
+	return s.lr.GetOverdueLoans(time.Now())
+}
+
+func (s *LoanService) validateCheckOut(req *CheckOutRequest) error {
+	if req.UserID == nil && (req.RecipientName == nil || *req.RecipientName == "") {
+		return ErrHolderRequired
+	}
+	if !req.DueAt.After(time.Now()) {
+		return ErrDueDateInvalid
+	}
+
+	if req.UserID != nil {
+		exists, err := s.ur.UsersExists([]int{*req.UserID})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUserNotFound
+		}
+	}
+
+	if req.AssetID != 0 {
+		return nil
+	}
+	if req.PyrCode == "" {
+		return ErrAssetIdentifier
+	}
+
+	asset, err := s.ar.FindItemByPyrCode(req.PyrCode)
+	if err != nil {
+		return err
+	}
+	if asset.ID == 0 {
+		return ErrAssetNotFound
+	}
+	req.AssetID = asset.ID
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package origins
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OriginHandler struct {
+	Service *OriginService
+}
+
+func NewHandler(r *repository.Repository, a *auditlog.Auditlog) *OriginHandler {
+	return &OriginHandler{
+		Service: NewService(r, a),
+	}
+}
+
+func (h *OriginHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/origins", security.Authorize("user"), h.GetOrigins)
+	router.GET("/origins/:id", security.Authorize("user"), h.GetOrigin)
+	router.POST("/origins", security.Authorize("admin"), h.CreateOrigin)
+	router.PATCH("/origins/:id", security.Authorize("admin"), h.UpdateOrigin)
+	router.DELETE("/origins/:id", security.Authorize("admin"), h.DeleteOrigin)
+}
+
+func (h *OriginHandler) GetOrigins(c *gin.Context) {
+	var query RetrieveOriginListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	origins, err := h.Service.GetOrigins(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania pochodzeń", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, origins)
+}
+
+func (h *OriginHandler) GetOrigin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	origin, err := h.Service.GetOrigin(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania pochodzenia", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, origin)
+}
+
+func (h *OriginHandler) CreateOrigin(c *gin.Context) {
+	var req CreateOriginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	origin, err := h.Service.CreateOrigin(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się dodać pochodzenia", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, origin)
+}
+
+func (h *OriginHandler) UpdateOrigin(c *gin.Context) {
+	var req PatchOriginRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	origin, err := h.Service.UpdateOrigin(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zaktualizować pochodzenia", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, origin)
+}
+
+func (h *OriginHandler) DeleteOrigin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	if err := h.Service.DeleteOrigin(id); err != nil {
+		h.handleError(c, "Nie udało się usunąć pochodzenia", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OriginHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrOriginNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrEmptySlug), errors.Is(err, ErrNothingToPatch):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrOriginInUse):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": "Pochodzenie jest używane, zamiast usuwać można je dezaktywować"})
+	default:
+		switch err.(type) {
+		case *custom_error.UniqueViolationError:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package origins
+
+type CreateOriginRequest struct {
+	Slug         string  `json:"slug"`
+	Name         string  `json:"name" binding:"required"`
+	IsRental     bool    `json:"is_rental"`
+	ContactName  *string `json:"contact_name"`
+	ContactEmail *string `json:"contact_email" binding:"omitempty,email"`
+	ContactPhone *string `json:"contact_phone"`
+	Notes        *string `json:"notes"`
+	Active       *bool   `json:"active"`
+}
+
+// PatchOriginRequest does not allow changing the slug since it is stored as is on items, stocks and transfers
+type PatchOriginRequest struct {
+	ID           int     `uri:"id" binding:"required"`
+	Name         *string `json:"name"`
+	IsRental     *bool   `json:"is_rental"`
+	ContactName  *string `json:"contact_name"`
+	ContactEmail *string `json:"contact_email" binding:"omitempty,email"`
+	ContactPhone *string `json:"contact_phone"`
+	Notes        *string `json:"notes"`
+	Active       *bool   `json:"active"`
+}
+
+type RetrieveOriginListQuery struct {
+	All bool `form:"all"`
+}
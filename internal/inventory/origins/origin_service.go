@@ -0,0 +1,155 @@
+package origins
+
+import (
+	"errors"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrOriginNotFound = errors.New("origin not found")
+	ErrEmptySlug      = errors.New("origin slug cannot be empty")
+	ErrOriginInUse    = errors.New("origin is still referenced by items, stocks, transfers or rentals")
+	ErrNothingToPatch = errors.New("no fields to update")
+)
+
+type OriginService struct {
+	r *repository.Repository
+	a *auditlog.Auditlog
+}
+
+func NewService(r *repository.Repository, a *auditlog.Auditlog) *OriginService {
+	return &OriginService{
+		r: r,
+		a: a,
+	}
+}
+
+func (s *OriginService) GetOrigins(query RetrieveOriginListQuery) ([]models.Origin, error) {
+	return s.r.GetOrigins(!query.All)
+}
+
+func (s *OriginService) GetOrigin(id int) (*models.Origin, error) {
+	origin, err := s.r.GetOrigin(id)
+	if err != nil {
+		return nil, err
+	}
+	if origin == nil {
+		return nil, ErrOriginNotFound
+	}
+
+	return origin, nil
+}
+
+// CreateOrigin derives the slug from the name when it is not given, the slug is what items store in their origin column
+func (s *OriginService) CreateOrigin(req CreateOriginRequest) (*models.Origin, error) {
+	slug := req.Slug
+	if slug == "" {
+		slug = req.Name
+	}
+
+	origin := &models.Origin{
+		Slug:         metadata.NewOrigin(slug).String(),
+		Name:         req.Name,
+		IsRental:     req.IsRental,
+		ContactName:  req.ContactName,
+		ContactEmail: req.ContactEmail,
+		ContactPhone: req.ContactPhone,
+		Notes:        req.Notes,
+		Active:       req.Active == nil || *req.Active,
+	}
+	if origin.Slug == "" {
+		return nil, ErrEmptySlug
+	}
+
+	if err := s.r.PersistOrigin(origin); err != nil {
+		return nil, err
+	}
+
+	origin, err := s.GetOrigin(origin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log("create", map[string]interface{}{
+		"slug": origin.Slug,
+		"msg":  "Dodano pochodzenie",
+	}, origin)
+
+	return origin, nil
+}
+
+func (s *OriginService) UpdateOrigin(req PatchOriginRequest) (*models.Origin, error) {
+	record := goqu.Record{}
+	if req.Name != nil {
+		record["name"] = *req.Name
+	}
+	if req.IsRental != nil {
+		record["is_rental"] = *req.IsRental
+	}
+	if req.ContactName != nil {
+		record["contact_name"] = *req.ContactName
+	}
+	if req.ContactEmail != nil {
+		record["contact_email"] = *req.ContactEmail
+	}
+	if req.ContactPhone != nil {
+		record["contact_phone"] = *req.ContactPhone
+	}
+	if req.Notes != nil {
+		record["notes"] = *req.Notes
+	}
+	if req.Active != nil {
+		record["active"] = *req.Active
+	}
+	if len(record) == 0 {
+		return nil, ErrNothingToPatch
+	}
+
+	if _, err := s.GetOrigin(req.ID); err != nil {
+		return nil, err
+	}
+	if err := s.r.UpdateOrigin(req.ID, record); err != nil {
+		return nil, err
+	}
+
+	origin, err := s.GetOrigin(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log("update", record, origin)
+
+	return origin, nil
+}
+
+// DeleteOrigin only removes origins nothing points to, used ones should be deactivated instead
+func (s *OriginService) DeleteOrigin(id int) error {
+	origin, err := s.GetOrigin(id)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.r.IsOriginUsed(origin.Slug)
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrOriginInUse
+	}
+
+	if err := s.r.DeleteOrigin(id); err != nil {
+		return err
+	}
+
+	go s.a.Log("delete", map[string]interface{}{
+		"slug": origin.Slug,
+		"msg":  "Usunięto pochodzenie",
+	}, origin)
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package rentals
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RentalHandler struct {
+	Service *RentalService
+}
+
+func NewHandler(r *repository.Repository, ar *assets.AssetsRepository, sr *stocks.StockRepository, a *auditlog.Auditlog) *RentalHandler {
+	return &RentalHandler{
+		Service: NewService(r, NewRepository(r), ar, sr, a),
+	}
+}
+
+func (h *RentalHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/rentals", security.Authorize("user"), h.GetRentals)
+	router.GET("/rentals/missing", security.Authorize("user"), h.GetMissingRentals)
+	router.GET("/rentals/:id", security.Authorize("user"), h.GetRental)
+	router.POST("/rentals", security.Authorize("moderator"), h.CreateRental)
+	router.POST("/rentals/:id/return", security.Authorize("moderator"), h.ReturnRental)
+}
+
+func (h *RentalHandler) GetRentals(c *gin.Context) {
+	var query RetrieveRentalListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	contracts, err := h.Service.GetContracts(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania umów najmu", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, contracts)
+}
+
+// GetMissingRentals lists per supplier what still has to come back before the equipment can be returned
+func (h *RentalHandler) GetMissingRentals(c *gin.Context) {
+	statuses, err := h.Service.GetSupplierStatus(c.Query("origin"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania brakującego sprzętu", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
+
+func (h *RentalHandler) GetRental(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	contract, err := h.Service.GetContract(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania umowy najmu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contract)
+}
+
+func (h *RentalHandler) CreateRental(c *gin.Context) {
+	var req CreateRentalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CreatedByID = &userID
+	}
+
+	contract, err := h.Service.CreateContract(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zarejestrować umowy najmu", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, contract)
+}
+
+func (h *RentalHandler) ReturnRental(c *gin.Context) {
+	var req ReturnRentalRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.ReturnedBy = &userID
+	}
+
+	contract, err := h.Service.ReturnRental(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zwrócić sprzętu do dostawcy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contract)
+}
+
+func (h *RentalHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrRentalNotFound), errors.Is(err, ErrAssetNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrNotRentalOrigin), errors.Is(err, ErrInvalidRentalPeriod), errors.Is(err, ErrEmptyContract),
+		errors.Is(err, ErrOriginMismatch), errors.Is(err, ErrNotInContract), errors.Is(err, ErrReturnExceedsContract):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrInsufficientStock), custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.UniqueViolationError:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Kategoria nie istnieje", "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package rentals
+
+import (
+	"sort"
+	"time"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+)
+
+// isReady reports whether a rented asset rests in the warehouse and can be handed back to the supplier
+func isReady(asset models.RentalContractAsset) bool {
+	return asset.LocationID == models.DefaultEquipmentLocationID && metadata.Status(asset.Status) == metadata.StatusAvailable
+}
+
+// BuildSupplierStatus groups outstanding rental equipment of open contracts by origin
+func BuildSupplierStatus(
+	contracts []models.RentalContract,
+	assets []models.RentalContractAsset,
+	stocks []models.RentalContractStock,
+	today time.Time,
+) []models.SupplierRentalStatus {
+	origins := make(map[int]string, len(contracts))
+	byOrigin := map[string]*models.SupplierRentalStatus{}
+	for _, contract := range contracts {
+		origins[contract.ID] = contract.Origin
+		status, ok := byOrigin[contract.Origin]
+		if !ok {
+			status = &models.SupplierRentalStatus{
+				Origin:        contract.Origin,
+				ContractIDs:   []int{},
+				NextDueDate:   contract.DueDate,
+				MissingAssets: []models.RentalContractAsset{},
+				MissingStocks: []models.RentalMissingStock{},
+			}
+			byOrigin[contract.Origin] = status
+		}
+		status.ContractIDs = append(status.ContractIDs, contract.ID)
+		if contract.DueDate.Before(status.NextDueDate) {
+			status.NextDueDate = contract.DueDate
+		}
+		if contract.DueDate.Before(today) {
+			status.Overdue = true
+		}
+	}
+
+	for _, asset := range assets {
+		status, ok := byOrigin[origins[asset.ContractID]]
+		if !ok || asset.ReturnedAt != nil {
+			continue
+		}
+		if isReady(asset) {
+			status.ReadyAssets++
+			continue
+		}
+		status.MissingAssets = append(status.MissingAssets, asset)
+	}
+
+	// the same warehouse stock covers all contracts of an origin, outstanding quantities add up
+	type stockKey struct {
+		origin     string
+		categoryID int
+	}
+	missingStocks := map[stockKey]*models.RentalMissingStock{}
+	var keys []stockKey
+	for _, stock := range stocks {
+		origin, ok := origins[stock.ContractID]
+		if !ok || stock.Outstanding() <= 0 {
+			continue
+		}
+		key := stockKey{origin, stock.CategoryID}
+		missing, ok := missingStocks[key]
+		if !ok {
+			missing = &models.RentalMissingStock{
+				CategoryID:    stock.CategoryID,
+				CategoryLabel: stock.CategoryLabel,
+				InWarehouse:   stock.InWarehouse,
+			}
+			missingStocks[key] = missing
+			keys = append(keys, key)
+		}
+		missing.Outstanding += stock.Outstanding()
+	}
+	for _, key := range keys {
+		missing := missingStocks[key]
+		if missing.Missing = missing.Outstanding - missing.InWarehouse; missing.Missing <= 0 {
+			continue
+		}
+		byOrigin[key.origin].MissingStocks = append(byOrigin[key.origin].MissingStocks, *missing)
+	}
+
+	result := make([]models.SupplierRentalStatus, 0, len(byOrigin))
+	for _, status := range byOrigin {
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Origin < result[j].Origin
+	})
+
+	return result
+}
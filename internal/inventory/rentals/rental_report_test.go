@@ -0,0 +1,64 @@
+package rentals
+
+import (
+	"testing"
+	"time"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func TestBuildSupplierStatusGroupsByOrigin(t *testing.T) {
+	contracts := []models.RentalContract{
+		{ID: 1, Origin: "probis", DueDate: date("2026-07-10")},
+		{ID: 2, Origin: "probis", DueDate: date("2026-07-05")},
+		{ID: 3, Origin: "netland", DueDate: date("2026-07-20")},
+	}
+	assets := []models.RentalContractAsset{
+		{ContractID: 1, AssetID: 10, Status: "available", LocationID: models.DefaultEquipmentLocationID},
+		{ContractID: 1, AssetID: 11, Status: "located", LocationID: 4},
+		{ContractID: 3, AssetID: 12, Status: "in_transit", LocationID: 4},
+	}
+
+	statuses := BuildSupplierStatus(contracts, assets, nil, date("2026-07-08"))
+
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "netland", statuses[0].Origin)
+	assert.False(t, statuses[0].Overdue)
+	assert.Len(t, statuses[0].MissingAssets, 1)
+
+	probis := statuses[1]
+	assert.Equal(t, []int{1, 2}, probis.ContractIDs)
+	assert.Equal(t, date("2026-07-05"), probis.NextDueDate)
+	assert.True(t, probis.Overdue)
+	assert.Equal(t, 1, probis.ReadyAssets)
+	assert.Len(t, probis.MissingAssets, 1)
+	assert.Equal(t, 11, probis.MissingAssets[0].AssetID)
+}
+
+func TestBuildSupplierStatusSumsOutstandingStock(t *testing.T) {
+	contracts := []models.RentalContract{
+		{ID: 1, Origin: "probis", DueDate: date("2026-07-10")},
+		{ID: 2, Origin: "probis", DueDate: date("2026-07-10")},
+	}
+	stocks := []models.RentalContractStock{
+		{ContractID: 1, CategoryID: 5, Quantity: 20, ReturnedQuantity: 5, InWarehouse: 25},
+		{ContractID: 2, CategoryID: 5, Quantity: 15, InWarehouse: 25},
+		{ContractID: 2, CategoryID: 6, Quantity: 4, InWarehouse: 4},
+	}
+
+	statuses := BuildSupplierStatus(contracts, nil, stocks, date("2026-07-01"))
+
+	assert.Len(t, statuses, 1)
+	assert.Len(t, statuses[0].MissingStocks, 1)
+	missing := statuses[0].MissingStocks[0]
+	assert.Equal(t, 5, missing.CategoryID)
+	assert.Equal(t, 30, missing.Outstanding)
+	assert.Equal(t, 25, missing.InWarehouse)
+	assert.Equal(t, 5, missing.Missing)
+}
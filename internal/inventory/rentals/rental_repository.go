@@ -0,0 +1,371 @@
+package rentals
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+)
+
+type RentalRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *RentalRepository {
+	return &RentalRepository{
+		repository: r,
+	}
+}
+
+func (r *RentalRepository) InsertContract(tx *goqu.TxDatabase, req CreateRentalRequest, rentedFrom time.Time, dueDate time.Time) (int, error) {
+	record := goqu.Record{
+		"origin":        req.Origin,
+		"reference":     req.Reference,
+		"rented_from":   rentedFrom,
+		"due_date":      dueDate,
+		"status":        models.RentalStatusOpen,
+		"notes":         req.Notes,
+		"created_by_id": req.CreatedByID,
+	}
+
+	var contractID int
+	if _, err := tx.Insert("rental_contracts").Rows(record).Returning("id").Executor().ScanVal(&contractID); err != nil {
+		return 0, fmt.Errorf("failed to insert rental contract: %w", err)
+	}
+
+	return contractID, nil
+}
+
+func (r *RentalRepository) InsertContractAssets(tx *goqu.TxDatabase, contractID int, assetIDs []int) error {
+	if len(assetIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]interface{}, 0, len(assetIDs))
+	for _, assetID := range assetIDs {
+		rows = append(rows, goqu.Record{"contract_id": contractID, "item_id": assetID})
+	}
+	if _, err := tx.Insert("rental_contract_assets").Rows(rows...).Executor().Exec(); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			return custom_error.WrapDBError("Zasób należy już do innej niezwróconej umowy najmu", string(pqErr.Code))
+		}
+		return fmt.Errorf("failed to insert rental contract assets: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RentalRepository) InsertContractStocks(tx *goqu.TxDatabase, contractID int, stocks []RentalStockRequest) error {
+	if len(stocks) == 0 {
+		return nil
+	}
+
+	rows := make([]interface{}, 0, len(stocks))
+	for _, stock := range stocks {
+		rows = append(rows, goqu.Record{
+			"contract_id":      contractID,
+			"item_category_id": stock.CategoryID,
+			"quantity":         stock.Quantity,
+		})
+	}
+	if _, err := tx.Insert("rental_contract_stocks").Rows(rows...).Executor().Exec(); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			return custom_error.WrapDBError("Zduplikowana kategoria w umowie najmu", string(pqErr.Code))
+		}
+		return fmt.Errorf("failed to insert rental contract stocks: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RentalRepository) GetContract(id int) (*models.RentalContract, error) {
+	var contract models.RentalContract
+
+	found, err := r.getContractQuery().Where(goqu.Ex{"id": id}).Executor().ScanStruct(&contract)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &contract, nil
+}
+
+func (r *RentalRepository) GetContracts(query RetrieveRentalListQuery) ([]models.RentalContract, error) {
+	conditions := goqu.Ex{}
+	if query.Origin != "" {
+		conditions["origin"] = query.Origin
+	}
+	if query.Status != "" {
+		conditions["status"] = query.Status
+	}
+
+	dataset := r.getContractQuery().Where(conditions)
+	if query.Overdue {
+		dataset = dataset.Where(
+			goqu.Ex{"status": models.RentalStatusOpen},
+			goqu.C("due_date").Lt(goqu.L("CURRENT_DATE")),
+		)
+	}
+
+	contracts := []models.RentalContract{}
+	err := dataset.
+		Order(goqu.I("due_date").Asc(), goqu.I("id").Asc()).
+		Executor().
+		ScanStructs(&contracts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return contracts, nil
+}
+
+// LockOpenContract locks the contract row and ensures it has not been returned in the meantime
+func (r *RentalRepository) LockOpenContract(tx *goqu.TxDatabase, id int) (*models.RentalContract, error) {
+	var contract models.RentalContract
+
+	found, err := tx.Select("id", "origin", "status").
+		From("rental_contracts").
+		Where(goqu.Ex{"id": id}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&contract)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rental contract: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	if contract.Status != models.RentalStatusOpen {
+		return nil, custom_error.NewInvalidStatusTransitionError("rental_contract", id, contract.Status, models.RentalStatusReturned)
+	}
+
+	return &contract, nil
+}
+
+// GetContractAssets returns asset checklist entries of given contracts, optionally only those not returned yet
+func (r *RentalRepository) GetContractAssets(contractIDs []int, outstandingOnly bool) ([]models.RentalContractAsset, error) {
+	assets := []models.RentalContractAsset{}
+	if len(contractIDs) == 0 {
+		return assets, nil
+	}
+
+	conditions := goqu.Ex{"rca.contract_id": contractIDs}
+	if outstandingOnly {
+		conditions["rca.returned_at"] = nil
+	}
+
+	err := r.repository.GoquDBWrapper.Select(
+		"rca.contract_id",
+		"rca.item_id",
+		"rca.returned_at",
+		"i.pyr_code",
+		goqu.COALESCE(goqu.I("i.status"), "").As("status"),
+		"i.location_id",
+		goqu.I("l.name").As("location_name"),
+		goqu.I("c.label").As("category_label"),
+	).
+		From(goqu.T("rental_contract_assets").As("rca")).
+		InnerJoin(goqu.T("items").As("i"), goqu.On(goqu.Ex{"rca.item_id": goqu.I("i.id")})).
+		LeftJoin(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"i.item_category_id": goqu.I("c.id")})).
+		LeftJoin(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"i.location_id": goqu.I("l.id")})).
+		Where(conditions).
+		Order(goqu.I("rca.contract_id").Asc(), goqu.I("rca.item_id").Asc()).
+		Executor().
+		ScanStructs(&assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rental contract assets: %w", err)
+	}
+
+	return assets, nil
+}
+
+// GetContractStocks returns stock checklist entries together with the origin's stock kept in the warehouse
+func (r *RentalRepository) GetContractStocks(contractIDs []int, outstandingOnly bool) ([]models.RentalContractStock, error) {
+	stocks := []models.RentalContractStock{}
+	if len(contractIDs) == 0 {
+		return stocks, nil
+	}
+
+	dataset := r.repository.GoquDBWrapper.Select(
+		"rcs.contract_id",
+		"rcs.item_category_id",
+		"rcs.quantity",
+		"rcs.returned_quantity",
+		goqu.I("c.label").As("category_label"),
+		goqu.COALESCE(goqu.I("s.quantity"), 0).As("in_warehouse"),
+	).
+		From(goqu.T("rental_contract_stocks").As("rcs")).
+		InnerJoin(goqu.T("rental_contracts").As("rc"), goqu.On(goqu.Ex{"rcs.contract_id": goqu.I("rc.id")})).
+		LeftJoin(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"rcs.item_category_id": goqu.I("c.id")})).
+		LeftJoin(
+			goqu.T("non_serialized_items").As("s"),
+			goqu.On(goqu.Ex{
+				"s.item_category_id": goqu.I("rcs.item_category_id"),
+				"s.origin":           goqu.I("rc.origin"),
+				"s.location_id":      models.DefaultEquipmentLocationID,
+			}),
+		).
+		Where(goqu.Ex{"rcs.contract_id": contractIDs})
+	if outstandingOnly {
+		dataset = dataset.Where(goqu.I("rcs.returned_quantity").Lt(goqu.I("rcs.quantity")))
+	}
+
+	err := dataset.
+		Order(goqu.I("rcs.contract_id").Asc(), goqu.I("rcs.item_category_id").Asc()).
+		Executor().
+		ScanStructs(&stocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rental contract stocks: %w", err)
+	}
+
+	return stocks, nil
+}
+
+func (r *RentalRepository) MarkAssetsReturned(tx *goqu.TxDatabase, contractID int, assetIDs []int) error {
+	if len(assetIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.Update("rental_contract_assets").
+		Set(goqu.Record{"returned_at": time.Now()}).
+		Where(goqu.Ex{"contract_id": contractID, "item_id": assetIDs, "returned_at": nil}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to mark rental assets as returned: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RentalRepository) AddReturnedStock(tx *goqu.TxDatabase, contractID int, categoryID int, quantity int) error {
+	result, err := tx.Update("rental_contract_stocks").
+		Set(goqu.Record{"returned_quantity": goqu.L("returned_quantity + ?", quantity)}).
+		Where(
+			goqu.Ex{"contract_id": contractID, "item_category_id": categoryID},
+			goqu.L("returned_quantity + ? <= quantity", quantity),
+		).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update returned stock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: category %d", ErrReturnExceedsContract, categoryID)
+	}
+
+	return nil
+}
+
+// CloseIfReturned marks the contract returned once nothing is outstanding, it reports whether the contract was closed
+func (r *RentalRepository) CloseIfReturned(tx *goqu.TxDatabase, contractID int) (bool, error) {
+	outstandingAssets := tx.From("rental_contract_assets").
+		Select(goqu.L("1")).
+		Where(goqu.Ex{"contract_id": contractID, "returned_at": nil})
+	outstandingStocks := tx.From("rental_contract_stocks").
+		Select(goqu.L("1")).
+		Where(goqu.Ex{"contract_id": contractID}, goqu.I("returned_quantity").Lt(goqu.I("quantity")))
+
+	result, err := tx.Update("rental_contracts").
+		Set(goqu.Record{"status": models.RentalStatusReturned, "returned_at": time.Now()}).
+		Where(
+			goqu.Ex{"id": contractID},
+			goqu.L("NOT EXISTS ?", outstandingAssets),
+			goqu.L("NOT EXISTS ?", outstandingStocks),
+		).
+		Executor().
+		Exec()
+	if err != nil {
+		return false, fmt.Errorf("failed to close rental contract: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// LockWarehouseStock locks the warehouse stock row of a category owned by the origin
+func (r *RentalRepository) LockWarehouseStock(tx *goqu.TxDatabase, categoryID int, origin string) (*models.StockItemFlat, error) {
+	var stock models.StockItemFlat
+	found, err := tx.Select(
+		goqu.I("id").As("stock_id"),
+		goqu.I("item_category_id").As("category_id"),
+		"quantity",
+	).
+		From("non_serialized_items").
+		Where(goqu.Ex{
+			"item_category_id": categoryID,
+			"location_id":      models.DefaultEquipmentLocationID,
+			"origin":           origin,
+		}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock of category %d: %w", categoryID, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stock, nil
+}
+
+// GetOrCreateSupplierLocation returns the virtual location gathering equipment returned to the origin
+func (r *RentalRepository) GetOrCreateSupplierLocation(tx *goqu.TxDatabase, origin string) (int, error) {
+	name := SupplierLocationName(origin)
+
+	var locationID int
+	found, err := tx.Select("id").From("locations").Where(goqu.Ex{"name": name}).Order(goqu.I("id").Asc()).Limit(1).Executor().ScanVal(&locationID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find supplier location: %w", err)
+	}
+	if found {
+		return locationID, nil
+	}
+
+	_, err = tx.Insert("locations").
+		Rows(goqu.Record{"name": name, "details": "Lokalizacja wirtualna - sprzęt zwrócony do dostawcy"}).
+		Returning("id").
+		Executor().
+		ScanVal(&locationID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create supplier location: %w", err)
+	}
+
+	return locationID, nil
+}
+
+func (r *RentalRepository) getContractQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"id",
+		"origin",
+		"reference",
+		"rented_from",
+		"due_date",
+		"status",
+		"notes",
+		"created_by_id",
+		"created_at",
+		"returned_at",
+	).From("rental_contracts")
+}
+
+func SupplierLocationName(origin string) string {
+	return "Dostawca: " + origin
+}
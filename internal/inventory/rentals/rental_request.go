@@ -0,0 +1,33 @@
+package rentals
+
+type RentalStockRequest struct {
+	CategoryID int `json:"category_id" binding:"required"`
+	Quantity   int `json:"quantity" binding:"required,gte=1"`
+}
+
+type CreateRentalRequest struct {
+	Origin      string               `json:"origin" binding:"required"`
+	Reference   *string              `json:"reference"`
+	RentedFrom  string               `json:"rented_from" binding:"required"`
+	DueDate     string               `json:"due_date" binding:"required"`
+	Notes       *string              `json:"notes"`
+	AssetIDs    []int                `json:"asset_ids"`
+	Stocks      []RentalStockRequest `json:"stocks" binding:"dive"`
+	CreatedByID *int                 `json:"-"`
+}
+
+// ReturnRentalRequest returns given items to the supplier, when both lists are empty everything outstanding is returned
+type ReturnRentalRequest struct {
+	ID         int                  `uri:"id" binding:"required"`
+	Mode       string               `json:"mode" binding:"required,oneof=retire move"`
+	AssetIDs   []int                `json:"asset_ids"`
+	Stocks     []RentalStockRequest `json:"stocks" binding:"dive"`
+	Notes      *string              `json:"notes"`
+	ReturnedBy *int                 `json:"-"`
+}
+
+type RetrieveRentalListQuery struct {
+	Origin  string `form:"origin"`
+	Status  string `form:"status" binding:"omitempty,oneof=open returned"`
+	Overdue bool   `form:"overdue"`
+}
@@ -0,0 +1,416 @@
+package rentals
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"warehouse/internal/inventory/assets"
+	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/inventory/kits"
+	"warehouse/internal/inventory/retirements"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrRentalNotFound        = errors.New("rental contract not found")
+	ErrAssetNotFound         = errors.New("asset not found")
+	ErrNotRentalOrigin       = errors.New("origin is not a rental company")
+	ErrInvalidRentalPeriod   = errors.New("rented_from and due_date must be in YYYY-MM-DD format and due_date cannot precede rented_from")
+	ErrEmptyContract         = errors.New("rental contract requires assets or stocks")
+	ErrOriginMismatch        = errors.New("asset origin does not match rental contract")
+	ErrNotInContract         = errors.New("item is not outstanding in rental contract")
+	ErrReturnExceedsContract = errors.New("returned quantity exceeds rented quantity")
+	ErrInsufficientStock     = errors.New("not enough stock of the origin in the warehouse")
+)
+
+type RentalService struct {
+	r         *repository.Repository
+	rr        *RentalRepository
+	ar        *assets.AssetsRepository
+	stockRepo *stocks.StockRepository
+	retRepo   *retirements.RetirementRepository
+	kr        *kits.KitRepository
+	a         *auditlog.Auditlog
+	il        *inventorylog.InventoryLog
+}
+
+func NewService(
+	r *repository.Repository,
+	rr *RentalRepository,
+	ar *assets.AssetsRepository,
+	sr *stocks.StockRepository,
+	a *auditlog.Auditlog,
+) *RentalService {
+	return &RentalService{
+		r:         r,
+		rr:        rr,
+		ar:        ar,
+		stockRepo: sr,
+		retRepo:   retirements.NewRepository(r),
+		kr:        kits.NewRepository(r),
+		a:         a,
+		il:        inventorylog.NewInventoryLog(a),
+	}
+}
+
+func (s *RentalService) CreateContract(req CreateRentalRequest) (*models.RentalContract, error) {
+	rentalOrigin, err := s.r.ResolveOrigin(req.Origin)
+	if errors.Is(err, repository.ErrUnknownOrigin) || errors.Is(err, repository.ErrInactiveOrigin) {
+		return nil, ErrNotRentalOrigin
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !rentalOrigin.IsRental {
+		return nil, ErrNotRentalOrigin
+	}
+	req.Origin = rentalOrigin.Slug
+	origin := metadata.Origin(rentalOrigin.Slug)
+
+	rentedFrom, dueDate, err := parseRentalPeriod(req.RentedFrom, req.DueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	req.AssetIDs = uniqueIDs(req.AssetIDs)
+	if len(req.AssetIDs) == 0 && len(req.Stocks) == 0 {
+		return nil, ErrEmptyContract
+	}
+	for _, assetID := range req.AssetIDs {
+		asset, err := s.ar.GetAsset(assetID)
+		if err != nil {
+			return nil, err
+		}
+		if asset.ID == 0 {
+			return nil, fmt.Errorf("%w: %d", ErrAssetNotFound, assetID)
+		}
+		if asset.Origin != origin || asset.Status == metadata.StatusRetired {
+			return nil, fmt.Errorf("%w: %s", ErrOriginMismatch, asset.PyrCode)
+		}
+	}
+
+	var contractID int
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if contractID, err = s.rr.InsertContract(tx, req, rentedFrom, dueDate); err != nil {
+			return err
+		}
+		if err := s.rr.InsertContractAssets(tx, contractID, req.AssetIDs); err != nil {
+			return err
+		}
+		return s.rr.InsertContractStocks(tx, contractID, req.Stocks)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := s.GetContract(contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(
+		"create",
+		map[string]interface{}{
+			"origin":    contract.Origin,
+			"due_date":  contract.DueDate,
+			"asset_ids": req.AssetIDs,
+			"stocks":    req.Stocks,
+			"msg":       "Zarejestrowano umowę najmu",
+		},
+		contract,
+	)
+
+	return contract, nil
+}
+
+// GetContract returns the contract with its return checklist
+func (s *RentalService) GetContract(id int) (*models.RentalContract, error) {
+	contract, err := s.rr.GetContract(id)
+	if err != nil {
+		return nil, err
+	}
+	if contract == nil {
+		return nil, ErrRentalNotFound
+	}
+
+	if contract.Assets, err = s.rr.GetContractAssets([]int{id}, false); err != nil {
+		return nil, err
+	}
+	for i := range contract.Assets {
+		contract.Assets[i].Ready = contract.Assets[i].ReturnedAt == nil && isReady(contract.Assets[i])
+	}
+	if contract.Stocks, err = s.rr.GetContractStocks([]int{id}, false); err != nil {
+		return nil, err
+	}
+	contract.Overdue = isOverdue(contract, today())
+
+	return contract, nil
+}
+
+func (s *RentalService) GetContracts(query RetrieveRentalListQuery) ([]models.RentalContract, error) {
+	contracts, err := s.rr.GetContracts(query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := today()
+	for i := range contracts {
+		contracts[i].Overdue = isOverdue(&contracts[i], now)
+	}
+
+	return contracts, nil
+}
+
+// GetSupplierStatus lists, per origin, what still has not come back to the warehouse for open contracts
+func (s *RentalService) GetSupplierStatus(origin string) ([]models.SupplierRentalStatus, error) {
+	contracts, err := s.rr.GetContracts(RetrieveRentalListQuery{Origin: origin, Status: models.RentalStatusOpen})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(contracts))
+	for _, contract := range contracts {
+		ids = append(ids, contract.ID)
+	}
+
+	outstandingAssets, err := s.rr.GetContractAssets(ids, true)
+	if err != nil {
+		return nil, err
+	}
+	outstandingStocks, err := s.rr.GetContractStocks(ids, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildSupplierStatus(contracts, outstandingAssets, outstandingStocks, today()), nil
+}
+
+// ReturnRental hands equipment back to the supplier in one transaction, items are either retired or moved to the supplier's virtual location
+func (s *RentalService) ReturnRental(req ReturnRentalRequest) (*models.RentalContract, error) {
+	var statusChanges []models.AssetStatusChange
+	var assetIDs []int
+	var returnedStocks []RentalStockRequest
+	var lowStock []models.StockLevel
+	var closed bool
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		contract, err := s.rr.LockOpenContract(tx, req.ID)
+		if err != nil {
+			return err
+		}
+		if contract == nil {
+			return ErrRentalNotFound
+		}
+
+		if assetIDs, returnedStocks, err = s.resolveReturnedItems(req); err != nil {
+			return err
+		}
+
+		var supplierLocationID int
+		if req.Mode == models.RentalReturnModeMove {
+			if supplierLocationID, err = s.rr.GetOrCreateSupplierLocation(tx, contract.Origin); err != nil {
+				return err
+			}
+		}
+
+		if statusChanges, err = s.returnAssets(tx, req, assetIDs, supplierLocationID); err != nil {
+			return err
+		}
+
+		for _, stock := range returnedStocks {
+			levels, err := s.returnStock(tx, req, contract.Origin, stock, supplierLocationID)
+			if err != nil {
+				return err
+			}
+			lowStock = append(lowStock, levels...)
+		}
+
+		if err := s.rr.MarkAssetsReturned(tx, req.ID, assetIDs); err != nil {
+			return err
+		}
+		closed, err = s.rr.CloseIfReturned(tx, req.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contract, err := s.GetContract(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, 0)
+	go s.stockRepo.NotifyLowStock(lowStock)
+	go s.a.Log(
+		"return",
+		map[string]interface{}{
+			"mode":      req.Mode,
+			"asset_ids": assetIDs,
+			"stocks":    returnedStocks,
+			"closed":    closed,
+			"msg":       "Zwrócono sprzęt do dostawcy",
+		},
+		contract,
+	)
+
+	return contract, nil
+}
+
+// resolveReturnedItems validates requested items against the outstanding checklist, an empty request returns everything outstanding
+func (s *RentalService) resolveReturnedItems(req ReturnRentalRequest) ([]int, []RentalStockRequest, error) {
+	outstandingAssets, err := s.rr.GetContractAssets([]int{req.ID}, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	outstandingStocks, err := s.rr.GetContractStocks([]int{req.ID}, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(req.AssetIDs) == 0 && len(req.Stocks) == 0 {
+		assetIDs := make([]int, 0, len(outstandingAssets))
+		for _, asset := range outstandingAssets {
+			assetIDs = append(assetIDs, asset.AssetID)
+		}
+		returnedStocks := make([]RentalStockRequest, 0, len(outstandingStocks))
+		for _, stock := range outstandingStocks {
+			returnedStocks = append(returnedStocks, RentalStockRequest{CategoryID: stock.CategoryID, Quantity: stock.Outstanding()})
+		}
+		return assetIDs, returnedStocks, nil
+	}
+
+	outstanding := make(map[int]bool, len(outstandingAssets))
+	for _, asset := range outstandingAssets {
+		outstanding[asset.AssetID] = true
+	}
+	assetIDs := uniqueIDs(req.AssetIDs)
+	for _, assetID := range assetIDs {
+		if !outstanding[assetID] {
+			return nil, nil, fmt.Errorf("%w: asset %d", ErrNotInContract, assetID)
+		}
+	}
+
+	return assetIDs, req.Stocks, nil
+}
+
+func (s *RentalService) returnAssets(tx *goqu.TxDatabase, req ReturnRentalRequest, assetIDs []int, supplierLocationID int) ([]models.AssetStatusChange, error) {
+	var statusChanges []models.AssetStatusChange
+
+	if req.Mode == models.RentalReturnModeRetire {
+		var err error
+		if statusChanges, err = s.ar.UpdateItemStatus(assetIDs, metadata.StatusRetired, tx); err != nil {
+			return nil, err
+		}
+		for _, assetID := range assetIDs {
+			retirement := retirements.RetireAssetRequest{
+				ID:          assetID,
+				Reason:      models.RetirementReasonReturnedToOrigin,
+				Notes:       req.Notes,
+				RetiredByID: req.ReturnedBy,
+			}
+			if err := s.retRepo.SetRetirement(tx, retirement, time.Now()); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for _, assetID := range assetIDs {
+			change, err := s.ar.RelocateAsset(tx, assetID, supplierLocationID)
+			if err != nil {
+				return nil, err
+			}
+			statusChanges = appendStatusChange(statusChanges, change)
+		}
+	}
+
+	for _, assetID := range assetIDs {
+		if err := s.kr.RemoveAssetFromKits(tx, assetID); err != nil {
+			return nil, err
+		}
+	}
+
+	return statusChanges, nil
+}
+
+// appendStatusChange records a relocation, nil means the asset kept its status
+func appendStatusChange(changes []models.AssetStatusChange, change *models.AssetStatusChange) []models.AssetStatusChange {
+	if change == nil {
+		return changes
+	}
+	return append(changes, *change)
+}
+
+// returnStock takes the origin's stock out of the warehouse, in move mode it lands in the supplier location
+func (s *RentalService) returnStock(tx *goqu.TxDatabase, req ReturnRentalRequest, origin string, stock RentalStockRequest, supplierLocationID int) ([]models.StockLevel, error) {
+	if err := s.rr.AddReturnedStock(tx, req.ID, stock.CategoryID, stock.Quantity); err != nil {
+		return nil, err
+	}
+
+	warehouseStock, err := s.rr.LockWarehouseStock(tx, stock.CategoryID, origin)
+	if err != nil {
+		return nil, err
+	}
+	if warehouseStock == nil || warehouseStock.Quantity < stock.Quantity {
+		return nil, fmt.Errorf("%w: category %d", ErrInsufficientStock, stock.CategoryID)
+	}
+
+	decrease := []models.StockItemRequest{{ID: warehouseStock.ID, Quantity: stock.Quantity}}
+	lowStock, err := s.stockRepo.DecreaseStockItemsQuantity(tx, decrease, models.DefaultEquipmentLocationID, models.AdjustmentReasonRental)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Mode == models.RentalReturnModeMove {
+		if _, err := s.stockRepo.AddStockQuantity(tx, stocks.StockItemRequest{
+			CategoryID: stock.CategoryID,
+			LocationID: supplierLocationID,
+			Quantity:   stock.Quantity,
+			Origin:     origin,
+		}, models.AdjustmentReasonRental); err != nil {
+			return nil, err
+		}
+	}
+
+	return lowStock, nil
+}
+
+func parseRentalPeriod(rentedFromValue string, dueDateValue string) (time.Time, time.Time, error) {
+	rentedFrom, err := time.Parse("2006-01-02", rentedFromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrInvalidRentalPeriod
+	}
+	dueDate, err := time.Parse("2006-01-02", dueDateValue)
+	if err != nil || dueDate.Before(rentedFrom) {
+		return time.Time{}, time.Time{}, ErrInvalidRentalPeriod
+	}
+
+	return rentedFrom, dueDate, nil
+}
+
+func isOverdue(contract *models.RentalContract, today time.Time) bool {
+	return contract.Status == models.RentalStatusOpen && contract.DueDate.Before(today)
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
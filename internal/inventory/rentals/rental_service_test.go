@@ -0,0 +1,20 @@
+package rentals
+
+import (
+	"testing"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendStatusChangeSkipsUnchangedStatus(t *testing.T) {
+	change := &models.AssetStatusChange{AssetID: 10, From: metadata.StatusAvailable, To: metadata.StatusLocated}
+
+	changes := appendStatusChange(nil, nil)
+	assert.Empty(t, changes)
+
+	changes = appendStatusChange(changes, change)
+	changes = appendStatusChange(changes, nil)
+	assert.Equal(t, []models.AssetStatusChange{*change}, changes)
+}
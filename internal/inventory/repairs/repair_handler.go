@@ -0,0 +1,174 @@
+package repairs
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/repository"
+	"warehouse/internal/service_desk"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RepairHandler struct {
+	Service *RepairService
+}
+
+func NewHandler(r *repository.Repository, ar *assets.AssetsRepository, a *auditlog.Auditlog) *RepairHandler {
+	return &RepairHandler{
+		Service: NewService(r, NewRepository(r), ar, service_desk.NewServiceDeskRepository(r), a),
+	}
+}
+
+func (h *RepairHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/repairs", security.Authorize("user"), h.GetRepairs)
+	router.GET("/repairs/:id", security.Authorize("user"), h.GetRepair)
+	router.POST("/repairs", security.Authorize("user"), h.OpenRepair)
+	router.PATCH("/repairs/:id", security.Authorize("user"), h.UpdateRepair)
+	router.PATCH("/repairs/:id/close", security.Authorize("user"), h.CloseRepair)
+	router.POST("/service-desk/requests/:id/repairs", security.Authorize("user"), h.OpenRepairFromServiceDeskRequest)
+}
+
+func (h *RepairHandler) GetRepairs(c *gin.Context) {
+	var query RetrieveRepairListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	tickets, err := h.Service.GetRepairs(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania napraw", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tickets)
+}
+
+func (h *RepairHandler) GetRepair(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	ticket, err := h.Service.GetRepair(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania naprawy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (h *RepairHandler) OpenRepair(c *gin.Context) {
+	var req CreateRepairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+	if req.AssetID == 0 && req.PyrCode == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Wymagane asset_id lub pyr_code"})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CreatedByID = &userID
+	}
+
+	ticket, err := h.Service.OpenRepair(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się otworzyć naprawy", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+func (h *RepairHandler) OpenRepairFromServiceDeskRequest(c *gin.Context) {
+	requestID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	var req CreateRepairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+	if req.PyrCode == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Wymagany pyr_code"})
+		return
+	}
+	req.AssetID = 0
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CreatedByID = &userID
+	}
+
+	ticket, err := h.Service.OpenRepairFromServiceDeskRequest(requestID, req)
+	if err != nil {
+		h.handleError(c, "Nie udało się otworzyć naprawy", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
+
+func (h *RepairHandler) UpdateRepair(c *gin.Context) {
+	var req PatchRepairRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	ticket, err := h.Service.UpdateRepair(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zaktualizować naprawy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (h *RepairHandler) CloseRepair(c *gin.Context) {
+	var req CloseRepairRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	ticket, err := h.Service.CloseRepair(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zamknąć naprawy", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ticket)
+}
+
+func (h *RepairHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrAssetNotFound), errors.Is(err, ErrRepairNotFound), errors.Is(err, ErrServiceDeskRequestNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrNotHardwareIssue), errors.Is(err, ErrInvalidExpectedReturn):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
@@ -0,0 +1,164 @@
+package repairs
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+type RepairRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *RepairRepository {
+	return &RepairRepository{
+		repository: r,
+	}
+}
+
+func (r *RepairRepository) InsertRepairTicket(tx *goqu.TxDatabase, req CreateRepairRequest, expectedReturnDate *time.Time) (int, error) {
+	record := goqu.Record{
+		"item_id":                 req.AssetID,
+		"status":                  models.RepairStatusOpen,
+		"vendor":                  req.Vendor,
+		"cost":                    req.Cost,
+		"notes":                   req.Notes,
+		"expected_return_date":    expectedReturnDate,
+		"service_desk_request_id": req.ServiceDeskRequestID,
+		"created_by_id":           req.CreatedByID,
+	}
+
+	var ticketID int
+	if _, err := tx.Insert("repair_tickets").Rows(record).Returning("id").Executor().ScanVal(&ticketID); err != nil {
+		return 0, fmt.Errorf("failed to insert repair ticket: %w", err)
+	}
+
+	return ticketID, nil
+}
+
+func (r *RepairRepository) GetRepairTicket(id int) (*models.RepairTicket, error) {
+	var ticket models.RepairTicket
+
+	found, err := r.getRepairTicketQuery().Where(goqu.Ex{"rt.id": id}).Executor().ScanStruct(&ticket)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &ticket, nil
+}
+
+func (r *RepairRepository) GetRepairTickets(query RetrieveRepairListQuery) ([]models.RepairTicket, error) {
+	conditions := goqu.Ex{}
+	if query.Status != "" {
+		conditions["rt.status"] = query.Status
+	}
+	if query.AssetID != 0 {
+		conditions["rt.item_id"] = query.AssetID
+	}
+
+	tickets := []models.RepairTicket{}
+	err := r.getRepairTicketQuery().
+		Where(conditions).
+		Order(goqu.I("rt.opened_at").Desc()).
+		Executor().
+		ScanStructs(&tickets)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return tickets, nil
+}
+
+// LockOpenRepairTicket locks the ticket row and ensures it has not been closed in the meantime, target names
+// the status the caller is about to set, a closed ticket is reported as an invalid transition into it
+func (r *RepairRepository) LockOpenRepairTicket(tx *goqu.TxDatabase, id int, target string) (*models.RepairTicket, error) {
+	var ticket struct {
+		AssetID int    `db:"item_id"`
+		Status  string `db:"status"`
+	}
+
+	found, err := tx.Select("item_id", "status").
+		From("repair_tickets").
+		Where(goqu.Ex{"id": id}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&ticket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock repair ticket: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	if ticket.Status != models.RepairStatusOpen {
+		return nil, custom_error.NewInvalidStatusTransitionError("repair", id, ticket.Status, target)
+	}
+
+	return &models.RepairTicket{ID: id, AssetID: ticket.AssetID, Status: ticket.Status}, nil
+}
+
+func (r *RepairRepository) CloseRepairTicket(tx *goqu.TxDatabase, id int, resolution string, notes *string) error {
+	record := goqu.Record{
+		"status":    resolution,
+		"closed_at": time.Now(),
+	}
+	if notes != nil {
+		record["notes"] = *notes
+	}
+
+	if _, err := tx.Update("repair_tickets").Set(record).Where(goqu.Ex{"id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to close repair ticket: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RepairRepository) UpdateRepairTicket(tx *goqu.TxDatabase, id int, record goqu.Record) error {
+	result, err := tx.Update("repair_tickets").
+		Set(record).
+		Where(goqu.Ex{"id": id, "status": models.RepairStatusOpen}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update repair ticket: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no open repair ticket found with id: %d", id)
+	}
+
+	return nil
+}
+
+func (r *RepairRepository) getRepairTicketQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"rt.id",
+		"rt.item_id",
+		goqu.I("i.pyr_code").As("pyr_code"),
+		"rt.status",
+		"rt.vendor",
+		"rt.cost",
+		"rt.notes",
+		"rt.expected_return_date",
+		"rt.service_desk_request_id",
+		"rt.created_by_id",
+		"rt.opened_at",
+		"rt.closed_at",
+	).
+		From(goqu.T("repair_tickets").As("rt")).
+		LeftJoin(
+			goqu.T("items").As("i"),
+			goqu.On(goqu.Ex{"rt.item_id": goqu.I("i.id")}),
+		)
+}
@@ -0,0 +1,31 @@
+package repairs
+
+type CreateRepairRequest struct {
+	AssetID              int      `json:"asset_id"`
+	PyrCode              string   `json:"pyr_code"`
+	Vendor               *string  `json:"vendor"`
+	Cost                 *float64 `json:"cost"`
+	Notes                *string  `json:"notes"`
+	ExpectedReturnDate   *string  `json:"expected_return_date"`
+	ServiceDeskRequestID *int     `json:"-"`
+	CreatedByID          *int     `json:"-"`
+}
+
+type PatchRepairRequest struct {
+	ID                 int      `uri:"id" binding:"required"`
+	Vendor             *string  `json:"vendor"`
+	Cost               *float64 `json:"cost"`
+	Notes              *string  `json:"notes"`
+	ExpectedReturnDate *string  `json:"expected_return_date"`
+}
+
+type CloseRepairRequest struct {
+	ID         int     `uri:"id" binding:"required"`
+	Resolution string  `json:"resolution" binding:"required,oneof=repaired unrepairable"`
+	Notes      *string `json:"notes"`
+}
+
+type RetrieveRepairListQuery struct {
+	Status  string `form:"status"`
+	AssetID int    `form:"asset_id"`
+}
@@ -0,0 +1,278 @@
+package repairs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"warehouse/internal/inventory/assets"
+	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/repository"
+	"warehouse/internal/service_desk"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrAssetNotFound              = errors.New("asset not found")
+	ErrRepairNotFound             = errors.New("repair ticket not found")
+	ErrServiceDeskRequestNotFound = errors.New("service desk request not found")
+	ErrNotHardwareIssue           = errors.New("only hardware_issue requests can spawn repair tickets")
+	ErrInvalidExpectedReturn      = errors.New("expected_return_date must be in YYYY-MM-DD format")
+)
+
+type RepairService struct {
+	r   *repository.Repository
+	rr  *RepairRepository
+	ar  *assets.AssetsRepository
+	sdr *service_desk.ServiceDeskRepository
+	a   *auditlog.Auditlog
+	il  *inventorylog.InventoryLog
+}
+
+func NewService(
+	r *repository.Repository,
+	rr *RepairRepository,
+	ar *assets.AssetsRepository,
+	sdr *service_desk.ServiceDeskRepository,
+	a *auditlog.Auditlog,
+) *RepairService {
+	return &RepairService{
+		r:   r,
+		rr:  rr,
+		ar:  ar,
+		sdr: sdr,
+		a:   a,
+		il:  inventorylog.NewInventoryLog(a),
+	}
+}
+
+func (s *RepairService) OpenRepair(req CreateRepairRequest) (*models.RepairTicket, error) {
+	if req.AssetID == 0 && req.PyrCode == "" {
+		return nil, ErrAssetNotFound
+	}
+
+	var asset *models.Asset
+	var err error
+	if req.AssetID == 0 {
+		asset, err = s.ar.FindItemByPyrCode(req.PyrCode)
+	} else {
+		asset, err = s.ar.GetAsset(req.AssetID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil || asset.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+	req.AssetID = asset.ID
+
+	expectedReturnDate, err := parseExpectedReturnDate(req.ExpectedReturnDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var ticketID int
+	var statusChanges []models.AssetStatusChange
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if statusChanges, err = s.ar.UpdateItemStatus([]int{req.AssetID}, metadata.StatusInRepair, tx); err != nil {
+			return err
+		}
+		if ticketID, err = s.rr.InsertRepairTicket(tx, req, expectedReturnDate); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.rr.GetRepairTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, 0)
+	go s.a.Log(
+		"create",
+		map[string]interface{}{
+			"asset_id":                req.AssetID,
+			"service_desk_request_id": req.ServiceDeskRequestID,
+			"msg":                     "Zasób przekazany do naprawy",
+		},
+		ticket,
+	)
+
+	return ticket, nil
+}
+
+// OpenRepairFromServiceDeskRequest spawns a repair ticket for a hardware_issue reported in the service desk
+func (s *RepairService) OpenRepairFromServiceDeskRequest(requestID int, req CreateRepairRequest) (*models.RepairTicket, error) {
+	sdRequest, err := s.sdr.GetRequest(requestID)
+	if errors.Is(err, service_desk.ErrRequestNotFound) {
+		return nil, ErrServiceDeskRequestNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sdRequest.Type != service_desk.RequestTypeHardwareIssue {
+		return nil, ErrNotHardwareIssue
+	}
+
+	req.ServiceDeskRequestID = &requestID
+	ticket, err := s.OpenRepair(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CreatedByID != nil {
+		comment := &service_desk.RequestComment{
+			RequestID: requestID,
+			Content:   fmt.Sprintf("Utworzono zgłoszenie naprawy #%d dla zasobu %d", ticket.ID, ticket.AssetID),
+			UserID:    *req.CreatedByID,
+			CreatedAt: time.Now(),
+		}
+		go func() {
+			if _, err := s.sdr.CreateComment(comment); err != nil {
+				log.Printf("Nie udało się dodać komentarza o naprawie #%d do zgłoszenia %d: %v", ticket.ID, requestID, err)
+			}
+		}()
+	}
+
+	return ticket, nil
+}
+
+func (s *RepairService) UpdateRepair(req PatchRepairRequest) (*models.RepairTicket, error) {
+	record := goqu.Record{}
+	if req.Vendor != nil {
+		record["vendor"] = *req.Vendor
+	}
+	if req.Cost != nil {
+		record["cost"] = *req.Cost
+	}
+	if req.Notes != nil {
+		record["notes"] = *req.Notes
+	}
+	if req.ExpectedReturnDate != nil {
+		expectedReturnDate, err := parseExpectedReturnDate(req.ExpectedReturnDate)
+		if err != nil {
+			return nil, err
+		}
+		record["expected_return_date"] = expectedReturnDate
+	}
+
+	// Zamknięte zgłoszenie jest niezmienne, także gdy żądanie nie zmienia żadnego pola
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		ticket, err := s.rr.LockOpenRepairTicket(tx, req.ID, models.RepairStatusOpen)
+		if err != nil {
+			return err
+		}
+		if ticket == nil {
+			return ErrRepairNotFound
+		}
+		if len(record) == 0 {
+			return nil
+		}
+		return s.rr.UpdateRepairTicket(tx, req.ID, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.rr.GetRepairTicket(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == nil {
+		return nil, ErrRepairNotFound
+	}
+
+	go s.a.Log("update", record, ticket)
+
+	return ticket, nil
+}
+
+// CloseRepair returns a repaired asset to the warehouse, an unrepairable one is marked unavailable
+func (s *RepairService) CloseRepair(req CloseRepairRequest) (*models.RepairTicket, error) {
+	var statusChange *models.AssetStatusChange
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		ticket, err := s.rr.LockOpenRepairTicket(tx, req.ID, "closed")
+		if err != nil {
+			return err
+		}
+		if ticket == nil {
+			return ErrRepairNotFound
+		}
+
+		if req.Resolution == models.RepairStatusRepaired {
+			statusChange, err = s.ar.UpdateAssetStatusAndLocation(tx, ticket.AssetID, models.DefaultEquipmentLocationID, metadata.StatusAvailable)
+			if err != nil {
+				return err
+			}
+		} else {
+			changes, err := s.ar.UpdateItemStatus([]int{ticket.AssetID}, metadata.StatusUnavailable, tx)
+			if err != nil {
+				return err
+			}
+			statusChange = &changes[0]
+		}
+
+		return s.rr.CloseRepairTicket(tx, req.ID, req.Resolution, req.Notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := s.rr.GetRepairTicket(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.il.CreateAssetStatusChangeLogEntries([]models.AssetStatusChange{*statusChange}, 0)
+	go s.a.Log(
+		"close",
+		map[string]interface{}{
+			"asset_id":   ticket.AssetID,
+			"resolution": req.Resolution,
+			"msg":        "Naprawa zakończona",
+		},
+		ticket,
+	)
+
+	return ticket, nil
+}
+
+func (s *RepairService) GetRepair(id int) (*models.RepairTicket, error) {
+	ticket, err := s.rr.GetRepairTicket(id)
+	if err != nil {
+		return nil, err
+	}
+	if ticket == nil {
+		return nil, ErrRepairNotFound
+	}
+
+	return ticket, nil
+}
+
+func (s *RepairService) GetRepairs(query RetrieveRepairListQuery) ([]models.RepairTicket, error) {
+	return s.rr.GetRepairTickets(query)
+}
+
+func parseExpectedReturnDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, ErrInvalidExpectedReturn
+	}
+
+	return &date, nil
+}
@@ -0,0 +1,119 @@
+package reservations
+
+import (
+	"sort"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+)
+
+// findConflicts checks holding reservations against current assets and stock. An asset is unavailable when it left
+// the source location or cannot be sent, stock is short when reservations of a row promise more than it holds.
+func findConflicts(reservations []models.Reservation) []models.ReservationConflict {
+	var conflicts []models.ReservationConflict
+
+	assetHolders := make(map[int][]int)
+	stockHolders := make(map[int][]int)
+	stockReserved := make(map[int]int)
+	stockAvailable := make(map[int]int)
+	stockCategories := make(map[int]int)
+
+	for _, reservation := range reservations {
+		for _, asset := range reservation.Assets {
+			assetHolders[asset.AssetID] = append(assetHolders[asset.AssetID], reservation.ID)
+			if asset.LocationID != reservation.FromLocationID || !metadata.Status(asset.Status).CanTransitionTo(metadata.StatusInTransit) {
+				assetID := asset.AssetID
+				conflicts = append(conflicts, models.ReservationConflict{
+					Type:           models.ReservationConflictAssetUnavailable,
+					AssetID:        &assetID,
+					ReservationIDs: []int{reservation.ID},
+				})
+			}
+		}
+
+		for _, stock := range reservation.Stocks {
+			if stock.StockItemID == nil {
+				categoryID := stock.CategoryID
+				conflicts = append(conflicts, models.ReservationConflict{
+					Type:           models.ReservationConflictStockShortage,
+					CategoryID:     &categoryID,
+					Reserved:       stock.Quantity,
+					ReservationIDs: []int{reservation.ID},
+				})
+				continue
+			}
+
+			stockID := *stock.StockItemID
+			available := stock.StockQuantity
+			if stock.StockLocationID == nil || *stock.StockLocationID != reservation.FromLocationID {
+				available = 0
+			}
+			if previous, ok := stockAvailable[stockID]; !ok || available < previous {
+				stockAvailable[stockID] = available
+			}
+			stockHolders[stockID] = appendUnique(stockHolders[stockID], reservation.ID)
+			stockReserved[stockID] += stock.Quantity
+			stockCategories[stockID] = stock.CategoryID
+		}
+	}
+
+	for _, assetID := range sortedKeys(assetHolders) {
+		if len(assetHolders[assetID]) < 2 {
+			continue
+		}
+		assetID := assetID
+		conflicts = append(conflicts, models.ReservationConflict{
+			Type:           models.ReservationConflictAssetDoubleBook,
+			AssetID:        &assetID,
+			ReservationIDs: assetHolders[assetID],
+		})
+	}
+
+	for _, stockID := range sortedKeys(stockHolders) {
+		if stockReserved[stockID] <= stockAvailable[stockID] {
+			continue
+		}
+		stockID, categoryID := stockID, stockCategories[stockID]
+		conflicts = append(conflicts, models.ReservationConflict{
+			Type:           models.ReservationConflictStockShortage,
+			StockItemID:    &stockID,
+			CategoryID:     &categoryID,
+			Reserved:       stockReserved[stockID],
+			Available:      stockAvailable[stockID],
+			ReservationIDs: stockHolders[stockID],
+		})
+	}
+
+	return conflicts
+}
+
+// attachConflicts gives every reservation the conflicts it takes part in
+func attachConflicts(reservations []models.Reservation, conflicts []models.ReservationConflict) {
+	for i := range reservations {
+		for _, conflict := range conflicts {
+			for _, id := range conflict.ReservationIDs {
+				if id == reservations[i].ID {
+					reservations[i].Conflicts = append(reservations[i].Conflicts, conflict)
+					break
+				}
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[int][]int) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func appendUnique(ids []int, id int) []int {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
@@ -0,0 +1,91 @@
+package reservations
+
+import (
+	"testing"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestFindConflictsReportsDoubleBookedAsset(t *testing.T) {
+	reservations := []models.Reservation{
+		{ID: 1, FromLocationID: 1, Assets: []models.ReservationAsset{{AssetID: 10, LocationID: 1, Status: "available"}}},
+		{ID: 2, FromLocationID: 1, Assets: []models.ReservationAsset{{AssetID: 10, LocationID: 1, Status: "available"}}},
+		{ID: 3, FromLocationID: 1, Assets: []models.ReservationAsset{{AssetID: 11, LocationID: 1, Status: "available"}}},
+	}
+
+	conflicts := findConflicts(reservations)
+
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, models.ReservationConflictAssetDoubleBook, conflicts[0].Type)
+	assert.Equal(t, 10, *conflicts[0].AssetID)
+	assert.Equal(t, []int{1, 2}, conflicts[0].ReservationIDs)
+}
+
+func TestFindConflictsReportsUnavailableAsset(t *testing.T) {
+	reservations := []models.Reservation{
+		{ID: 1, FromLocationID: 1, Assets: []models.ReservationAsset{
+			{AssetID: 10, LocationID: 4, Status: "located"},
+			{AssetID: 11, LocationID: 1, Status: "in_repair"},
+			{AssetID: 12, LocationID: 1, Status: "available"},
+		}},
+	}
+
+	conflicts := findConflicts(reservations)
+
+	assert.Len(t, conflicts, 2)
+	assert.Equal(t, models.ReservationConflictAssetUnavailable, conflicts[0].Type)
+	assert.Equal(t, 10, *conflicts[0].AssetID)
+	assert.Equal(t, 11, *conflicts[1].AssetID)
+}
+
+func TestFindConflictsSumsStockAcrossReservations(t *testing.T) {
+	reservations := []models.Reservation{
+		{ID: 1, FromLocationID: 1, Stocks: []models.ReservationStock{
+			{StockItemID: intPtr(5), CategoryID: 3, Quantity: 6, StockLocationID: intPtr(1), StockQuantity: 10},
+			{StockItemID: intPtr(6), CategoryID: 4, Quantity: 2, StockLocationID: intPtr(1), StockQuantity: 10},
+		}},
+		{ID: 2, FromLocationID: 1, Stocks: []models.ReservationStock{
+			{StockItemID: intPtr(5), CategoryID: 3, Quantity: 5, StockLocationID: intPtr(1), StockQuantity: 10},
+		}},
+	}
+
+	conflicts := findConflicts(reservations)
+
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, models.ReservationConflictStockShortage, conflicts[0].Type)
+	assert.Equal(t, 5, *conflicts[0].StockItemID)
+	assert.Equal(t, 11, conflicts[0].Reserved)
+	assert.Equal(t, 10, conflicts[0].Available)
+	assert.Equal(t, []int{1, 2}, conflicts[0].ReservationIDs)
+}
+
+func TestFindConflictsReportsRemovedStockRow(t *testing.T) {
+	reservations := []models.Reservation{
+		{ID: 1, FromLocationID: 1, Stocks: []models.ReservationStock{{CategoryID: 3, Quantity: 4}}},
+	}
+
+	conflicts := findConflicts(reservations)
+
+	assert.Len(t, conflicts, 1)
+	assert.Nil(t, conflicts[0].StockItemID)
+	assert.Equal(t, 3, *conflicts[0].CategoryID)
+	assert.Equal(t, 4, conflicts[0].Reserved)
+}
+
+func TestAttachConflictsToInvolvedReservations(t *testing.T) {
+	reservations := []models.Reservation{{ID: 1}, {ID: 2}, {ID: 3}}
+	conflicts := []models.ReservationConflict{
+		{Type: models.ReservationConflictAssetDoubleBook, AssetID: intPtr(10), ReservationIDs: []int{1, 3}},
+	}
+
+	attachConflicts(reservations, conflicts)
+
+	assert.Len(t, reservations[0].Conflicts, 1)
+	assert.Empty(t, reservations[1].Conflicts)
+	assert.Len(t, reservations[2].Conflicts, 1)
+}
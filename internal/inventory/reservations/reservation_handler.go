@@ -0,0 +1,129 @@
+package reservations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReservationHandler struct {
+	Service *ReservationService
+}
+
+func NewHandler(r *repository.Repository, rr *ReservationRepository, a *auditlog.Auditlog) *ReservationHandler {
+	return &ReservationHandler{
+		Service: NewService(r, rr, a),
+	}
+}
+
+func (h *ReservationHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/reservations", security.Authorize("user"), h.GetReservations)
+	router.GET("/reservations/conflicts", security.Authorize("user"), h.GetConflicts)
+	router.GET("/reservations/:id", security.Authorize("user"), h.GetReservation)
+	router.POST("/reservations", security.Authorize("user"), h.CreateReservation)
+	router.PATCH("/reservations/:id/cancel", security.Authorize("user"), h.CancelReservation)
+}
+
+func (h *ReservationHandler) GetReservations(c *gin.Context) {
+	var query RetrieveReservationListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	reservations, err := h.Service.GetReservations(query)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania rezerwacji", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservations)
+}
+
+func (h *ReservationHandler) GetConflicts(c *gin.Context) {
+	conflicts, err := h.Service.GetConflicts()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania konfliktów rezerwacji", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conflicts)
+}
+
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	reservation, err := h.Service.GetReservation(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania rezerwacji", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	var req CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CreatedByID = &userID
+	}
+
+	reservation, err := h.Service.CreateReservation(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się utworzyć rezerwacji", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	reservation, err := h.Service.CancelReservation(id)
+	if err != nil {
+		h.handleError(c, "Nie udało się anulować rezerwacji", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrReservationNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrEmptyReservation), errors.Is(err, ErrSameLocation), errors.Is(err, ErrPlannedDateInvalid),
+		errors.Is(err, ErrExpiryInvalid), errors.Is(err, ErrInvalidPeriod):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrReservationUnavailable), errors.Is(err, ErrAssetUnavailable), errors.Is(err, ErrAssetReserved),
+		errors.Is(err, ErrStockUnavailable), errors.Is(err, ErrStockReserved):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package reservations
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+)
+
+type ReservationRepository struct {
+	repository *repository.Repository
+}
+
+type selectSource interface {
+	From(from ...interface{}) *goqu.SelectDataset
+}
+
+type lockedAsset struct {
+	ID         int    `db:"id"`
+	LocationID int    `db:"location_id"`
+	Status     string `db:"status"`
+}
+
+type lockedStock struct {
+	ID         int `db:"id"`
+	CategoryID int `db:"item_category_id"`
+	LocationID int `db:"location_id"`
+	Quantity   int `db:"quantity"`
+}
+
+func NewRepository(r *repository.Repository) *ReservationRepository {
+	return &ReservationRepository{
+		repository: r,
+	}
+}
+
+func (r *ReservationRepository) InsertReservation(tx *goqu.TxDatabase, req CreateReservationRequest, expiresAt time.Time) (int, error) {
+	record := goqu.Record{
+		"from_location_id": req.FromLocationID,
+		"to_location_id":   req.ToLocationID,
+		"planned_at":       req.PlannedAt,
+		"expires_at":       expiresAt,
+		"notes":            req.Notes,
+		"created_by_id":    req.CreatedByID,
+	}
+
+	var id int
+	if _, err := tx.Insert("reservations").Rows(record).Returning("id").Executor().ScanVal(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return 0, custom_error.WrapDBError("Nieprawidłowa lokalizacja", string(pqErr.Code))
+		}
+		return 0, fmt.Errorf("failed to insert reservation: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *ReservationRepository) InsertReservationAssets(tx *goqu.TxDatabase, reservationID int, assetIDs []int) error {
+	if len(assetIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]interface{}, len(assetIDs))
+	for i, assetID := range assetIDs {
+		rows[i] = goqu.Record{"reservation_id": reservationID, "item_id": assetID}
+	}
+
+	if _, err := tx.Insert("reservation_assets").Rows(rows...).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to insert reserved assets: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReservationRepository) InsertReservationStocks(tx *goqu.TxDatabase, reservationID int, stocks []models.StockItemRequest, locked map[int]lockedStock) error {
+	if len(stocks) == 0 {
+		return nil
+	}
+
+	rows := make([]interface{}, len(stocks))
+	for i, stock := range stocks {
+		rows[i] = goqu.Record{
+			"reservation_id":   reservationID,
+			"stock_item_id":    stock.ID,
+			"item_category_id": locked[stock.ID].CategoryID,
+			"quantity":         stock.Quantity,
+		}
+	}
+
+	if _, err := tx.Insert("reservation_stocks").Rows(rows...).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to insert reserved stock: %w", err)
+	}
+
+	return nil
+}
+
+// LockAssets locks reserved assets so two reservations cannot take the same asset at once
+func (r *ReservationRepository) LockAssets(tx *goqu.TxDatabase, assetIDs []int) (map[int]lockedAsset, error) {
+	var assets []lockedAsset
+	err := tx.Select("id", "location_id", "status").
+		From("items").
+		Where(goqu.Ex{"id": assetIDs}).
+		Order(goqu.I("id").Asc()).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStructs(&assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock assets: %w", err)
+	}
+
+	locked := make(map[int]lockedAsset, len(assets))
+	for _, asset := range assets {
+		locked[asset.ID] = asset
+	}
+
+	return locked, nil
+}
+
+func (r *ReservationRepository) LockStocks(tx *goqu.TxDatabase, stockIDs []int) (map[int]lockedStock, error) {
+	var stocks []lockedStock
+	err := tx.Select("id", "item_category_id", "location_id", "quantity").
+		From("non_serialized_items").
+		Where(goqu.Ex{"id": stockIDs}).
+		Order(goqu.I("id").Asc()).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStructs(&stocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock: %w", err)
+	}
+
+	locked := make(map[int]lockedStock, len(stocks))
+	for _, stock := range stocks {
+		locked[stock.ID] = stock
+	}
+
+	return locked, nil
+}
+
+// LockReservation locks a reservation for a status change, returns nil when there is none
+func (r *ReservationRepository) LockReservation(tx *goqu.TxDatabase, id int) (*models.Reservation, error) {
+	var reservation models.Reservation
+	found, err := tx.Select("id", "from_location_id", "to_location_id", "status", "expires_at").
+		From("reservations").
+		Where(goqu.Ex{"id": id}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&reservation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock reservation: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &reservation, nil
+}
+
+func (r *ReservationRepository) UpdateReservationStatus(tx *goqu.TxDatabase, id int, status string, transferID *int) error {
+	record := goqu.Record{"status": status}
+	if transferID != nil {
+		record["transfer_id"] = *transferID
+	}
+
+	_, err := tx.Update("reservations").
+		Set(record).
+		Where(goqu.Ex{"id": id}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+
+	return nil
+}
+
+// FulfillReservation closes a holding reservation with the transfer created from it, which releases its items
+func (r *ReservationRepository) FulfillReservation(tx *goqu.TxDatabase, id int, fromLocationID int, transferID int) error {
+	reservation, err := r.LockReservation(tx, id)
+	if err != nil {
+		return err
+	}
+	if reservation == nil {
+		return ErrReservationNotFound
+	}
+
+	now := time.Now()
+	if !reservation.IsHolding(now) {
+		return fmt.Errorf("%w: %s", ErrReservationUnavailable, reservation.ReportedStatus(now))
+	}
+	if reservation.FromLocationID != fromLocationID {
+		return fmt.Errorf("%w: items are reserved at location %d", ErrReservationUnavailable, reservation.FromLocationID)
+	}
+
+	return r.UpdateReservationStatus(tx, id, models.ReservationStatusFulfilled, &transferID)
+}
+
+// GetHeldAssets returns those of the assets held by a reservation other than exceptID
+func (r *ReservationRepository) GetHeldAssets(assetIDs []int, exceptID int, now time.Time) ([]int, error) {
+	return r.heldAssets(r.repository.GoquDBWrapper, assetIDs, exceptID, now)
+}
+
+// GetUnreservedStock returns quantities of stock rows left after subtracting holds of reservations other than exceptID
+func (r *ReservationRepository) GetUnreservedStock(stockIDs []int, exceptID int, now time.Time) (map[int]int, error) {
+	return r.unreservedStock(r.repository.GoquDBWrapper, stockIDs, exceptID, now)
+}
+
+func (r *ReservationRepository) heldAssets(db selectSource, assetIDs []int, exceptID int, now time.Time) ([]int, error) {
+	held := []int{}
+	if len(assetIDs) == 0 {
+		return held, nil
+	}
+
+	err := db.From(goqu.T("reservation_assets").As("ra")).
+		Select("ra.item_id").
+		Distinct().
+		Join(goqu.T("reservations").As("r"), goqu.On(goqu.Ex{"ra.reservation_id": goqu.I("r.id")})).
+		Where(
+			goqu.Ex{"ra.item_id": assetIDs},
+			holdingConditions(exceptID, now),
+		).
+		Executor().
+		ScanVals(&held)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reserved assets: %w", err)
+	}
+
+	return held, nil
+}
+
+func (r *ReservationRepository) unreservedStock(db selectSource, stockIDs []int, exceptID int, now time.Time) (map[int]int, error) {
+	unreserved := make(map[int]int, len(stockIDs))
+	if len(stockIDs) == 0 {
+		return unreserved, nil
+	}
+
+	held := db.From(goqu.T("reservation_stocks").As("rs")).
+		Select(goqu.COALESCE(goqu.SUM("rs.quantity"), 0)).
+		Join(goqu.T("reservations").As("r"), goqu.On(goqu.Ex{"rs.reservation_id": goqu.I("r.id")})).
+		Where(
+			goqu.Ex{"rs.stock_item_id": goqu.I("s.id")},
+			holdingConditions(exceptID, now),
+		)
+
+	var rows []struct {
+		ID       int `db:"id"`
+		Quantity int `db:"quantity"`
+	}
+	err := db.From(goqu.T("non_serialized_items").As("s")).
+		Select("s.id", goqu.L("s.quantity - (?)", held).As("quantity")).
+		Where(goqu.Ex{"s.id": stockIDs}).
+		Executor().
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reserved stock: %w", err)
+	}
+
+	for _, row := range rows {
+		unreserved[row.ID] = row.Quantity
+	}
+
+	return unreserved, nil
+}
+
+// holdingConditions matches reservations that still block their items, expired ones release them without a status change
+func holdingConditions(exceptID int, now time.Time) exp.ExpressionList {
+	return goqu.And(
+		goqu.Ex{"r.status": models.ReservationStatusActive},
+		goqu.I("r.expires_at").Gt(now),
+		goqu.I("r.id").Neq(exceptID),
+	)
+}
+
+func (r *ReservationRepository) GetReservation(id int) (*models.Reservation, error) {
+	var reservation models.Reservation
+	found, err := r.getReservationQuery().Where(goqu.Ex{"r.id": id}).Executor().ScanStruct(&reservation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	reservations := []models.Reservation{reservation}
+	if err := r.loadItems(reservations); err != nil {
+		return nil, err
+	}
+
+	return &reservations[0], nil
+}
+
+func (r *ReservationRepository) GetReservations(query RetrieveReservationListQuery, from, to *time.Time, now time.Time) ([]models.Reservation, error) {
+	var conditions []exp.Expression
+	switch query.Status {
+	case models.ReservationStatusActive:
+		conditions = append(conditions, goqu.Ex{"r.status": models.ReservationStatusActive}, goqu.I("r.expires_at").Gt(now))
+	case models.ReservationStatusExpired:
+		conditions = append(conditions, goqu.Ex{"r.status": models.ReservationStatusActive}, goqu.I("r.expires_at").Lte(now))
+	case "":
+	default:
+		conditions = append(conditions, goqu.Ex{"r.status": query.Status})
+	}
+	if query.LocationID != 0 {
+		conditions = append(conditions, goqu.Or(
+			goqu.Ex{"r.from_location_id": query.LocationID},
+			goqu.Ex{"r.to_location_id": query.LocationID},
+		))
+	}
+	if query.AssetID != 0 {
+		conditions = append(conditions, goqu.I("r.id").In(
+			r.repository.GoquDBWrapper.From("reservation_assets").Select("reservation_id").Where(goqu.Ex{"item_id": query.AssetID}),
+		))
+	}
+	if from != nil {
+		conditions = append(conditions, goqu.I("r.planned_at").Gte(*from))
+	}
+	if to != nil {
+		conditions = append(conditions, goqu.I("r.planned_at").Lt(to.AddDate(0, 0, 1)))
+	}
+
+	return r.scanReservations(r.getReservationQuery().Where(conditions...).Order(goqu.I("r.planned_at").Asc(), goqu.I("r.id").Asc()))
+}
+
+// GetHoldingReservations lists every reservation still blocking its items, conflicts are found among all of them
+func (r *ReservationRepository) GetHoldingReservations(now time.Time) ([]models.Reservation, error) {
+	query := r.getReservationQuery().
+		Where(holdingConditions(0, now)).
+		Order(goqu.I("r.planned_at").Asc(), goqu.I("r.id").Asc())
+
+	return r.scanReservations(query)
+}
+
+func (r *ReservationRepository) scanReservations(query *goqu.SelectDataset) ([]models.Reservation, error) {
+	reservations := []models.Reservation{}
+	if err := query.Executor().ScanStructs(&reservations); err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if err := r.loadItems(reservations); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// loadItems fills reserved assets and stock together with their current state
+func (r *ReservationRepository) loadItems(reservations []models.Reservation) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(reservations))
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+	}
+
+	var assets []models.ReservationAsset
+	err := r.repository.GoquDBWrapper.
+		Select(
+			"ra.reservation_id",
+			"ra.item_id",
+			"i.pyr_code",
+			goqu.I("c.label").As("category_label"),
+			"i.location_id",
+			"i.status",
+		).
+		From(goqu.T("reservation_assets").As("ra")).
+		Join(goqu.T("items").As("i"), goqu.On(goqu.Ex{"ra.item_id": goqu.I("i.id")})).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"i.item_category_id": goqu.I("c.id")})).
+		Where(goqu.Ex{"ra.reservation_id": ids}).
+		Order(goqu.I("ra.item_id").Asc()).
+		Executor().
+		ScanStructs(&assets)
+	if err != nil {
+		return fmt.Errorf("failed to query reserved assets: %w", err)
+	}
+
+	var stocks []models.ReservationStock
+	err = r.repository.GoquDBWrapper.
+		Select(
+			"rs.reservation_id",
+			"rs.stock_item_id",
+			"rs.item_category_id",
+			goqu.I("c.label").As("category_label"),
+			"rs.quantity",
+			goqu.I("s.location_id").As("stock_location_id"),
+			goqu.L("COALESCE(s.quantity, 0)").As("stock_quantity"),
+		).
+		From(goqu.T("reservation_stocks").As("rs")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"rs.item_category_id": goqu.I("c.id")})).
+		LeftJoin(goqu.T("non_serialized_items").As("s"), goqu.On(goqu.Ex{"rs.stock_item_id": goqu.I("s.id")})).
+		Where(goqu.Ex{"rs.reservation_id": ids}).
+		Order(goqu.I("rs.id").Asc()).
+		Executor().
+		ScanStructs(&stocks)
+	if err != nil {
+		return fmt.Errorf("failed to query reserved stock: %w", err)
+	}
+
+	index := make(map[int]int, len(reservations))
+	for i := range reservations {
+		index[reservations[i].ID] = i
+		reservations[i].Assets = []models.ReservationAsset{}
+		reservations[i].Stocks = []models.ReservationStock{}
+	}
+	for _, asset := range assets {
+		i := index[asset.ReservationID]
+		reservations[i].Assets = append(reservations[i].Assets, asset)
+	}
+	for _, stock := range stocks {
+		i := index[stock.ReservationID]
+		reservations[i].Stocks = append(reservations[i].Stocks, stock)
+	}
+
+	return nil
+}
+
+func (r *ReservationRepository) getReservationQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"r.id",
+		"r.from_location_id",
+		goqu.I("fl.name").As("from_location_name"),
+		"r.to_location_id",
+		goqu.I("tl.name").As("to_location_name"),
+		"r.planned_at",
+		"r.expires_at",
+		"r.status",
+		"r.transfer_id",
+		"r.notes",
+		"r.created_by_id",
+		"r.created_at",
+	).
+		From(goqu.T("reservations").As("r")).
+		Join(goqu.T("locations").As("fl"), goqu.On(goqu.Ex{"r.from_location_id": goqu.I("fl.id")})).
+		Join(goqu.T("locations").As("tl"), goqu.On(goqu.Ex{"r.to_location_id": goqu.I("tl.id")}))
+}
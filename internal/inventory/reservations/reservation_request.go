@@ -0,0 +1,25 @@
+package reservations
+
+import (
+	"time"
+	"warehouse/pkg/models"
+)
+
+type CreateReservationRequest struct {
+	FromLocationID int                       `json:"from_location_id" binding:"required"`
+	ToLocationID   int                       `json:"location_id" binding:"required"`
+	PlannedAt      time.Time                 `json:"planned_at" binding:"required"`
+	ExpiresAt      *time.Time                `json:"expires_at"`
+	Assets         []models.AssetItemRequest `json:"assets" binding:"dive"`
+	Stocks         []models.StockItemRequest `json:"stocks" binding:"dive"`
+	Notes          *string                   `json:"notes"`
+	CreatedByID    *int                      `json:"-"`
+}
+
+type RetrieveReservationListQuery struct {
+	Status     string `form:"status" binding:"omitempty,oneof=active expired fulfilled cancelled"`
+	LocationID int    `form:"location_id"`
+	AssetID    int    `form:"asset_id"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+}
@@ -0,0 +1,303 @@
+package reservations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// DefaultReservationHold is how long a reservation without expires_at holds items after its planned date
+const DefaultReservationHold = 24 * time.Hour
+
+var (
+	ErrReservationNotFound    = errors.New("reservation not found")
+	ErrReservationUnavailable = errors.New("reservation is no longer active")
+	ErrEmptyReservation       = errors.New("reservation needs assets or stocks")
+	ErrSameLocation           = errors.New("reservation from and to location cannot be the same")
+	ErrPlannedDateInvalid     = errors.New("planned_at cannot be in the past")
+	ErrExpiryInvalid          = errors.New("expires_at must be in the future and not before planned_at")
+	ErrAssetUnavailable       = errors.New("asset cannot be sent from the source location")
+	ErrAssetReserved          = errors.New("asset is already reserved")
+	ErrStockUnavailable       = errors.New("stock is not present in the source location")
+	ErrStockReserved          = errors.New("not enough unreserved stock")
+	ErrInvalidPeriod          = errors.New("from and to have to be dates in YYYY-MM-DD format, to not before from")
+)
+
+type ReservationService struct {
+	r  *repository.Repository
+	rr *ReservationRepository
+	a  *auditlog.Auditlog
+}
+
+func NewService(r *repository.Repository, rr *ReservationRepository, a *auditlog.Auditlog) *ReservationService {
+	return &ReservationService{
+		r:  r,
+		rr: rr,
+		a:  a,
+	}
+}
+
+// CreateReservation holds assets and stock quantities at the source location, items already held by another
+// reservation cannot be promised twice
+func (s *ReservationService) CreateReservation(req CreateReservationRequest) (*models.Reservation, error) {
+	expiresAt, err := validateReservation(req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	assetIDs := make([]int, len(req.Assets))
+	for i, asset := range req.Assets {
+		assetIDs[i] = asset.ID
+	}
+	stockIDs := make([]int, len(req.Stocks))
+	for i, stock := range req.Stocks {
+		stockIDs[i] = stock.ID
+	}
+
+	var reservationID int
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		now := time.Now()
+
+		if len(assetIDs) > 0 {
+			assets, err := s.rr.LockAssets(tx, assetIDs)
+			if err != nil {
+				return err
+			}
+			for _, id := range assetIDs {
+				asset, ok := assets[id]
+				if !ok || asset.LocationID != req.FromLocationID || !metadata.Status(asset.Status).CanTransitionTo(metadata.StatusInTransit) {
+					return fmt.Errorf("%w: %d", ErrAssetUnavailable, id)
+				}
+			}
+
+			held, err := s.rr.heldAssets(tx, assetIDs, 0, now)
+			if err != nil {
+				return err
+			}
+			if len(held) > 0 {
+				return fmt.Errorf("%w: %v", ErrAssetReserved, held)
+			}
+		}
+
+		var stocks map[int]lockedStock
+		if len(stockIDs) > 0 {
+			if stocks, err = s.rr.LockStocks(tx, stockIDs); err != nil {
+				return err
+			}
+			unreserved, err := s.rr.unreservedStock(tx, stockIDs, 0, now)
+			if err != nil {
+				return err
+			}
+
+			requested := make(map[int]int, len(req.Stocks))
+			for _, stock := range req.Stocks {
+				requested[stock.ID] += stock.Quantity
+			}
+			for id, quantity := range requested {
+				stock, ok := stocks[id]
+				if !ok || stock.LocationID != req.FromLocationID {
+					return fmt.Errorf("%w: %d", ErrStockUnavailable, id)
+				}
+				if unreserved[id] < quantity {
+					return fmt.Errorf("%w for stock %d: %d left", ErrStockReserved, id, max(unreserved[id], 0))
+				}
+			}
+		}
+
+		if reservationID, err = s.rr.InsertReservation(tx, req, expiresAt); err != nil {
+			return err
+		}
+		if err = s.rr.InsertReservationAssets(tx, reservationID, assetIDs); err != nil {
+			return err
+		}
+		return s.rr.InsertReservationStocks(tx, reservationID, req.Stocks, stocks)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.GetReservation(reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(
+		"reserve",
+		map[string]interface{}{
+			"from_location_id": reservation.FromLocationID,
+			"to_location_id":   reservation.ToLocationID,
+			"planned_at":       reservation.PlannedAt,
+			"expires_at":       reservation.ExpiresAt,
+			"msg":              "Zarezerwowano sprzęt pod planowany transfer",
+		},
+		reservation,
+	)
+
+	return reservation, nil
+}
+
+// CancelReservation releases the items of an active reservation, an expired one can be cancelled as well
+func (s *ReservationService) CancelReservation(id int) (*models.Reservation, error) {
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		reservation, err := s.rr.LockReservation(tx, id)
+		if err != nil {
+			return err
+		}
+		if reservation == nil {
+			return ErrReservationNotFound
+		}
+		if reservation.Status != models.ReservationStatusActive {
+			return fmt.Errorf("%w: %s", ErrReservationUnavailable, reservation.Status)
+		}
+
+		return s.rr.UpdateReservationStatus(tx, id, models.ReservationStatusCancelled, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.GetReservation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(
+		"cancel",
+		map[string]interface{}{
+			"msg": "Anulowano rezerwację",
+		},
+		reservation,
+	)
+
+	return reservation, nil
+}
+
+func (s *ReservationService) GetReservation(id int) (*models.Reservation, error) {
+	reservation, err := s.rr.GetReservation(id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+
+	reservations := []models.Reservation{*reservation}
+	if err := s.withConflicts(reservations, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &reservations[0], nil
+}
+
+// GetReservations is the planning view, holding reservations come with the conflicts they take part in
+func (s *ReservationService) GetReservations(query RetrieveReservationListQuery) ([]models.Reservation, error) {
+	from, to, err := parsePeriod(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reservations, err := s.rr.GetReservations(query, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.withConflicts(reservations, now); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func (s *ReservationService) GetConflicts() ([]models.ReservationConflict, error) {
+	holding, err := s.rr.GetHoldingReservations(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := findConflicts(holding)
+	if conflicts == nil {
+		return []models.ReservationConflict{}, nil
+	}
+
+	return conflicts, nil
+}
+
+// withConflicts reports expired reservations and attaches conflicts found among all holding reservations
+func (s *ReservationService) withConflicts(reservations []models.Reservation, now time.Time) error {
+	anyHolding := false
+	for i := range reservations {
+		anyHolding = anyHolding || reservations[i].IsHolding(now)
+		reservations[i].Status = reservations[i].ReportedStatus(now)
+	}
+	if !anyHolding {
+		return nil
+	}
+
+	holding, err := s.rr.GetHoldingReservations(now)
+	if err != nil {
+		return err
+	}
+	attachConflicts(reservations, findConflicts(holding))
+
+	return nil
+}
+
+func validateReservation(req CreateReservationRequest, now time.Time) (time.Time, error) {
+	if len(req.Assets) == 0 && len(req.Stocks) == 0 {
+		return time.Time{}, ErrEmptyReservation
+	}
+	if req.FromLocationID == req.ToLocationID {
+		return time.Time{}, ErrSameLocation
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if req.PlannedAt.Before(today) {
+		return time.Time{}, ErrPlannedDateInvalid
+	}
+
+	expiresAt := req.PlannedAt.Add(DefaultReservationHold)
+	if req.ExpiresAt != nil {
+		expiresAt = *req.ExpiresAt
+	}
+	if !expiresAt.After(now) || expiresAt.Before(req.PlannedAt) {
+		return time.Time{}, ErrExpiryInvalid
+	}
+
+	return expiresAt, nil
+}
+
+func parsePeriod(from, to string) (*time.Time, *time.Time, error) {
+	fromDate, err := parseDate(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	toDate, err := parseDate(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fromDate != nil && toDate != nil && toDate.Before(*fromDate) {
+		return nil, nil, ErrInvalidPeriod
+	}
+
+	return fromDate, toDate, nil
+}
+
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, ErrInvalidPeriod
+	}
+
+	return &date, nil
+}
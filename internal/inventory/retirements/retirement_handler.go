@@ -0,0 +1,65 @@
+package retirements
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/inventory/kits"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RetirementHandler struct {
+	Service *RetirementService
+}
+
+func NewHandler(r *repository.Repository, ar *assets.AssetsRepository, a *auditlog.Auditlog) *RetirementHandler {
+	return &RetirementHandler{
+		Service: NewService(r, NewRepository(r), ar, kits.NewRepository(r), a),
+	}
+}
+
+func (h *RetirementHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/assets/:id/retire", security.Authorize("moderator"), h.RetireAsset)
+}
+
+func (h *RetirementHandler) RetireAsset(c *gin.Context) {
+	var req RetireAssetRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.RetiredByID = &userID
+	}
+
+	asset, err := h.Service.RetireAsset(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się wycofać zasobu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, asset)
+}
+
+func (h *RetirementHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrAssetNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrNotRentedAsset), errors.Is(err, ErrInvalidRetiredDate):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
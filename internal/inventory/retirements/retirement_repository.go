@@ -0,0 +1,34 @@
+package retirements
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type RetirementRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *RetirementRepository {
+	return &RetirementRepository{
+		repository: r,
+	}
+}
+
+func (r *RetirementRepository) SetRetirement(tx *goqu.TxDatabase, req RetireAssetRequest, retiredAt time.Time) error {
+	record := goqu.Record{
+		"retired_at":        retiredAt,
+		"retirement_reason": req.Reason,
+		"retirement_notes":  req.Notes,
+		"retired_by_id":     req.RetiredByID,
+	}
+
+	if _, err := tx.Update("items").Set(record).Where(goqu.Ex{"id": req.ID}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to retire asset: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+package retirements
+
+type RetireAssetRequest struct {
+	ID          int     `uri:"id" binding:"required"`
+	Reason      string  `json:"reason" binding:"required,oneof=lost stolen broken returned_to_origin"`
+	RetiredAt   *string `json:"retired_at"`
+	Notes       *string `json:"notes"`
+	RetiredByID *int    `json:"-"`
+}
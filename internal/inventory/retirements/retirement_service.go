@@ -0,0 +1,121 @@
+package retirements
+
+import (
+	"errors"
+	"time"
+	"warehouse/internal/inventory/assets"
+	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/inventory/kits"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrAssetNotFound      = errors.New("asset not found")
+	ErrNotRentedAsset     = errors.New("only assets rented from a company can be returned to origin")
+	ErrInvalidRetiredDate = errors.New("retired_at must be a past date in YYYY-MM-DD format")
+)
+
+type RetirementService struct {
+	r  *repository.Repository
+	rr *RetirementRepository
+	ar *assets.AssetsRepository
+	kr *kits.KitRepository
+	a  *auditlog.Auditlog
+	il *inventorylog.InventoryLog
+}
+
+func NewService(
+	r *repository.Repository,
+	rr *RetirementRepository,
+	ar *assets.AssetsRepository,
+	kr *kits.KitRepository,
+	a *auditlog.Auditlog,
+) *RetirementService {
+	return &RetirementService{
+		r:  r,
+		rr: rr,
+		ar: ar,
+		kr: kr,
+		a:  a,
+		il: inventorylog.NewInventoryLog(a),
+	}
+}
+
+// RetireAsset takes an asset out of the inventory while keeping its row and history, it also leaves any kit it belonged to
+func (s *RetirementService) RetireAsset(req RetireAssetRequest) (*models.Asset, error) {
+	asset, err := s.ar.GetAsset(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if asset.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+	if req.Reason == models.RetirementReasonReturnedToOrigin {
+		origin, err := s.r.GetOriginBySlug(asset.Origin.String())
+		if err != nil {
+			return nil, err
+		}
+		if origin == nil || !origin.IsRental {
+			return nil, ErrNotRentedAsset
+		}
+	}
+
+	retiredAt, err := parseRetiredAt(req.RetiredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var statusChanges []models.AssetStatusChange
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if statusChanges, err = s.ar.UpdateItemStatus([]int{req.ID}, metadata.StatusRetired, tx); err != nil {
+			return err
+		}
+		if err := s.rr.SetRetirement(tx, req, retiredAt); err != nil {
+			return err
+		}
+		return s.kr.RemoveAssetFromKits(tx, req.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	retired, err := s.ar.GetAsset(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, 0)
+	go s.a.Log(
+		"retire",
+		map[string]interface{}{
+			"reason":        req.Reason,
+			"retired_at":    retiredAt,
+			"retired_by_id": req.RetiredByID,
+			"notes":         req.Notes,
+			"msg":           "Zasób wycofany z ewidencji",
+		},
+		retired,
+	)
+
+	return retired, nil
+}
+
+func parseRetiredAt(value *string) (time.Time, error) {
+	if value == nil || *value == "" {
+		return time.Now(), nil
+	}
+
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil || date.After(time.Now()) {
+		return time.Time{}, ErrInvalidRetiredDate
+	}
+
+	return date, nil
+}
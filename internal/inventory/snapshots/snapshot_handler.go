@@ -0,0 +1,48 @@
+package snapshots
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/repository"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SnapshotHandler struct {
+	Service *SnapshotService
+}
+
+func NewHandler(r *repository.Repository) *SnapshotHandler {
+	return &SnapshotHandler{
+		Service: NewService(NewRepository(r)),
+	}
+}
+
+func (h *SnapshotHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/locations/:id/snapshot", security.Authorize("user"), h.GetSnapshot)
+}
+
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	var query SnapshotQuery
+	if err := c.ShouldBindUri(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	snapshot, err := h.Service.GetSnapshot(query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSnapshotTime) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy moment stanu", "details": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd odtwarzania stanu lokalizacji", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
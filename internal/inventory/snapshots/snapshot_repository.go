@@ -0,0 +1,89 @@
+package snapshots
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type SnapshotRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *SnapshotRepository {
+	return &SnapshotRepository{
+		repository: r,
+	}
+}
+
+// assetHistoryEntry is a recorded location and status of an asset, both are null once the asset was deleted
+type assetHistoryEntry struct {
+	ID            int       `db:"id"`
+	AssetID       int       `db:"item_id"`
+	LocationID    *int      `db:"location_id"`
+	Status        *string   `db:"status"`
+	ChangedAt     time.Time `db:"changed_at"`
+	PyrCode       *string   `db:"pyr_code"`
+	CategoryLabel *string   `db:"category_label"`
+}
+
+// GetAssetHistoryAt lists history up to the given time of every asset which has been at the location by then
+func (r *SnapshotRepository) GetAssetHistoryAt(locationID int, at time.Time) ([]assetHistoryEntry, error) {
+	visited := r.repository.GoquDBWrapper.From("asset_location_history").
+		Select("item_id").
+		Where(goqu.Ex{"location_id": locationID}, goqu.C("changed_at").Lte(at))
+
+	entries := []assetHistoryEntry{}
+	err := r.repository.GoquDBWrapper.
+		Select(
+			"h.id",
+			"h.item_id",
+			"h.location_id",
+			"h.status",
+			"h.changed_at",
+			goqu.I("i.pyr_code"),
+			goqu.I("c.label").As("category_label"),
+		).
+		From(goqu.T("asset_location_history").As("h")).
+		LeftJoin(goqu.T("items").As("i"), goqu.On(goqu.Ex{"h.item_id": goqu.I("i.id")})).
+		LeftJoin(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"i.item_category_id": goqu.I("c.id")})).
+		Where(goqu.I("h.item_id").In(visited), goqu.I("h.changed_at").Lte(at)).
+		Executor().
+		ScanStructs(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetStockAt sums ledger entries applied at the location up to the given time
+func (r *SnapshotRepository) GetStockAt(locationID int, at time.Time) ([]models.SnapshotStock, error) {
+	stock := []models.SnapshotStock{}
+	err := r.repository.GoquDBWrapper.
+		Select(
+			"sa.item_category_id",
+			goqu.I("c.label").As("category_label"),
+			"sa.origin",
+			goqu.SUM("sa.delta").As("quantity"),
+		).
+		From(goqu.T("stock_adjustments").As("sa")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"sa.item_category_id": goqu.I("c.id")})).
+		Where(
+			goqu.Ex{"sa.location_id": locationID, "sa.status": models.AdjustmentStatusApplied},
+			goqu.I("sa.applied_at").Lte(at),
+		).
+		GroupBy("sa.item_category_id", "c.label", "sa.origin").
+		Having(goqu.SUM("sa.delta").Neq(0)).
+		Order(goqu.I("c.label").Asc(), goqu.I("sa.origin").Asc()).
+		Executor().
+		ScanStructs(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return stock, nil
+}
@@ -0,0 +1,6 @@
+package snapshots
+
+type SnapshotQuery struct {
+	LocationID int    `uri:"id" binding:"required"`
+	At         string `form:"at" binding:"required"`
+}
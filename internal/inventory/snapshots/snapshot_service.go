@@ -0,0 +1,87 @@
+package snapshots
+
+import (
+	"errors"
+	"sort"
+	"time"
+	"warehouse/pkg/models"
+)
+
+var ErrInvalidSnapshotTime = errors.New("at must be an RFC3339 timestamp or a YYYY-MM-DD date")
+
+type SnapshotService struct {
+	sr *SnapshotRepository
+}
+
+func NewService(sr *SnapshotRepository) *SnapshotService {
+	return &SnapshotService{
+		sr: sr,
+	}
+}
+
+// GetSnapshot reconstructs what was at the location at the given time, history starts with the first recorded change
+func (s *SnapshotService) GetSnapshot(query SnapshotQuery) (*models.InventorySnapshot, error) {
+	at, err := parseSnapshotTime(query.At)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.sr.GetAssetHistoryAt(query.LocationID, at)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.sr.GetStockAt(query.LocationID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.InventorySnapshot{
+		LocationID: query.LocationID,
+		At:         at,
+		Assets:     assetsAt(history, query.LocationID),
+		Stock:      stock,
+	}, nil
+}
+
+// assetsAt takes the last entry of every asset, ties on the change time are settled by the entry id, and keeps
+// assets whose last entry is at the location
+func assetsAt(history []assetHistoryEntry, locationID int) []models.SnapshotAsset {
+	latest := map[int]assetHistoryEntry{}
+	for _, entry := range history {
+		last, ok := latest[entry.AssetID]
+		if !ok || entry.ChangedAt.After(last.ChangedAt) || (entry.ChangedAt.Equal(last.ChangedAt) && entry.ID > last.ID) {
+			latest[entry.AssetID] = entry
+		}
+	}
+
+	assets := []models.SnapshotAsset{}
+	for _, entry := range latest {
+		if entry.LocationID == nil || *entry.LocationID != locationID {
+			continue
+		}
+		assets = append(assets, models.SnapshotAsset{
+			AssetID:       entry.AssetID,
+			PyrCode:       entry.PyrCode,
+			CategoryLabel: entry.CategoryLabel,
+			Status:        entry.Status,
+		})
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].AssetID < assets[j].AssetID
+	})
+
+	return assets
+}
+
+// parseSnapshotTime accepts a timestamp or a date, a date means the start of that day
+// so the state after an event day is asked for with the following date
+func parseSnapshotTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	if at, err := time.Parse("2006-01-02", value); err == nil {
+		return at, nil
+	}
+
+	return time.Time{}, ErrInvalidSnapshotTime
+}
@@ -0,0 +1,71 @@
+package snapshots
+
+import (
+	"testing"
+	"time"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestAssetsAtTakesLastEntryPerAsset(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	history := []assetHistoryEntry{
+		// left the location afterwards
+		{ID: 1, AssetID: 10, LocationID: intPtr(4), Status: strPtr("located"), ChangedAt: day},
+		{ID: 5, AssetID: 10, LocationID: intPtr(1), Status: strPtr("available"), ChangedAt: day.Add(time.Hour)},
+		// came back, entries are not ordered
+		{ID: 9, AssetID: 20, LocationID: intPtr(4), Status: strPtr("located"), ChangedAt: day.Add(2 * time.Hour), PyrCode: strPtr("PYR-LT1")},
+		{ID: 2, AssetID: 20, LocationID: intPtr(1), Status: strPtr("available"), ChangedAt: day.Add(time.Hour)},
+		// same change time, the later entry wins
+		{ID: 7, AssetID: 30, LocationID: intPtr(1), Status: strPtr("available"), ChangedAt: day},
+		{ID: 8, AssetID: 30, LocationID: intPtr(4), Status: strPtr("located"), ChangedAt: day},
+		// deleted while at the location
+		{ID: 3, AssetID: 40, LocationID: intPtr(4), Status: strPtr("located"), ChangedAt: day},
+		{ID: 4, AssetID: 40, ChangedAt: day.Add(time.Hour)},
+	}
+
+	assets := assetsAt(history, 4)
+
+	assert.Equal(t, []models.SnapshotAsset{
+		{AssetID: 20, PyrCode: strPtr("PYR-LT1"), Status: strPtr("located")},
+		{AssetID: 30, Status: strPtr("located")},
+	}, assets)
+}
+
+func TestAssetsAtWithoutHistory(t *testing.T) {
+	assert.Equal(t, []models.SnapshotAsset{}, assetsAt(nil, 4))
+}
+
+func TestParseSnapshotTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr error
+	}{
+		{name: "date means start of day", value: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "utc timestamp", value: "2024-03-01T10:30:00Z", want: time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{name: "timestamp with offset", value: "2024-03-01T10:30:00+02:00", want: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{name: "timestamp without zone", value: "2024-03-01T10:30:00", wantErr: ErrInvalidSnapshotTime},
+		{name: "empty", value: "", wantErr: ErrInvalidSnapshotTime},
+		{name: "other date format", value: "01.03.2024", wantErr: ErrInvalidSnapshotTime},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := parseSnapshotTime(tt.value)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.True(t, tt.want.Equal(at), "got %s", at)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package stocks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *StockHandler) GetAdjustments(c *gin.Context) {
+	var query RetrieveAdjustmentListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	adjustments, err := h.Adjustments.GetAdjustments(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania korekt stanu", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustments)
+}
+
+func (h *StockHandler) AdjustStock(c *gin.Context) {
+	var req AdjustStockRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.RequestedByID = &userID
+	}
+
+	adjustment, err := h.Adjustments.RequestAdjustment(req)
+	if err != nil {
+		h.handleStockError(c, "Nie udało się zarejestrować korekty stanu", err)
+		return
+	}
+
+	status := http.StatusCreated
+	if adjustment.Status == models.AdjustmentStatusPending {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, adjustment)
+}
+
+func (h *StockHandler) ApproveAdjustment(c *gin.Context) {
+	h.reviewAdjustment(c, "Nie udało się zatwierdzić korekty stanu", h.Adjustments.ApproveAdjustment)
+}
+
+func (h *StockHandler) RejectAdjustment(c *gin.Context) {
+	h.reviewAdjustment(c, "Nie udało się odrzucić korekty stanu", h.Adjustments.RejectAdjustment)
+}
+
+func (h *StockHandler) reviewAdjustment(c *gin.Context, msg string, review func(ReviewAdjustmentRequest, int) (*models.StockAdjustment, error)) {
+	var req ReviewAdjustmentRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+			return
+		}
+	}
+
+	userID, err := security.GetUserIDFromContext(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Brak identyfikatora użytkownika"})
+		return
+	}
+
+	adjustment, err := review(req, userID)
+	if err != nil {
+		h.handleStockError(c, msg, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+func (h *StockHandler) GetStockHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	history, err := h.Adjustments.GetStockHistory(id)
+	if err != nil {
+		h.handleStockError(c, "Błąd pobierania historii stanu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *StockHandler) GetLedgerDiscrepancies(c *gin.Context) {
+	discrepancies, err := h.Adjustments.GetLedgerDiscrepancies()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd sprawdzania dziennika stanów", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, discrepancies)
+}
+
+func (h *StockHandler) handleStockError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrStockNotFound), errors.Is(err, ErrAdjustmentNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrAdjustmentDirection), errors.Is(err, ErrSameOrigin), errors.Is(err, ErrSameStock), errors.Is(err, ErrIncompatibleStock):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrSelfApproval):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrInsufficientQuantity), errors.Is(err, ErrStockItemUnavailable), custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
@@ -0,0 +1,257 @@
+package stocks
+
+import (
+	"fmt"
+	"time"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// InsertStockAdjustment writes a ledger entry, an applied entry has to be paired with the quantity change in the same transaction
+func (r *StockRepository) InsertStockAdjustment(tx *goqu.TxDatabase, entry models.StockAdjustment) (int, error) {
+	status := entry.Status
+	if status == "" {
+		status = models.AdjustmentStatusApplied
+	}
+
+	record := goqu.Record{
+		"stock_item_id":             entry.StockItemID,
+		"item_category_id":          entry.CategoryID,
+		"location_id":               entry.LocationID,
+		"origin":                    entry.Origin,
+		"delta":                     entry.Delta,
+		"reason":                    entry.Reason,
+		"status":                    status,
+		"notes":                     entry.Notes,
+		"requested_by_id":           entry.RequestedByID,
+		"counterpart_stock_item_id": entry.CounterpartStockItemID,
+	}
+	if status == models.AdjustmentStatusApplied {
+		record["applied_at"] = goqu.L("NOW()")
+	}
+
+	var id int
+	if _, err := tx.Insert("stock_adjustments").Rows(record).Returning("id").Executor().ScanVal(&id); err != nil {
+		return 0, fmt.Errorf("failed to insert stock adjustment: %w", err)
+	}
+
+	return id, nil
+}
+
+// recordAdjustment books a quantity change made by one of the stock flows, empty changes are skipped
+func (r *StockRepository) recordAdjustment(tx *goqu.TxDatabase, stockID, categoryID, locationID int, origin *string, delta int, reason string) error {
+	if delta == 0 {
+		return nil
+	}
+
+	_, err := r.InsertStockAdjustment(tx, models.StockAdjustment{
+		StockItemID: &stockID,
+		CategoryID:  categoryID,
+		LocationID:  locationID,
+		Origin:      origin,
+		Delta:       delta,
+		Reason:      reason,
+	})
+	return err
+}
+
+// ApplyStockDelta changes the quantity of a stock row, a decrease below zero is refused.
+// Returns the levels a decrease pushed below their minimum.
+func (r *StockRepository) ApplyStockDelta(tx *goqu.TxDatabase, stockID int, delta int) ([]models.StockLevel, error) {
+	var stock struct {
+		CategoryID int `db:"item_category_id"`
+		LocationID int `db:"location_id"`
+	}
+	found, err := tx.Update("non_serialized_items").
+		Set(goqu.Record{"quantity": goqu.L("quantity + ?", delta)}).
+		Where(goqu.Ex{"id": stockID}).
+		Where(goqu.L("quantity + ? >= 0", delta)).
+		Returning("item_category_id", "location_id").
+		Executor().
+		ScanStruct(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to adjust stock %d: %w", stockID, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w for stock %d", ErrInsufficientQuantity, stockID)
+	}
+	if delta > 0 {
+		return nil, nil
+	}
+
+	return r.crossedMinimums(tx, map[int]int{stock.CategoryID: -delta}, stock.LocationID)
+}
+
+func (r *StockRepository) GetStockAdjustment(id int) (*models.StockAdjustment, error) {
+	var adjustment models.StockAdjustment
+	found, err := r.getStockAdjustmentQuery().Where(goqu.Ex{"sa.id": id}).Executor().ScanStruct(&adjustment)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &adjustment, nil
+}
+
+func (r *StockRepository) GetStockAdjustments(query RetrieveAdjustmentListQuery) ([]models.StockAdjustment, error) {
+	conditions := goqu.Ex{}
+	if query.StockItemID != 0 {
+		conditions["sa.stock_item_id"] = query.StockItemID
+	}
+	if query.CategoryID != 0 {
+		conditions["sa.item_category_id"] = query.CategoryID
+	}
+	if query.Status != "" {
+		conditions["sa.status"] = query.Status
+	}
+	if query.Reason != "" {
+		conditions["sa.reason"] = query.Reason
+	}
+
+	adjustments := []models.StockAdjustment{}
+	err := r.getStockAdjustmentQuery().
+		Where(conditions).
+		Order(goqu.I("sa.requested_at").Desc(), goqu.I("sa.id").Desc()).
+		Executor().
+		ScanStructs(&adjustments)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return adjustments, nil
+}
+
+// LockPendingAdjustment locks the entry and ensures nobody has reviewed it in the meantime
+func (r *StockRepository) LockPendingAdjustment(tx *goqu.TxDatabase, id int, target string) (*models.StockAdjustment, error) {
+	var adjustment models.StockAdjustment
+	found, err := tx.Select("id", "stock_item_id", "delta", "status", "requested_by_id").
+		From("stock_adjustments").
+		Where(goqu.Ex{"id": id}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&adjustment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock adjustment: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	if adjustment.Status != models.AdjustmentStatusPending {
+		return nil, custom_error.NewInvalidStatusTransitionError("stock_adjustment", id, adjustment.Status, target)
+	}
+
+	return &adjustment, nil
+}
+
+func (r *StockRepository) ReviewStockAdjustment(tx *goqu.TxDatabase, id int, status string, reviewerID int, notes *string) error {
+	now := time.Now()
+	record := goqu.Record{
+		"status":         status,
+		"reviewed_by_id": reviewerID,
+		"reviewed_at":    now,
+		"review_notes":   notes,
+	}
+	if status == models.AdjustmentStatusApplied {
+		record["applied_at"] = now
+	}
+
+	_, err := tx.Update("stock_adjustments").
+		Set(record).
+		Where(goqu.Ex{"id": id}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to review stock adjustment: %w", err)
+	}
+
+	return nil
+}
+
+// GetStockHistory lists applied ledger entries of a stock row with the quantity right after each of them
+func (r *StockRepository) GetStockHistory(stockID int) ([]models.StockHistoryEntry, error) {
+	history := []models.StockHistoryEntry{}
+	err := r.repository.GoquDBWrapper.
+		Select(
+			goqu.I("sa.id").As("adjustment_id"),
+			"sa.location_id",
+			goqu.I("l.name").As("location_name"),
+			"sa.origin",
+			"sa.delta",
+			"sa.reason",
+			goqu.L("SUM(sa.delta) OVER (ORDER BY sa.applied_at, sa.id)").As("quantity_after"),
+			"sa.requested_by_id",
+			"sa.applied_at",
+		).
+		From(goqu.T("stock_adjustments").As("sa")).
+		Join(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"sa.location_id": goqu.I("l.id")})).
+		Where(goqu.Ex{"sa.stock_item_id": stockID, "sa.status": models.AdjustmentStatusApplied}).
+		Order(goqu.I("sa.applied_at").Asc(), goqu.I("sa.id").Asc()).
+		Executor().
+		ScanStructs(&history)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return history, nil
+}
+
+// GetLedgerDiscrepancies lists stock rows whose quantity cannot be derived from their applied ledger entries
+func (r *StockRepository) GetLedgerDiscrepancies() ([]models.StockLedgerDiscrepancy, error) {
+	ledgerQuantity := goqu.L(
+		"COALESCE((SELECT SUM(sa.delta) FROM stock_adjustments sa WHERE sa.stock_item_id = s.id AND sa.status = ?), 0)",
+		models.AdjustmentStatusApplied,
+	)
+
+	discrepancies := []models.StockLedgerDiscrepancy{}
+	err := r.repository.GoquDBWrapper.
+		Select(
+			goqu.I("s.id").As("stock_item_id"),
+			goqu.I("s.item_category_id"),
+			goqu.I("c.label").As("category_label"),
+			goqu.I("s.location_id"),
+			goqu.I("s.quantity"),
+			ledgerQuantity.As("ledger_quantity"),
+		).
+		From(goqu.T("non_serialized_items").As("s")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"s.item_category_id": goqu.I("c.id")})).
+		Where(goqu.I("s.quantity").Neq(ledgerQuantity)).
+		Order(goqu.I("s.id").Asc()).
+		Executor().
+		ScanStructs(&discrepancies)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return discrepancies, nil
+}
+
+func (r *StockRepository) getStockAdjustmentQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"sa.id",
+		"sa.stock_item_id",
+		"sa.item_category_id",
+		goqu.I("c.label").As("category_label"),
+		"sa.location_id",
+		goqu.I("l.name").As("location_name"),
+		"sa.origin",
+		"sa.delta",
+		"sa.reason",
+		"sa.status",
+		"sa.notes",
+		"sa.requested_by_id",
+		"sa.requested_at",
+		"sa.reviewed_by_id",
+		"sa.reviewed_at",
+		"sa.review_notes",
+		"sa.applied_at",
+		"sa.counterpart_stock_item_id",
+	).
+		From(goqu.T("stock_adjustments").As("sa")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"sa.item_category_id": goqu.I("c.id")})).
+		Join(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"sa.location_id": goqu.I("l.id")}))
+}
@@ -0,0 +1,239 @@
+package stocks
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrStockNotFound        = errors.New("stock item not found")
+	ErrAdjustmentNotFound   = errors.New("stock adjustment not found")
+	ErrAdjustmentDirection  = errors.New("damaged and lost stock can only decrease, found stock can only increase")
+	ErrSelfApproval         = errors.New("adjustment has to be approved by another moderator")
+	ErrStockItemUnavailable = errors.New("stock row of the adjustment no longer exists")
+)
+
+type StockAdjustmentService struct {
+	r             *repository.Repository
+	stockRepo     *StockRepository
+	a             *auditlog.Auditlog
+	approvalLimit int
+}
+
+func NewAdjustmentService(r *repository.Repository, sr *StockRepository, a *auditlog.Auditlog) *StockAdjustmentService {
+	return &StockAdjustmentService{
+		r:             r,
+		stockRepo:     sr,
+		a:             a,
+		approvalLimit: adjustmentApprovalLimit(),
+	}
+}
+
+// RequestAdjustment books a manual change, changes above the approval limit wait for a moderator
+func (s *StockAdjustmentService) RequestAdjustment(req AdjustStockRequest) (*models.StockAdjustment, error) {
+	stock, err := s.stockRepo.GetStockItem(req.StockID)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil || stock.ID == 0 {
+		return nil, ErrStockNotFound
+	}
+
+	return s.requestAdjustment(stock, req)
+}
+
+// AdjustToQuantity turns a requested absolute quantity into a ledger entry, nil means there is nothing to change
+func (s *StockAdjustmentService) AdjustToQuantity(req AdjustStockRequest, quantity int) (*models.StockAdjustment, error) {
+	stock, err := s.stockRepo.GetStockItem(req.StockID)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil || stock.ID == 0 {
+		return nil, ErrStockNotFound
+	}
+
+	req.Delta = quantity - stock.Quantity
+	if req.Delta == 0 {
+		return nil, nil
+	}
+
+	return s.requestAdjustment(stock, req)
+}
+
+func (s *StockAdjustmentService) requestAdjustment(stock *models.StockItem, req AdjustStockRequest) (*models.StockAdjustment, error) {
+	if !models.AdjustmentReasonAllows(req.Reason, req.Delta) {
+		return nil, ErrAdjustmentDirection
+	}
+	if stock.Quantity+req.Delta < 0 {
+		return nil, fmt.Errorf("%w for stock %d", ErrInsufficientQuantity, stock.ID)
+	}
+
+	entry := models.StockAdjustment{
+		StockItemID:   &stock.ID,
+		CategoryID:    stock.Category.ID,
+		LocationID:    stock.Location.ID,
+		Origin:        &stock.Origin,
+		Delta:         req.Delta,
+		Reason:        req.Reason,
+		Status:        models.AdjustmentStatusApplied,
+		Notes:         req.Notes,
+		RequestedByID: req.RequestedByID,
+	}
+	if models.RequiresApproval(req.Delta, s.approvalLimit) {
+		entry.Status = models.AdjustmentStatusPending
+	}
+
+	var adjustmentID int
+	var lowStock []models.StockLevel
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if entry.Status == models.AdjustmentStatusApplied {
+			if lowStock, err = s.stockRepo.ApplyStockDelta(tx, stock.ID, req.Delta); err != nil {
+				return err
+			}
+		}
+		if adjustmentID, err = s.stockRepo.InsertStockAdjustment(tx, entry); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment, err := s.stockRepo.GetStockAdjustment(adjustmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := "Korekta stanu materiałów"
+	if adjustment.Status == models.AdjustmentStatusPending {
+		msg = "Korekta stanu materiałów oczekuje na zatwierdzenie"
+	}
+
+	go s.stockRepo.NotifyLowStock(lowStock)
+	go s.a.Log(
+		"adjust",
+		map[string]interface{}{
+			"stock_item_id": stock.ID,
+			"delta":         req.Delta,
+			"reason":        req.Reason,
+			"status":        adjustment.Status,
+			"msg":           msg,
+		},
+		adjustment,
+	)
+
+	return adjustment, nil
+}
+
+// ApproveAdjustment applies a pending change, the requester cannot approve their own adjustment
+func (s *StockAdjustmentService) ApproveAdjustment(req ReviewAdjustmentRequest, reviewerID int) (*models.StockAdjustment, error) {
+	var lowStock []models.StockLevel
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		adjustment, err := s.stockRepo.LockPendingAdjustment(tx, req.ID, models.AdjustmentStatusApplied)
+		if err != nil {
+			return err
+		}
+		if adjustment == nil {
+			return ErrAdjustmentNotFound
+		}
+		if adjustment.RequestedByID != nil && *adjustment.RequestedByID == reviewerID {
+			return ErrSelfApproval
+		}
+		if adjustment.StockItemID == nil {
+			return ErrStockItemUnavailable
+		}
+
+		if lowStock, err = s.stockRepo.ApplyStockDelta(tx, *adjustment.StockItemID, adjustment.Delta); err != nil {
+			return err
+		}
+		return s.stockRepo.ReviewStockAdjustment(tx, req.ID, models.AdjustmentStatusApplied, reviewerID, req.Notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.logReview(req.ID, lowStock, "approve", "Korekta stanu materiałów zatwierdzona")
+}
+
+func (s *StockAdjustmentService) RejectAdjustment(req ReviewAdjustmentRequest, reviewerID int) (*models.StockAdjustment, error) {
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		adjustment, err := s.stockRepo.LockPendingAdjustment(tx, req.ID, models.AdjustmentStatusRejected)
+		if err != nil {
+			return err
+		}
+		if adjustment == nil {
+			return ErrAdjustmentNotFound
+		}
+
+		return s.stockRepo.ReviewStockAdjustment(tx, req.ID, models.AdjustmentStatusRejected, reviewerID, req.Notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.logReview(req.ID, nil, "reject", "Korekta stanu materiałów odrzucona")
+}
+
+func (s *StockAdjustmentService) logReview(id int, lowStock []models.StockLevel, action, msg string) (*models.StockAdjustment, error) {
+	adjustment, err := s.stockRepo.GetStockAdjustment(id)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.stockRepo.NotifyLowStock(lowStock)
+	go s.a.Log(
+		action,
+		map[string]interface{}{
+			"stock_item_id": adjustment.StockItemID,
+			"delta":         adjustment.Delta,
+			"msg":           msg,
+		},
+		adjustment,
+	)
+
+	return adjustment, nil
+}
+
+func (s *StockAdjustmentService) GetAdjustments(query RetrieveAdjustmentListQuery) ([]models.StockAdjustment, error) {
+	return s.stockRepo.GetStockAdjustments(query)
+}
+
+func (s *StockAdjustmentService) GetStockHistory(stockID int) ([]models.StockHistoryEntry, error) {
+	stock, err := s.stockRepo.GetStockItem(stockID)
+	if err != nil {
+		return nil, err
+	}
+	if stock == nil || stock.ID == 0 {
+		return nil, ErrStockNotFound
+	}
+
+	return s.stockRepo.GetStockHistory(stockID)
+}
+
+func (s *StockAdjustmentService) GetLedgerDiscrepancies() ([]models.StockLedgerDiscrepancy, error) {
+	return s.stockRepo.GetLedgerDiscrepancies()
+}
+
+// adjustmentApprovalLimit reads STOCK_ADJUSTMENT_APPROVAL_LIMIT, without it no adjustment needs approval
+func adjustmentApprovalLimit() int {
+	if value := os.Getenv("STOCK_ADJUSTMENT_APPROVAL_LIMIT"); value != "" {
+		if limit, err := strconv.Atoi(value); err == nil && limit >= 0 {
+			return limit
+		}
+		log.Printf("Nieprawidłowa wartość STOCK_ADJUSTMENT_APPROVAL_LIMIT: %s", value)
+	}
+
+	return 0
+}
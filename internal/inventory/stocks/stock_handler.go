@@ -1,12 +1,13 @@
 package stocks
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"warehouse/internal/repository"
 	"warehouse/pkg/auditlog"
 	custom_error "warehouse/pkg/errors"
-	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
 	"warehouse/pkg/security"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,8 @@ type StockHandler struct {
 	Repository      *repository.Repository
 	StockRepository *StockRepository
 	AuditLog        *auditlog.Auditlog
+	Adjustments     *StockAdjustmentService
+	Splits          *StockSplitService
 }
 
 func NewStockHandler(r *repository.Repository, sr *StockRepository, a *auditlog.Auditlog) *StockHandler {
@@ -24,6 +27,8 @@ func NewStockHandler(r *repository.Repository, sr *StockRepository, a *auditlog.
 		Repository:      r,
 		StockRepository: sr,
 		AuditLog:        a,
+		Adjustments:     NewAdjustmentService(r, sr, a),
+		Splits:          NewSplitService(r, sr, a),
 	}
 }
 
@@ -32,6 +37,14 @@ func (h *StockHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.PATCH("/stocks/:id", security.Authorize("moderator"), h.UpdateStock)
 	router.GET("/stocks", security.Authorize("user"), h.GetStocks)
 	router.DELETE("/stocks/:id", security.Authorize("admin"), h.DeleteStock)
+	router.GET("/stocks/adjustments", security.Authorize("user"), h.GetAdjustments)
+	router.POST("/stocks/:id/adjustments", security.Authorize("user"), h.AdjustStock)
+	router.GET("/stocks/:id/history", security.Authorize("user"), h.GetStockHistory)
+	router.POST("/stocks/:id/split", security.Authorize("moderator"), h.SplitStock)
+	router.POST("/stocks/:id/merge", security.Authorize("moderator"), h.MergeStock)
+	router.PATCH("/stocks/adjustments/:id/approve", security.Authorize("moderator"), h.ApproveAdjustment)
+	router.PATCH("/stocks/adjustments/:id/reject", security.Authorize("moderator"), h.RejectAdjustment)
+	router.GET("/stocks/ledger/discrepancies", security.Authorize("moderator"), h.GetLedgerDiscrepancies)
 }
 
 func (h *StockHandler) CreateStock(c *gin.Context) {
@@ -44,15 +57,15 @@ func (h *StockHandler) CreateStock(c *gin.Context) {
 	if stockRequest.LocationID == 0 {
 		stockRequest.LocationID = 1 // setting up default location if other is not provided
 	}
-	origin, err := metadata.NewOrigin(stockRequest.Origin)
+	origin, err := h.Repository.ResolveOrigin(stockRequest.Origin)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid asset origin",
-			"details": err.Error(),
-		})
+		abortWithOriginError(c, err)
 		return
 	}
-	stockRequest.Origin = origin.String()
+	stockRequest.Origin = origin.Slug
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		stockRequest.CreatedByID = &userID
+	}
 
 	stockItem, err := h.StockRepository.PersistStockItem(stockRequest)
 
@@ -93,22 +106,50 @@ func (h *StockHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
+	if stockRequest.Quantity != nil && stockRequest.Reason == nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Reason is required to change stock quantity"})
+		return
+	}
+
 	if stockRequest.Origin != nil {
-		origin, err := metadata.NewOrigin(*stockRequest.Origin)
+		origin, err := h.Repository.ResolveOrigin(*stockRequest.Origin)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
-				"error":   "Invalid asset origin",
-				"details": err.Error(),
-			})
+			abortWithOriginError(c, err)
 			return
 		}
-		//TODO kinda shady
-		originString := origin.String()
-		stockRequest.Origin = &originString
+		stockRequest.Origin = &origin.Slug
 	}
 
-	stock, err := h.StockRepository.UpdateStock(&stockRequest)
+	if stockRequest.LocationID != nil || stockRequest.Origin != nil {
+		if _, err := h.StockRepository.UpdateStock(&stockRequest); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Unable to update stock", "details": err.Error()})
+			return
+		}
+	}
+
+	// Quantity is never overwritten, the difference is booked in the adjustment ledger
+	if stockRequest.Quantity != nil {
+		adjustmentRequest := AdjustStockRequest{
+			StockID: stockRequest.ID,
+			Reason:  *stockRequest.Reason,
+			Notes:   stockRequest.Notes,
+		}
+		if userID, err := security.GetUserIDFromContext(c); err == nil {
+			adjustmentRequest.RequestedByID = &userID
+		}
+
+		adjustment, err := h.Adjustments.AdjustToQuantity(adjustmentRequest, *stockRequest.Quantity)
+		if err != nil {
+			h.handleStockError(c, "Unable to update stock quantity", err)
+			return
+		}
+		if adjustment != nil && adjustment.Status == models.AdjustmentStatusPending {
+			c.JSON(http.StatusAccepted, adjustment)
+			return
+		}
+	}
 
+	stock, err := h.StockRepository.GetStockItem(stockRequest.ID)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Unable to update stock", "details": err.Error()})
 		return
@@ -166,3 +207,12 @@ func (h *StockHandler) DeleteStock(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Stock deleted successfully"})
 }
+
+func abortWithOriginError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrUnknownOrigin) || errors.Is(err, repository.ErrInactiveOrigin) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid stock origin", "details": err.Error()})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Unable to check stock origin", "details": err.Error()})
+}
@@ -1,33 +1,31 @@
 package stocks
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"warehouse/internal/repository"
 	custom_error "warehouse/pkg/errors"
 	"warehouse/pkg/models"
+	"warehouse/pkg/notify"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/lib/pq"
 )
 
+var ErrInsufficientQuantity = errors.New("insufficient quantity")
+
 type StockRepository struct {
 	repository *repository.Repository
+	notifier   notify.Notifier
 }
 
-func NewRepository(r *repository.Repository) *StockRepository {
-	return &StockRepository{repository: r}
+func NewRepository(r *repository.Repository, n notify.Notifier) *StockRepository {
+	return &StockRepository{repository: r, notifier: n}
 }
 
 func (r *StockRepository) PersistStockItem(stockRequest StockItemRequest) (*models.StockItem, error) {
-	query := r.repository.GoquDBWrapper.Insert("non_serialized_items").
-		Rows(goqu.Record{
-			"quantity":         stockRequest.Quantity,
-			"location_id":      stockRequest.LocationID,
-			"item_category_id": stockRequest.CategoryID,
-			"origin":           stockRequest.Origin,
-		}).
-		Returning("id")
 	stockItem := models.StockItem{
 		Quantity: stockRequest.Quantity,
 		Category: models.ItemCategory{
@@ -39,11 +37,39 @@ func (r *StockRepository) PersistStockItem(stockRequest StockItemRequest) (*mode
 		Origin: stockRequest.Origin,
 	}
 
-	if _, err := query.Executor().ScanVal(&stockItem.ID); err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			return nil, custom_error.WrapDBError("Duplicate serial number for asset", string(pqErr.Code))
+	err := repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		query := tx.Insert("non_serialized_items").
+			Rows(goqu.Record{
+				"quantity":         stockRequest.Quantity,
+				"location_id":      stockRequest.LocationID,
+				"item_category_id": stockRequest.CategoryID,
+				"origin":           stockRequest.Origin,
+			}).
+			Returning("id")
+
+		if _, err := query.Executor().ScanVal(&stockItem.ID); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				return custom_error.WrapDBError("Duplicate serial number for asset", string(pqErr.Code))
+			}
+			return fmt.Errorf("failed to insert stock item record: %w", err)
 		}
-		return nil, fmt.Errorf("failed to insert stock item record: %w", err)
+
+		if stockItem.Quantity == 0 {
+			return nil
+		}
+		_, err := r.InsertStockAdjustment(tx, models.StockAdjustment{
+			StockItemID:   &stockItem.ID,
+			CategoryID:    stockRequest.CategoryID,
+			LocationID:    stockRequest.LocationID,
+			Origin:        &stockRequest.Origin,
+			Delta:         stockRequest.Quantity,
+			Reason:        models.AdjustmentReasonCreated,
+			RequestedByID: stockRequest.CreatedByID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &stockItem, nil
@@ -142,28 +168,33 @@ func (r *StockRepository) HasRelatedItems(categoryID string) bool {
 	return count > 0
 }
 
+// UpdateStock changes location and origin of a stock row, quantity changes go through the adjustment ledger
 func (r *StockRepository) UpdateStock(stockRequest *PatchStockItemRequest) (*models.StockItem, error) {
 	updates, err := buildUpdateFields(stockRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	query := r.repository.GoquDBWrapper.
-		Update("non_serialized_items").
-		Set(updates).
-		Where(goqu.Ex{"id": stockRequest.ID}) // Assuming `ID` is provided to identify the row
+	err = repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		stock, err := r.lockStock(tx, stockRequest.ID)
+		if err != nil {
+			return err
+		}
+		if stock == nil {
+			return fmt.Errorf("no rows updated")
+		}
 
-	result, err := query.Executor().Exec()
-	if err != nil {
-		return nil, fmt.Errorf("failed to update stock item: %w", err)
-	}
+		query := tx.Update("non_serialized_items").
+			Set(updates).
+			Where(goqu.Ex{"id": stockRequest.ID})
+		if _, err := query.Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to update stock item: %w", err)
+		}
 
-	rowsAffected, err := result.RowsAffected()
+		return r.recordRelocation(tx, stockRequest, stock)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve rows affected: %w", err)
-	}
-	if rowsAffected == 0 {
-		return nil, fmt.Errorf("no rows updated")
+		return nil, err
 	}
 
 	updatedStock, err := r.GetStockItem(stockRequest.ID)
@@ -231,7 +262,10 @@ func (r *StockRepository) GetStockItemsByTransfer(transferID int) (*[]models.Sto
 	return &stocks, nil
 }
 
-func (r *StockRepository) DecreaseStockItemsQuantity(tx *goqu.TxDatabase, stocks []models.StockItemRequest, fromLocationID int) error {
+// DecreaseStockItemsQuantity takes stock out of a location and returns the levels this pushed below their minimum,
+// callers pass them to NotifyLowStock once the transaction is committed. Reason is booked in the adjustment ledger.
+func (r *StockRepository) DecreaseStockItemsQuantity(tx *goqu.TxDatabase, stocks []models.StockItemRequest, fromLocationID int, reason string) ([]models.StockLevel, error) {
+	decreasedByCategory := map[int]int{}
 	for _, stockItem := range stocks {
 		// Step 1: Decrease the quantity
 		updateQuery := tx.Update("non_serialized_items").
@@ -242,25 +276,30 @@ func (r *StockRepository) DecreaseStockItemsQuantity(tx *goqu.TxDatabase, stocks
 				"id":          stockItem.ID,
 				"location_id": fromLocationID,
 			}).
-			Where(goqu.C("quantity").Gte(stockItem.Quantity)) // Ensure sufficient quantity
+			Where(goqu.C("quantity").Gte(stockItem.Quantity)). // Ensure sufficient quantity
+			Returning("item_category_id", "origin")
 
-		result, err := updateQuery.Executor().Exec()
+		var decreased struct {
+			CategoryID int     `db:"item_category_id"`
+			Origin     *string `db:"origin"`
+		}
+		found, err := updateQuery.Executor().ScanStruct(&decreased)
 		if err != nil {
-			return fmt.Errorf("failed to decrease quantity for category %d from location %d: %w", stockItem.ID, fromLocationID, err)
+			return nil, fmt.Errorf("failed to decrease quantity for category %d from location %d: %w", stockItem.ID, fromLocationID, err)
 		}
 
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			return fmt.Errorf("failed to check rows affected for category %d: %w", stockItem.ID, err)
+		if !found {
+			return nil, fmt.Errorf("%w for category %d at location %d", ErrInsufficientQuantity, stockItem.ID, fromLocationID)
 		}
+		decreasedByCategory[decreased.CategoryID] += stockItem.Quantity
 
-		if rowsAffected == 0 {
-			return fmt.Errorf("insufficient quantity for category %d at location %d", stockItem.ID, fromLocationID)
+		if err := r.recordAdjustment(tx, stockItem.ID, decreased.CategoryID, fromLocationID, decreased.Origin, -stockItem.Quantity, reason); err != nil {
+			return nil, err
 		}
 
-		if fromLocationID == 1 {
-			log.Println("cannot decrease quantity form main warehouse")
-			return nil
+		// Empty rows are kept in the main warehouse
+		if fromLocationID == models.DefaultEquipmentLocationID {
+			continue
 		}
 		deleteQuery := tx.Delete("non_serialized_items").
 			Where(goqu.Ex{
@@ -270,33 +309,113 @@ func (r *StockRepository) DecreaseStockItemsQuantity(tx *goqu.TxDatabase, stocks
 			Where(goqu.C("quantity").Eq(0)) // Only delete records where quantity is now zero
 
 		if _, err := deleteQuery.Executor().Exec(); err != nil {
-			return fmt.Errorf("failed to remove stock item with zero quantity: %w", err)
+			return nil, fmt.Errorf("failed to remove stock item with zero quantity: %w", err)
 		}
 	}
 
-	return nil
+	return r.crossedMinimums(tx, decreasedByCategory, fromLocationID)
+}
+
+// crossedMinimums checks thresholds of decreased categories, both global ones and the ones of the location
+func (r *StockRepository) crossedMinimums(tx *goqu.TxDatabase, decreasedByCategory map[int]int, locationID int) ([]models.StockLevel, error) {
+	var crossed []models.StockLevel
+	for categoryID, decreased := range decreasedByCategory {
+		levels, err := r.getStockLevels(tx, goqu.Ex{"t.item_category_id": categoryID}, goqu.Or(
+			goqu.I("t.location_id").IsNull(),
+			goqu.I("t.location_id").Eq(locationID),
+		))
+		if err != nil {
+			return nil, err
+		}
+		for _, level := range levels {
+			if level.CrossedMinimum(decreased) {
+				crossed = append(crossed, level)
+			}
+		}
+	}
+
+	return crossed, nil
+}
+
+// GetLowStockLevels lists every threshold the current quantity is below
+func (r *StockRepository) GetLowStockLevels() ([]models.StockLevel, error) {
+	levels, err := r.getStockLevels(r.repository.GoquDBWrapper, goqu.L("1 = 1"))
+	if err != nil {
+		return nil, err
+	}
+
+	low := []models.StockLevel{}
+	for _, level := range levels {
+		if level.IsBelowMinimum() {
+			low = append(low, level)
+		}
+	}
+
+	return low, nil
+}
+
+// NotifyLowStock sends an alert for every level, failures are only logged since stock has already moved
+func (r *StockRepository) NotifyLowStock(levels []models.StockLevel) {
+	if r.notifier == nil {
+		return
+	}
+
+	for _, level := range levels {
+		place := "we wszystkich lokalizacjach"
+		if level.LocationName != nil {
+			place = "w lokalizacji " + *level.LocationName
+		}
+
+		err := r.notifier.Notify(notify.Notification{
+			Kind:    "low_stock",
+			Title:   fmt.Sprintf("Niski stan: %s", level.CategoryLabel),
+			Message: fmt.Sprintf("Stan %s %s spadł do %d, minimum to %d", level.CategoryLabel, place, level.Quantity, level.MinQuantity),
+			Data: map[string]interface{}{
+				"threshold_id": level.ThresholdID,
+				"category_id":  level.CategoryID,
+				"location_id":  level.LocationID,
+				"quantity":     level.Quantity,
+				"min_quantity": level.MinQuantity,
+			},
+		})
+		if err != nil {
+			log.Printf("Nie udało się wysłać powiadomienia o niskim stanie kategorii %d: %v", level.CategoryID, err)
+		}
+	}
 }
 
-func (r *StockRepository) IncreaseStockAtDestination(tx *goqu.TxDatabase, transferID int) error {
-	query := `
-		INSERT INTO non_serialized_items (item_category_id, location_id, quantity, origin)
-		SELECT 
-			nst.item_category_id, 
-			t.to_location_id, 
-			nst.quantity, 
-			nst.origin
-		FROM non_serialized_transfers nst
-		INNER JOIN transfers t ON nst.transfer_id = t.id
-		WHERE t.id = $1
-		ON CONFLICT (item_category_id, location_id, origin)
-		DO UPDATE SET quantity = non_serialized_items.quantity + EXCLUDED.quantity;
-	`
-	_, err := tx.Exec(query, transferID)
+type selectSource interface {
+	From(from ...interface{}) *goqu.SelectDataset
+}
+
+// getStockLevels sums quantities for each threshold, a threshold without location sums all locations
+func (r *StockRepository) getStockLevels(db selectSource, conditions ...exp.Expression) ([]models.StockLevel, error) {
+	quantity := goqu.L(
+		"COALESCE((SELECT SUM(s.quantity) FROM non_serialized_items s WHERE s.item_category_id = t.item_category_id AND (t.location_id IS NULL OR s.location_id = t.location_id)), 0)",
+	)
+
+	levels := []models.StockLevel{}
+	err := db.From(goqu.T("stock_thresholds").As("t")).
+		Select(
+			goqu.I("t.id").As("threshold_id"),
+			goqu.I("t.item_category_id"),
+			goqu.I("c.label").As("category_label"),
+			goqu.I("t.location_id"),
+			goqu.I("l.name").As("location_name"),
+			goqu.I("t.min_quantity"),
+			quantity.As("quantity"),
+		).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"t.item_category_id": goqu.I("c.id")})).
+		LeftJoin(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"t.location_id": goqu.I("l.id")})).
+		Where(conditions...).
+		Order(goqu.I("c.label").Asc(), goqu.I("l.name").Asc().NullsFirst()).
+		Executor().
+		ScanStructs(&levels)
 	if err != nil {
-		return fmt.Errorf("failed to increase stock at destination: %w", err)
+		return nil, fmt.Errorf("failed to query stock levels: %w", err)
 	}
 
-	return nil
+	return levels, nil
 }
 
 func (r *StockRepository) RemoveZeroQuantityStock(tx *goqu.TxDatabase, transferReq RemoveStockItemFromTransferRequest) error {
@@ -314,15 +433,15 @@ func (r *StockRepository) RemoveZeroQuantityStock(tx *goqu.TxDatabase, transferR
 	return nil
 }
 
+// RestoreStockToLocation puts stock of a transfer line back to the row of its origin, creating the row when
+// the transfer emptied and removed it
 func (r *StockRepository) RestoreStockToLocation(tx *goqu.TxDatabase, transferReq RemoveStockItemFromTransferRequest) error {
-	_, err := tx.Update("non_serialized_items").
-		Set(goqu.Record{"quantity": goqu.L("quantity + ?", transferReq.Quantity)}).
-		Where(goqu.Ex{
-			"item_category_id": transferReq.CategoryID,
-			"location_id":      transferReq.ToLocationID,
-		}).
-		Executor().
-		Exec()
+	_, err := r.AddStockQuantity(tx, StockItemRequest{
+		CategoryID: transferReq.CategoryID,
+		LocationID: transferReq.ToLocationID,
+		Quantity:   transferReq.Quantity,
+		Origin:     transferReq.Origin,
+	}, models.AdjustmentReasonTransfer)
 	if err != nil {
 		return fmt.Errorf("failed to restore stock to given location: %w", err)
 	}
@@ -330,15 +449,29 @@ func (r *StockRepository) RestoreStockToLocation(tx *goqu.TxDatabase, transferRe
 	return nil
 }
 
+// DeleteStock removes a stock row, its remaining quantity is booked out of the ledger first
 func (r *StockRepository) DeleteStock(id int) error {
-	_, err := r.repository.GoquDBWrapper.Delete("non_serialized_items").
-		Where(goqu.Ex{"id": id}).
-		Executor().Exec()
-	if err != nil {
-		return fmt.Errorf("failed to delete stock: %w", err)
-	}
+	return repository.WithTransaction(r.repository.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		stock, err := r.lockStock(tx, id)
+		if err != nil {
+			return err
+		}
+		if stock != nil {
+			err := r.recordAdjustment(tx, id, stock.CategoryID, stock.LocationID, stock.Origin, -stock.Quantity, models.AdjustmentReasonRemoved)
+			if err != nil {
+				return err
+			}
+		}
 
-	return nil
+		_, err = tx.Delete("non_serialized_items").
+			Where(goqu.Ex{"id": id}).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to delete stock: %w", err)
+		}
+
+		return nil
+	})
 }
 
 func (r *StockRepository) getStockItemQuery() *goqu.SelectDataset {
@@ -385,12 +518,33 @@ func transformToStockItem(flatStock models.FlatStockRecord) models.StockItem {
 	}
 }
 
+// recordRelocation books the quantity out of the previous location or origin and into the new one,
+// so the ledger can still tell what was where
+func (r *StockRepository) recordRelocation(tx *goqu.TxDatabase, stockRequest *PatchStockItemRequest, previous *lockedStock) error {
+	locationID := previous.LocationID
+	if stockRequest.LocationID != nil {
+		locationID = *stockRequest.LocationID
+	}
+	origin := previous.Origin
+	if stockRequest.Origin != nil {
+		origin = stockRequest.Origin
+	}
+
+	sameOrigin := (origin == nil && previous.Origin == nil) || (origin != nil && previous.Origin != nil && *origin == *previous.Origin)
+	if locationID == previous.LocationID && sameOrigin {
+		return nil
+	}
+
+	err := r.recordAdjustment(tx, stockRequest.ID, previous.CategoryID, previous.LocationID, previous.Origin, -previous.Quantity, models.AdjustmentReasonRelocated)
+	if err != nil {
+		return err
+	}
+	return r.recordAdjustment(tx, stockRequest.ID, previous.CategoryID, locationID, origin, previous.Quantity, models.AdjustmentReasonRelocated)
+}
+
 func buildUpdateFields(stockRequest *PatchStockItemRequest) (goqu.Record, error) {
 	updates := goqu.Record{}
 
-	if stockRequest.Quantity != nil {
-		updates["quantity"] = *stockRequest.Quantity
-	}
 	if stockRequest.Origin != nil {
 		updates["origin"] = *stockRequest.Origin
 	}
@@ -404,3 +558,125 @@ func buildUpdateFields(stockRequest *PatchStockItemRequest) (goqu.Record, error)
 
 	return updates, nil
 }
+
+// SetStockQuantity overwrites the quantity of a stock row, books the difference with given reason and returns the previous value
+func (r *StockRepository) SetStockQuantity(tx *goqu.TxDatabase, stockID int, quantity int, reason string) (int, error) {
+	stock, err := r.lockStock(tx, stockID)
+	if err != nil {
+		return 0, err
+	}
+	if stock == nil {
+		return 0, fmt.Errorf("no stock found with id: %d", stockID)
+	}
+
+	_, err = tx.Update("non_serialized_items").
+		Set(goqu.Record{"quantity": quantity}).
+		Where(goqu.Ex{"id": stockID}).
+		Executor().
+		Exec()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update stock quantity: %w", err)
+	}
+
+	if err := r.recordAdjustment(tx, stockID, stock.CategoryID, stock.LocationID, stock.Origin, quantity-stock.Quantity, reason); err != nil {
+		return 0, err
+	}
+
+	return stock.Quantity, nil
+}
+
+type lockedStock struct {
+	ID         int     `db:"id"`
+	CategoryID int     `db:"item_category_id"`
+	LocationID int     `db:"location_id"`
+	Origin     *string `db:"origin"`
+	Quantity   int     `db:"quantity"`
+}
+
+func (r *StockRepository) lockStock(tx *goqu.TxDatabase, stockID int) (*lockedStock, error) {
+	var stock lockedStock
+	found, err := tx.Select("id", "item_category_id", "location_id", "origin", "quantity").
+		From("non_serialized_items").
+		Where(goqu.Ex{"id": stockID}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stock, nil
+}
+
+// AddStockQuantity increases the stock of a category at a location, creating the row when missing,
+// the increase is booked in the ledger with given reason
+func (r *StockRepository) AddStockQuantity(tx *goqu.TxDatabase, stockRequest StockItemRequest, reason string) (int, error) {
+	stockID, err := r.upsertStockQuantity(tx, stockRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.recordAdjustment(tx, stockID, stockRequest.CategoryID, stockRequest.LocationID, &stockRequest.Origin, stockRequest.Quantity, reason)
+	if err != nil {
+		return 0, err
+	}
+
+	return stockID, nil
+}
+
+// upsertStockQuantity adds to the row of category, location and origin without booking it, a zero quantity only ensures the row exists
+func (r *StockRepository) upsertStockQuantity(tx *goqu.TxDatabase, stockRequest StockItemRequest) (int, error) {
+	var stockID int
+
+	found, err := upsertStockQuery(tx.Insert("non_serialized_items"), stockRequest).Executor().ScanVal(&stockID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add stock quantity: %w", err)
+	}
+	if !found {
+		return 0, fmt.Errorf("failed to add stock quantity: no row of category %d at location %d", stockRequest.CategoryID, stockRequest.LocationID)
+	}
+
+	return stockID, nil
+}
+
+func upsertStockQuery(insert *goqu.InsertDataset, stockRequest StockItemRequest) *goqu.InsertDataset {
+	return insert.
+		Rows(goqu.Record{
+			"quantity":         stockRequest.Quantity,
+			"location_id":      stockRequest.LocationID,
+			"item_category_id": stockRequest.CategoryID,
+			"origin":           stockRequest.Origin,
+		}).
+		OnConflict(goqu.DoUpdate("item_category_id, location_id, origin", goqu.Record{
+			"quantity": goqu.L("non_serialized_items.quantity + EXCLUDED.quantity"),
+		})).
+		Returning("id")
+}
+
+// LockLargestStock locks the stock row of a category with the highest quantity at given location
+func (r *StockRepository) LockLargestStock(tx *goqu.TxDatabase, categoryID int, locationID int) (*models.StockItemFlat, error) {
+	var stock models.StockItemFlat
+	found, err := tx.Select(
+		goqu.I("id").As("stock_id"),
+		goqu.I("item_category_id").As("category_id"),
+		"quantity",
+	).
+		From("non_serialized_items").
+		Where(goqu.Ex{"item_category_id": categoryID, "location_id": locationID}).
+		Order(goqu.I("quantity").Desc(), goqu.I("id").Asc()).
+		Limit(1).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stock of category %d: %w", categoryID, err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stock, nil
+}
@@ -0,0 +1,21 @@
+package stocks
+
+import (
+	"testing"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// Cancelling leg 2 of a shipment restores stock to the hub, whose row was deleted once leg 2 emptied it
+func TestUpsertStockQueryRecreatesEmptiedRowPerOrigin(t *testing.T) {
+	insert := goqu.Dialect("postgres").Insert("non_serialized_items")
+
+	sql, _, err := upsertStockQuery(insert, StockItemRequest{CategoryID: 3, LocationID: 7, Quantity: 5, Origin: "probis"}).ToSQL()
+
+	assert.NoError(t, err)
+	assert.Contains(t, sql, `INSERT INTO "non_serialized_items"`)
+	assert.Contains(t, sql, `VALUES (3, 7, 'probis', 5)`)
+	assert.Contains(t, sql, `ON CONFLICT (item_category_id, location_id, origin) DO UPDATE SET "quantity"=non_serialized_items.quantity + EXCLUDED.quantity`)
+	assert.Contains(t, sql, `RETURNING "id"`)
+}
@@ -1,10 +1,11 @@
 package stocks
 
 type StockItemRequest struct {
-	CategoryID int    `json:"category_id" binding:"required"`
-	LocationID int    `json:"location_id"`
-	Quantity   int    `json:"quantity" binding:"required"`
-	Origin     string `json:"origin"`
+	CategoryID  int    `json:"category_id" binding:"required"`
+	LocationID  int    `json:"location_id"`
+	Quantity    int    `json:"quantity" binding:"required"`
+	Origin      string `json:"origin"`
+	CreatedByID *int   `json:"-"`
 }
 
 type PatchStockItemRequest struct {
@@ -12,6 +13,8 @@ type PatchStockItemRequest struct {
 	LocationID *int    `json:"location_id"`
 	Quantity   *int    `json:"quantity"`
 	Origin     *string `json:"origin"`
+	Reason     *string `json:"reason" binding:"omitempty,oneof=count_correction damaged found lost"`
+	Notes      *string `json:"notes"`
 }
 
 type RemoveStockItemFromTransferRequest struct {
@@ -19,6 +22,8 @@ type RemoveStockItemFromTransferRequest struct {
 	ToLocationID int `json:"location_id" binding:"required"`
 	TransferID   int
 	CategoryID   int
+	// Origin of the transfer line, stock is restored to the row of this origin
+	Origin string `json:"-"`
 }
 
 type MoveStockItemToLocationRequest struct {
@@ -27,3 +32,36 @@ type MoveStockItemToLocationRequest struct {
 	FromLocationID int
 	ToLocationID   int
 }
+
+type AdjustStockRequest struct {
+	StockID       int     `uri:"id" binding:"required"`
+	Delta         int     `json:"delta" binding:"required"`
+	Reason        string  `json:"reason" binding:"required,oneof=count_correction damaged found lost"`
+	Notes         *string `json:"notes"`
+	RequestedByID *int    `json:"-"`
+}
+
+type ReviewAdjustmentRequest struct {
+	ID    int     `uri:"id" binding:"required"`
+	Notes *string `json:"notes"`
+}
+
+type RetrieveAdjustmentListQuery struct {
+	StockItemID int    `form:"stock_id"`
+	CategoryID  int    `form:"category_id"`
+	Status      string `form:"status" binding:"omitempty,oneof=pending applied rejected"`
+	Reason      string `form:"reason"`
+}
+
+type SplitStockRequest struct {
+	StockID       int    `uri:"id" binding:"required"`
+	Quantity      int    `json:"quantity" binding:"required,min=1"`
+	Origin        string `json:"origin" binding:"required"`
+	RequestedByID *int   `json:"-"`
+}
+
+type MergeStockRequest struct {
+	StockID       int  `uri:"id" binding:"required"`
+	SourceID      int  `json:"source_id" binding:"required"`
+	RequestedByID *int `json:"-"`
+}
@@ -0,0 +1,63 @@
+package stocks
+
+import (
+	"net/http"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *StockHandler) SplitStock(c *gin.Context) {
+	var req SplitStockRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	origin, err := h.Repository.ResolveOrigin(req.Origin)
+	if err != nil {
+		abortWithOriginError(c, err)
+		return
+	}
+	req.Origin = origin.Slug
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.RequestedByID = &userID
+	}
+
+	result, err := h.Splits.SplitStock(req)
+	if err != nil {
+		h.handleStockError(c, "Nie udało się podzielić stanu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *StockHandler) MergeStock(c *gin.Context) {
+	var req MergeStockRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.RequestedByID = &userID
+	}
+
+	result, err := h.Splits.MergeStock(req)
+	if err != nil {
+		h.handleStockError(c, "Nie udało się scalić stanów", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
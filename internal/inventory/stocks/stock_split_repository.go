@@ -0,0 +1,141 @@
+package stocks
+
+import (
+	"fmt"
+	"sort"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// lockStocks locks rows in id order so concurrent splits and merges cannot deadlock, missing rows are left out
+func (r *StockRepository) lockStocks(tx *goqu.TxDatabase, ids ...int) (map[int]*lockedStock, error) {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+
+	locked := map[int]*lockedStock{}
+	for _, id := range sorted {
+		stock, err := r.lockStock(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if stock != nil {
+			locked[id] = stock
+		}
+	}
+
+	return locked, nil
+}
+
+// findStock reads a row without locking it, SplitStock needs its category and location before locking both rows
+func (r *StockRepository) findStock(tx *goqu.TxDatabase, stockID int) (*lockedStock, error) {
+	var stock lockedStock
+	found, err := tx.Select("id", "item_category_id", "location_id", "origin", "quantity").
+		From("non_serialized_items").
+		Where(goqu.Ex{"id": stockID}).
+		Executor().
+		ScanStruct(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stock, nil
+}
+
+// ensureStockRow returns the row of category, location and origin, creating an empty one when missing.
+// Unlike upsertStockQuantity an existing row is not updated, so it stays unlocked until lockStocks.
+func (r *StockRepository) ensureStockRow(tx *goqu.TxDatabase, stockRequest StockItemRequest) (int, error) {
+	var stockID int
+	found, err := tx.Insert("non_serialized_items").
+		Rows(goqu.Record{
+			"quantity":         0,
+			"location_id":      stockRequest.LocationID,
+			"item_category_id": stockRequest.CategoryID,
+			"origin":           stockRequest.Origin,
+		}).
+		OnConflict(goqu.DoNothing()).
+		Returning("id").
+		Executor().
+		ScanVal(&stockID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create stock row: %w", err)
+	}
+	if found {
+		return stockID, nil
+	}
+
+	_, err = tx.Select("id").
+		From("non_serialized_items").
+		Where(goqu.Ex{
+			"item_category_id": stockRequest.CategoryID,
+			"location_id":      stockRequest.LocationID,
+			"origin":           stockRequest.Origin,
+		}).
+		Executor().
+		ScanVal(&stockID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stock row: %w", err)
+	}
+
+	return stockID, nil
+}
+
+// moveStockQuantity moves quantity between two rows and books it on both of them with the other row as counterpart
+func (r *StockRepository) moveStockQuantity(tx *goqu.TxDatabase, from, to *lockedStock, quantity int, reason string, userID *int) error {
+	result, err := tx.Update("non_serialized_items").
+		Set(goqu.Record{"quantity": goqu.L("quantity - ?", quantity)}).
+		Where(goqu.Ex{"id": from.ID}).
+		Where(goqu.C("quantity").Gte(quantity)).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to decrease stock %d: %w", from.ID, err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("%w for stock %d", ErrInsufficientQuantity, from.ID)
+	}
+
+	_, err = tx.Update("non_serialized_items").
+		Set(goqu.Record{"quantity": goqu.L("quantity + ?", quantity)}).
+		Where(goqu.Ex{"id": to.ID}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to increase stock %d: %w", to.ID, err)
+	}
+
+	entries := []models.StockAdjustment{
+		{
+			StockItemID:            &from.ID,
+			CategoryID:             from.CategoryID,
+			LocationID:             from.LocationID,
+			Origin:                 from.Origin,
+			Delta:                  -quantity,
+			Reason:                 reason,
+			RequestedByID:          userID,
+			CounterpartStockItemID: &to.ID,
+		},
+		{
+			StockItemID:            &to.ID,
+			CategoryID:             to.CategoryID,
+			LocationID:             to.LocationID,
+			Origin:                 to.Origin,
+			Delta:                  quantity,
+			Reason:                 reason,
+			RequestedByID:          userID,
+			CounterpartStockItemID: &from.ID,
+		},
+	}
+	for _, entry := range entries {
+		if _, err := r.InsertStockAdjustment(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
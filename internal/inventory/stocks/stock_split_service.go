@@ -0,0 +1,197 @@
+package stocks
+
+import (
+	"errors"
+	"fmt"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrSameOrigin        = errors.New("stock already has this origin")
+	ErrSameStock         = errors.New("stock cannot be merged into itself")
+	ErrIncompatibleStock = errors.New("only rows of the same category and location can be merged")
+)
+
+type StockSplitService struct {
+	r         *repository.Repository
+	stockRepo *StockRepository
+	a         *auditlog.Auditlog
+}
+
+// StockRowsResult shows both rows after a split or merge
+type StockRowsResult struct {
+	Source *models.StockItem `json:"source"`
+	Target *models.StockItem `json:"target"`
+}
+
+func NewSplitService(r *repository.Repository, sr *StockRepository, a *auditlog.Auditlog) *StockSplitService {
+	return &StockSplitService{
+		r:         r,
+		stockRepo: sr,
+		a:         a,
+	}
+}
+
+// SplitStock moves part of a row to the row of another origin at the same location, creating it when missing.
+// Both rows are locked in id order like in MergeStock.
+func (s *StockSplitService) SplitStock(req SplitStockRequest) (*StockRowsResult, error) {
+	var targetID int
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		source, err := s.stockRepo.findStock(tx, req.StockID)
+		if err != nil {
+			return err
+		}
+		if source == nil {
+			return ErrStockNotFound
+		}
+		if source.Origin != nil && *source.Origin == req.Origin {
+			return ErrSameOrigin
+		}
+
+		targetID, err = s.stockRepo.ensureStockRow(tx, StockItemRequest{
+			CategoryID: source.CategoryID,
+			LocationID: source.LocationID,
+			Origin:     req.Origin,
+		})
+		if err != nil {
+			return err
+		}
+
+		locked, err := s.stockRepo.lockStocks(tx, req.StockID, targetID)
+		if err != nil {
+			return err
+		}
+		source, target := locked[req.StockID], locked[targetID]
+		if err := validateSplit(source, target, req); err != nil {
+			return err
+		}
+
+		return s.stockRepo.moveStockQuantity(tx, source, target, req.Quantity, models.AdjustmentReasonSplit, req.RequestedByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.getRows(req.StockID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.logRows(result, "split", req.Quantity, "Część stanu przeniesiona do innego pochodzenia", "Stan przyjęty z podziału innego wiersza")
+
+	return result, nil
+}
+
+// MergeStock moves the whole quantity of the source row into the target row, the emptied source row is kept
+// so stocktakes and the ledger still point to it
+func (s *StockSplitService) MergeStock(req MergeStockRequest) (*StockRowsResult, error) {
+	if err := validateMerge(req); err != nil {
+		return nil, err
+	}
+
+	var moved int
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		locked, err := s.stockRepo.lockStocks(tx, req.StockID, req.SourceID)
+		if err != nil {
+			return err
+		}
+		target, source := locked[req.StockID], locked[req.SourceID]
+		if err := checkCompatible(source, target); err != nil {
+			return err
+		}
+
+		moved = source.Quantity
+		if moved == 0 {
+			return nil
+		}
+		return s.stockRepo.moveStockQuantity(tx, source, target, moved, models.AdjustmentReasonMerge, req.RequestedByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.getRows(req.SourceID, req.StockID)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.logRows(result, "merge", moved, "Wiersz stanu scalony z innym", "Scalono wiersz stanu")
+
+	return result, nil
+}
+
+// validateSplit checks the locked rows, they may have changed since the source was first read
+func validateSplit(source, target *lockedStock, req SplitStockRequest) error {
+	if err := checkCompatible(source, target); err != nil {
+		return err
+	}
+	if source.ID == target.ID || (source.Origin != nil && *source.Origin == req.Origin) {
+		return ErrSameOrigin
+	}
+	if source.Quantity < req.Quantity {
+		return fmt.Errorf("%w for stock %d", ErrInsufficientQuantity, source.ID)
+	}
+
+	return nil
+}
+
+func validateMerge(req MergeStockRequest) error {
+	if req.StockID == req.SourceID {
+		return ErrSameStock
+	}
+
+	return nil
+}
+
+// checkCompatible allows moving quantity only between rows of the same category and location
+func checkCompatible(source, target *lockedStock) error {
+	if source == nil || target == nil {
+		return ErrStockNotFound
+	}
+	if target.CategoryID != source.CategoryID || target.LocationID != source.LocationID {
+		return ErrIncompatibleStock
+	}
+
+	return nil
+}
+
+func (s *StockSplitService) getRows(sourceID, targetID int) (*StockRowsResult, error) {
+	source, err := s.stockRepo.GetStockItem(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.stockRepo.GetStockItem(targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StockRowsResult{Source: source, Target: target}, nil
+}
+
+// logRows leaves an audit entry on both rows pointing at the other one
+func (s *StockSplitService) logRows(result *StockRowsResult, action string, quantity int, sourceMsg, targetMsg string) {
+	s.a.Log(
+		action,
+		map[string]interface{}{
+			"target_stock_id": result.Target.ID,
+			"quantity":        quantity,
+			"msg":             sourceMsg,
+		},
+		*result.Source,
+	)
+	s.a.Log(
+		action,
+		map[string]interface{}{
+			"source_stock_id": result.Source.ID,
+			"quantity":        quantity,
+			"msg":             targetMsg,
+		},
+		*result.Target,
+	)
+}
@@ -0,0 +1,60 @@
+package stocks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestValidateSplit(t *testing.T) {
+	source := &lockedStock{ID: 1, CategoryID: 3, LocationID: 1, Origin: strPtr("own"), Quantity: 10}
+	target := &lockedStock{ID: 2, CategoryID: 3, LocationID: 1, Origin: strPtr("probis")}
+
+	tests := []struct {
+		name    string
+		source  *lockedStock
+		target  *lockedStock
+		req     SplitStockRequest
+		wantErr error
+	}{
+		{name: "part of the row", source: source, target: target, req: SplitStockRequest{Quantity: 4, Origin: "probis"}},
+		{name: "whole row", source: source, target: target, req: SplitStockRequest{Quantity: 10, Origin: "probis"}},
+		{name: "more than the row holds", source: source, target: target, req: SplitStockRequest{Quantity: 11, Origin: "probis"}, wantErr: ErrInsufficientQuantity},
+		{name: "same origin", source: source, target: source, req: SplitStockRequest{Quantity: 1, Origin: "own"}, wantErr: ErrSameOrigin},
+		{name: "same row", source: source, target: source, req: SplitStockRequest{Quantity: 1, Origin: "probis"}, wantErr: ErrSameOrigin},
+		{name: "source removed", target: target, req: SplitStockRequest{Quantity: 1, Origin: "probis"}, wantErr: ErrStockNotFound},
+		{
+			name:    "source relocated meanwhile",
+			source:  &lockedStock{ID: 1, CategoryID: 3, LocationID: 4, Origin: strPtr("own"), Quantity: 10},
+			target:  target,
+			req:     SplitStockRequest{Quantity: 1, Origin: "probis"},
+			wantErr: ErrIncompatibleStock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSplit(tt.source, tt.target, tt.req)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateMerge(t *testing.T) {
+	assert.NoError(t, validateMerge(MergeStockRequest{StockID: 1, SourceID: 2}))
+	assert.ErrorIs(t, validateMerge(MergeStockRequest{StockID: 1, SourceID: 1}), ErrSameStock)
+}
+
+func TestCheckCompatible(t *testing.T) {
+	row := &lockedStock{ID: 1, CategoryID: 3, LocationID: 1}
+
+	assert.NoError(t, checkCompatible(row, &lockedStock{ID: 2, CategoryID: 3, LocationID: 1}))
+	assert.ErrorIs(t, checkCompatible(row, &lockedStock{ID: 2, CategoryID: 5, LocationID: 1}), ErrIncompatibleStock)
+	assert.ErrorIs(t, checkCompatible(row, &lockedStock{ID: 2, CategoryID: 3, LocationID: 4}), ErrIncompatibleStock)
+	assert.ErrorIs(t, checkCompatible(nil, row), ErrStockNotFound)
+}
@@ -0,0 +1,229 @@
+package stocktakes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/locations"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StocktakeHandler struct {
+	Service *StocktakeService
+}
+
+func NewHandler(
+	r *repository.Repository,
+	ar *assets.AssetsRepository,
+	sr *stocks.StockRepository,
+	lr *locations.LocationRepository,
+	a *auditlog.Auditlog,
+) *StocktakeHandler {
+	return &StocktakeHandler{
+		Service: NewService(r, NewRepository(r), ar, sr, lr, a),
+	}
+}
+
+func (h *StocktakeHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/stocktakes", security.Authorize("user"), h.GetStocktakes)
+	router.GET("/stocktakes/:id", security.Authorize("user"), h.GetStocktake)
+	router.GET("/stocktakes/:id/reconciliation", security.Authorize("user"), h.GetReconciliation)
+	router.POST("/stocktakes", security.Authorize("moderator"), h.StartStocktake)
+	router.POST("/stocktakes/:id/scans", security.Authorize("user"), h.ScanAsset)
+	router.PUT("/stocktakes/:id/stock/:stock_id", security.Authorize("user"), h.CountStock)
+	router.PATCH("/stocktakes/:id/close", security.Authorize("moderator"), h.CloseStocktake)
+	router.POST("/stocktakes/:id/apply", security.Authorize("moderator"), h.ApplyStocktake)
+}
+
+func (h *StocktakeHandler) GetStocktakes(c *gin.Context) {
+	var query RetrieveStocktakeListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	stocktakes, err := h.Service.GetStocktakes(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania inwentaryzacji", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stocktakes)
+}
+
+func (h *StocktakeHandler) GetStocktake(c *gin.Context) {
+	id, ok := h.stocktakeID(c)
+	if !ok {
+		return
+	}
+
+	stocktake, err := h.Service.GetStocktake(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania inwentaryzacji", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stocktake)
+}
+
+func (h *StocktakeHandler) StartStocktake(c *gin.Context) {
+	var req StartStocktakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	stocktake, err := h.Service.StartStocktake(req.LocationID, h.currentUserID(c))
+	if err != nil {
+		h.handleError(c, "Nie udało się rozpocząć inwentaryzacji", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, stocktake)
+}
+
+func (h *StocktakeHandler) ScanAsset(c *gin.Context) {
+	id, ok := h.stocktakeID(c)
+	if !ok {
+		return
+	}
+
+	var req ScanAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	result, err := h.Service.ScanAsset(id, req.PyrCode, h.currentUserID(c))
+	if err != nil {
+		h.handleError(c, "Nie udało się zarejestrować skanu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *StocktakeHandler) CountStock(c *gin.Context) {
+	id, ok := h.stocktakeID(c)
+	if !ok {
+		return
+	}
+
+	stockID, err := strconv.Atoi(c.Param("stock_id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	var req CountStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if err := h.Service.CountStock(id, stockID, *req.Quantity, h.currentUserID(c)); err != nil {
+		h.handleError(c, "Nie udało się zapisać stanu", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *StocktakeHandler) GetReconciliation(c *gin.Context) {
+	id, ok := h.stocktakeID(c)
+	if !ok {
+		return
+	}
+
+	rec, err := h.Service.GetReconciliation(id)
+	if err != nil {
+		h.handleError(c, "Błąd przygotowania rozliczenia", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rec)
+}
+
+func (h *StocktakeHandler) CloseStocktake(c *gin.Context) {
+	id, ok := h.stocktakeID(c)
+	if !ok {
+		return
+	}
+
+	rec, err := h.Service.CloseStocktake(id)
+	if err != nil {
+		h.handleError(c, "Nie udało się zamknąć inwentaryzacji", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rec)
+}
+
+func (h *StocktakeHandler) ApplyStocktake(c *gin.Context) {
+	id, ok := h.stocktakeID(c)
+	if !ok {
+		return
+	}
+
+	var req ApplyStocktakeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.Service.ApplyStocktake(id, req, h.currentUserID(c))
+	if err != nil {
+		h.handleError(c, "Nie udało się zastosować poprawek", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *StocktakeHandler) stocktakeID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h *StocktakeHandler) currentUserID(c *gin.Context) *int {
+	userID, err := security.GetUserIDFromContext(c)
+	if err != nil {
+		return nil
+	}
+
+	return &userID
+}
+
+func (h *StocktakeHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrStocktakeNotFound), errors.Is(err, ErrAssetNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrStockNotInScope):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.UniqueViolationError:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Inwentaryzacja tej lokalizacji jest już otwarta", "details": err.Error()})
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Lokalizacja nie istnieje", "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package stocktakes
+
+import (
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+)
+
+// ScannedAsset is an asset registered in a stocktake together with its recorded location
+type ScannedAsset struct {
+	AssetID       int     `db:"item_id"`
+	PyrCode       *string `db:"pyr_code"`
+	Status        *string `db:"status"`
+	LocationID    int     `db:"location_id"`
+	CategoryLabel *string `db:"category_label"`
+}
+
+type AssetDiscrepancy struct {
+	AssetID       int             `json:"asset_id"`
+	PyrCode       string          `json:"pyr_code"`
+	Status        metadata.Status `json:"status"`
+	LocationID    int             `json:"recorded_location_id"`
+	CategoryLabel string          `json:"category_label"`
+}
+
+type StockDiscrepancy struct {
+	StockID       int    `json:"stock_id"`
+	CategoryID    int    `json:"category_id"`
+	CategoryLabel string `json:"category_label"`
+	Recorded      int    `json:"recorded"`
+	Counted       *int   `json:"counted"`
+	Difference    int    `json:"difference"`
+}
+
+type Reconciliation struct {
+	StocktakeID     int                `json:"stocktake_id"`
+	LocationID      int                `json:"location_id"`
+	ExpectedCount   int                `json:"expected_count"`
+	ScannedCount    int                `json:"scanned_count"`
+	MissingAssets   []AssetDiscrepancy `json:"missing_assets"`
+	MisplacedAssets []AssetDiscrepancy `json:"misplaced_assets"`
+	Stock           []StockDiscrepancy `json:"stock"`
+}
+
+// IsClean reports whether the counted state matches the records
+func (r *Reconciliation) IsClean() bool {
+	if len(r.MissingAssets) > 0 || len(r.MisplacedAssets) > 0 {
+		return false
+	}
+	for _, s := range r.Stock {
+		if s.Counted != nil && s.Difference != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func BuildReconciliation(
+	stocktake *models.Stocktake,
+	expected []models.Asset,
+	scanned []ScannedAsset,
+	stock []models.StockItem,
+	counts map[int]int,
+) *Reconciliation {
+	rec := &Reconciliation{
+		StocktakeID:     stocktake.ID,
+		LocationID:      stocktake.LocationID,
+		ExpectedCount:   len(expected),
+		ScannedCount:    len(scanned),
+		MissingAssets:   []AssetDiscrepancy{},
+		MisplacedAssets: []AssetDiscrepancy{},
+		Stock:           []StockDiscrepancy{},
+	}
+
+	scannedIDs := make(map[int]bool, len(scanned))
+	for _, s := range scanned {
+		scannedIDs[s.AssetID] = true
+		if s.LocationID == stocktake.LocationID {
+			continue
+		}
+		rec.MisplacedAssets = append(rec.MisplacedAssets, AssetDiscrepancy{
+			AssetID:       s.AssetID,
+			PyrCode:       deref(s.PyrCode),
+			Status:        metadata.Status(deref(s.Status)),
+			LocationID:    s.LocationID,
+			CategoryLabel: deref(s.CategoryLabel),
+		})
+	}
+
+	for _, asset := range expected {
+		if scannedIDs[asset.ID] {
+			continue
+		}
+		rec.MissingAssets = append(rec.MissingAssets, AssetDiscrepancy{
+			AssetID:       asset.ID,
+			PyrCode:       asset.PyrCode,
+			Status:        asset.Status,
+			LocationID:    stocktake.LocationID,
+			CategoryLabel: asset.Category.Label,
+		})
+	}
+
+	for _, item := range stock {
+		discrepancy := StockDiscrepancy{
+			StockID:       item.ID,
+			CategoryID:    item.Category.ID,
+			CategoryLabel: item.Category.Label,
+			Recorded:      item.Quantity,
+		}
+		if counted, ok := counts[item.ID]; ok {
+			counted := counted
+			discrepancy.Counted = &counted
+			discrepancy.Difference = counted - item.Quantity
+		}
+		rec.Stock = append(rec.Stock, discrepancy)
+	}
+
+	return rec
+}
+
+// canMarkMissing reports whether a missing asset can be flagged unavailable, assets on loan, in repair or in transit are expected to be away
+func canMarkMissing(status metadata.Status) bool {
+	return status == metadata.StatusAvailable || status == metadata.StatusLocated || status == metadata.StatusInStock
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
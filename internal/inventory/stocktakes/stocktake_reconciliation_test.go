@@ -0,0 +1,55 @@
+package stocktakes
+
+import (
+	"testing"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildReconciliation(t *testing.T) {
+	stocktake := &models.Stocktake{ID: 3, LocationID: 5}
+	pyr := "PYR-LT9"
+
+	expected := []models.Asset{
+		{ID: 1, PyrCode: "PYR-LT1", Status: metadata.StatusLocated},
+		{ID: 2, PyrCode: "PYR-LT2", Status: metadata.StatusLocated},
+	}
+	scanned := []ScannedAsset{
+		{AssetID: 1, LocationID: 5},
+		{AssetID: 9, LocationID: 1, PyrCode: &pyr},
+	}
+	stock := []models.StockItem{
+		{ID: 10, Quantity: 20},
+		{ID: 11, Quantity: 4},
+	}
+	counts := map[int]int{10: 17}
+
+	rec := BuildReconciliation(stocktake, expected, scanned, stock, counts)
+
+	assert.Equal(t, 2, rec.ExpectedCount)
+	assert.Equal(t, 2, rec.ScannedCount)
+	assert.Len(t, rec.MissingAssets, 1)
+	assert.Equal(t, 2, rec.MissingAssets[0].AssetID)
+	assert.Len(t, rec.MisplacedAssets, 1)
+	assert.Equal(t, "PYR-LT9", rec.MisplacedAssets[0].PyrCode)
+	assert.Equal(t, 1, rec.MisplacedAssets[0].LocationID)
+	assert.Equal(t, -3, rec.Stock[0].Difference)
+	assert.Nil(t, rec.Stock[1].Counted)
+	assert.False(t, rec.IsClean())
+}
+
+func TestBuildReconciliationClean(t *testing.T) {
+	stocktake := &models.Stocktake{ID: 1, LocationID: 2}
+
+	rec := BuildReconciliation(
+		stocktake,
+		[]models.Asset{{ID: 1}},
+		[]ScannedAsset{{AssetID: 1, LocationID: 2}},
+		[]models.StockItem{{ID: 4, Quantity: 3}},
+		map[int]int{4: 3},
+	)
+
+	assert.True(t, rec.IsClean())
+}
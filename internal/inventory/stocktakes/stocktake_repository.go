@@ -0,0 +1,230 @@
+package stocktakes
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+)
+
+type StocktakeRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *StocktakeRepository {
+	return &StocktakeRepository{
+		repository: r,
+	}
+}
+
+func (r *StocktakeRepository) InsertStocktake(locationID int, startedByID *int) (int, error) {
+	var id int
+
+	_, err := r.repository.GoquDBWrapper.Insert("stocktakes").
+		Rows(goqu.Record{
+			"location_id":   locationID,
+			"started_by_id": startedByID,
+		}).
+		Returning("id").
+		Executor().
+		ScanVal(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			return 0, custom_error.WrapDBError("Stocktake already open for location", string(pqErr.Code))
+		}
+		return 0, fmt.Errorf("failed to insert stocktake: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *StocktakeRepository) GetStocktake(id int) (*models.Stocktake, error) {
+	var stocktake models.Stocktake
+
+	found, err := r.repository.GoquDBWrapper.From("stocktakes").Where(goqu.Ex{"id": id}).Executor().ScanStruct(&stocktake)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stocktake, nil
+}
+
+func (r *StocktakeRepository) GetStocktakes(query RetrieveStocktakeListQuery) ([]models.Stocktake, error) {
+	conditions := goqu.Ex{}
+	if query.LocationID != 0 {
+		conditions["location_id"] = query.LocationID
+	}
+	if query.Status != "" {
+		conditions["status"] = query.Status
+	}
+
+	stocktakes := []models.Stocktake{}
+	err := r.repository.GoquDBWrapper.From("stocktakes").
+		Where(conditions).
+		Order(goqu.C("started_at").Desc()).
+		Executor().
+		ScanStructs(&stocktakes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return stocktakes, nil
+}
+
+// LockStocktake locks the session row so scans, counts and status changes do not interleave
+func (r *StocktakeRepository) LockStocktake(tx *goqu.TxDatabase, id int) (*models.Stocktake, error) {
+	var stocktake models.Stocktake
+
+	found, err := tx.From("stocktakes").Where(goqu.Ex{"id": id}).ForUpdate(exp.Wait).Executor().ScanStruct(&stocktake)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stocktake: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stocktake, nil
+}
+
+// LockStocktakeShared lets several devices scan concurrently while preventing the session from being closed mid-scan
+func (r *StocktakeRepository) LockStocktakeShared(tx *goqu.TxDatabase, id int) (*models.Stocktake, error) {
+	var stocktake models.Stocktake
+
+	found, err := tx.From("stocktakes").Where(goqu.Ex{"id": id}).ForShare(exp.Wait).Executor().ScanStruct(&stocktake)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock stocktake: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &stocktake, nil
+}
+
+func (r *StocktakeRepository) UpdateStocktakeStatus(tx *goqu.TxDatabase, id int, status string, userID *int) error {
+	record := goqu.Record{"status": status}
+	switch status {
+	case models.StocktakeStatusClosed:
+		record["closed_at"] = time.Now()
+	case models.StocktakeStatusApplied:
+		record["applied_at"] = time.Now()
+		record["applied_by_id"] = userID
+	}
+
+	if _, err := tx.Update("stocktakes").Set(record).Where(goqu.Ex{"id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to update stocktake status: %w", err)
+	}
+
+	return nil
+}
+
+// InsertScan registers a scanned asset, returns false when the asset was already scanned in this session
+func (r *StocktakeRepository) InsertScan(tx *goqu.TxDatabase, stocktakeID int, assetID int, scannedByID *int) (bool, error) {
+	result, err := tx.Insert("stocktake_asset_scans").
+		Rows(goqu.Record{
+			"stocktake_id":  stocktakeID,
+			"item_id":       assetID,
+			"scanned_by_id": scannedByID,
+		}).
+		OnConflict(goqu.DoNothing()).
+		Executor().
+		Exec()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert scan: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *StocktakeRepository) UpsertStockCount(tx *goqu.TxDatabase, stocktakeID int, stockID int, quantity int, countedByID *int) error {
+	_, err := tx.Insert("stocktake_stock_counts").
+		Rows(goqu.Record{
+			"stocktake_id":  stocktakeID,
+			"stock_id":      stockID,
+			"quantity":      quantity,
+			"counted_by_id": countedByID,
+		}).
+		OnConflict(goqu.DoUpdate("stocktake_id, stock_id", goqu.Record{
+			"quantity":      goqu.I("excluded.quantity"),
+			"counted_by_id": goqu.I("excluded.counted_by_id"),
+			"counted_at":    goqu.L("NOW()"),
+		})).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to save stock count: %w", err)
+	}
+
+	return nil
+}
+
+func (r *StocktakeRepository) GetScannedAssets(stocktakeID int) ([]ScannedAsset, error) {
+	scanned := []ScannedAsset{}
+
+	err := r.repository.GoquDBWrapper.Select(
+		"s.item_id",
+		"i.pyr_code",
+		"i.status",
+		"i.location_id",
+		goqu.I("c.label").As("category_label"),
+	).
+		From(goqu.T("stocktake_asset_scans").As("s")).
+		InnerJoin(goqu.T("items").As("i"), goqu.On(goqu.Ex{"s.item_id": goqu.I("i.id")})).
+		LeftJoin(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"i.item_category_id": goqu.I("c.id")})).
+		Where(goqu.Ex{"s.stocktake_id": stocktakeID}).
+		Order(goqu.I("s.scanned_at").Asc()).
+		Executor().
+		ScanStructs(&scanned)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return scanned, nil
+}
+
+func (r *StocktakeRepository) GetStockCounts(stocktakeID int) (map[int]int, error) {
+	var rows []struct {
+		StockID  int `db:"stock_id"`
+		Quantity int `db:"quantity"`
+	}
+
+	err := r.repository.GoquDBWrapper.Select("stock_id", "quantity").
+		From("stocktake_stock_counts").
+		Where(goqu.Ex{"stocktake_id": stocktakeID}).
+		Executor().
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.StockID] = row.Quantity
+	}
+
+	return counts, nil
+}
+
+func (r *StocktakeRepository) StockBelongsToLocation(stockID int, locationID int) (bool, error) {
+	count, err := r.repository.GoquDBWrapper.From("non_serialized_items").
+		Where(goqu.Ex{"id": stockID, "location_id": locationID}).
+		Count()
+	if err != nil {
+		return false, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return count > 0, nil
+}
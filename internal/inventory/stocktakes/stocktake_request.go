@@ -0,0 +1,29 @@
+package stocktakes
+
+type StartStocktakeRequest struct {
+	LocationID int `json:"location_id" binding:"required"`
+}
+
+type ScanAssetRequest struct {
+	PyrCode string `json:"pyr_code" binding:"required"`
+}
+
+type CountStockRequest struct {
+	Quantity *int `json:"quantity" binding:"required,min=0"`
+}
+
+// ApplyStocktakeRequest selects which fixes from the reconciliation should be applied, all of them by default
+type ApplyStocktakeRequest struct {
+	MarkMissingUnavailable *bool `json:"mark_missing_unavailable"`
+	RelocateMisplaced      *bool `json:"relocate_misplaced"`
+	ApplyStockCounts       *bool `json:"apply_stock_counts"`
+}
+
+func (r ApplyStocktakeRequest) enabled(option *bool) bool {
+	return option == nil || *option
+}
+
+type RetrieveStocktakeListQuery struct {
+	LocationID int    `form:"location_id"`
+	Status     string `form:"status"`
+}
@@ -0,0 +1,353 @@
+package stocktakes
+
+import (
+	"errors"
+	"strconv"
+	"warehouse/internal/inventory/assets"
+	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/locations"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrStocktakeNotFound = errors.New("stocktake not found")
+	ErrAssetNotFound     = errors.New("asset not found")
+	ErrStockNotInScope   = errors.New("stock does not belong to the stocktake location")
+)
+
+type ScanResult struct {
+	Asset     *models.Asset `json:"asset"`
+	Duplicate bool          `json:"duplicate"`
+	Expected  bool          `json:"expected"`
+}
+
+type ApplyResult struct {
+	Reconciliation  *Reconciliation            `json:"reconciliation"`
+	StatusChanges   []models.AssetStatusChange `json:"status_changes"`
+	RelocatedAssets []int                      `json:"relocated_assets"`
+	SkippedAssets   []int                      `json:"skipped_assets"`
+	UpdatedStock    []int                      `json:"updated_stock"`
+}
+
+type StocktakeService struct {
+	r         *repository.Repository
+	sr        *StocktakeRepository
+	ar        *assets.AssetsRepository
+	stockRepo *stocks.StockRepository
+	lr        *locations.LocationRepository
+	a         *auditlog.Auditlog
+	il        *inventorylog.InventoryLog
+}
+
+func NewService(
+	r *repository.Repository,
+	sr *StocktakeRepository,
+	ar *assets.AssetsRepository,
+	stockRepo *stocks.StockRepository,
+	lr *locations.LocationRepository,
+	a *auditlog.Auditlog,
+) *StocktakeService {
+	return &StocktakeService{
+		r:         r,
+		sr:        sr,
+		ar:        ar,
+		stockRepo: stockRepo,
+		lr:        lr,
+		a:         a,
+		il:        inventorylog.NewInventoryLog(a),
+	}
+}
+
+func (s *StocktakeService) StartStocktake(locationID int, userID *int) (*models.Stocktake, error) {
+	id, err := s.sr.InsertStocktake(locationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stocktake, err := s.sr.GetStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log("create", map[string]interface{}{
+		"location_id": locationID,
+		"msg":         "Rozpoczęto inwentaryzację",
+	}, stocktake)
+
+	return stocktake, nil
+}
+
+func (s *StocktakeService) GetStocktake(id int) (*models.Stocktake, error) {
+	stocktake, err := s.sr.GetStocktake(id)
+	if err != nil {
+		return nil, err
+	}
+	if stocktake == nil {
+		return nil, ErrStocktakeNotFound
+	}
+
+	return stocktake, nil
+}
+
+func (s *StocktakeService) GetStocktakes(query RetrieveStocktakeListQuery) ([]models.Stocktake, error) {
+	return s.sr.GetStocktakes(query)
+}
+
+func (s *StocktakeService) ScanAsset(stocktakeID int, pyrCode string, userID *int) (*ScanResult, error) {
+	asset, err := s.ar.FindItemByPyrCode(pyrCode)
+	if err != nil {
+		return nil, err
+	}
+	if asset.ID == 0 {
+		return nil, ErrAssetNotFound
+	}
+
+	result := &ScanResult{Asset: asset}
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		stocktake, err := s.lockOpenStocktake(tx, stocktakeID, false)
+		if err != nil {
+			return err
+		}
+
+		inserted, err := s.sr.InsertScan(tx, stocktakeID, asset.ID, userID)
+		if err != nil {
+			return err
+		}
+
+		result.Duplicate = !inserted
+		result.Expected = asset.Location.ID == stocktake.LocationID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *StocktakeService) CountStock(stocktakeID int, stockID int, quantity int, userID *int) error {
+	return repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		stocktake, err := s.lockOpenStocktake(tx, stocktakeID, false)
+		if err != nil {
+			return err
+		}
+
+		ok, err := s.sr.StockBelongsToLocation(stockID, stocktake.LocationID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrStockNotInScope
+		}
+
+		return s.sr.UpsertStockCount(tx, stocktakeID, stockID, quantity, userID)
+	})
+}
+
+func (s *StocktakeService) GetReconciliation(stocktakeID int) (*Reconciliation, error) {
+	stocktake, err := s.GetStocktake(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildReconciliation(stocktake)
+}
+
+// CloseStocktake stops accepting scans and returns the final reconciliation
+func (s *StocktakeService) CloseStocktake(stocktakeID int) (*Reconciliation, error) {
+	var stocktake *models.Stocktake
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if stocktake, err = s.lockOpenStocktake(tx, stocktakeID, true); err != nil {
+			return err
+		}
+		return s.sr.UpdateStocktakeStatus(tx, stocktakeID, models.StocktakeStatusClosed, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := s.buildReconciliation(stocktake)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log("close", map[string]interface{}{
+		"location_id":      stocktake.LocationID,
+		"expected_count":   rec.ExpectedCount,
+		"scanned_count":    rec.ScannedCount,
+		"missing_assets":   len(rec.MissingAssets),
+		"misplaced_assets": len(rec.MisplacedAssets),
+		"msg":              "Zamknięto inwentaryzację",
+	}, stocktake)
+
+	return rec, nil
+}
+
+// ApplyStocktake applies the reconciliation fixes of a closed stocktake in a single transaction
+func (s *StocktakeService) ApplyStocktake(stocktakeID int, req ApplyStocktakeRequest, userID *int) (*ApplyResult, error) {
+	stocktake, err := s.GetStocktake(stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := s.buildReconciliation(stocktake)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ApplyResult{
+		Reconciliation:  rec,
+		StatusChanges:   []models.AssetStatusChange{},
+		RelocatedAssets: []int{},
+		SkippedAssets:   []int{},
+		UpdatedStock:    []int{},
+	}
+	stockChanges := map[int][2]int{}
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		locked, err := s.sr.LockStocktake(tx, stocktakeID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != models.StocktakeStatusClosed {
+			return custom_error.NewInvalidStatusTransitionError("stocktake", stocktakeID, locked.Status, models.StocktakeStatusApplied)
+		}
+
+		if req.enabled(req.MarkMissingUnavailable) {
+			missingIDs := []int{}
+			for _, missing := range rec.MissingAssets {
+				if canMarkMissing(missing.Status) {
+					missingIDs = append(missingIDs, missing.AssetID)
+				} else {
+					result.SkippedAssets = append(result.SkippedAssets, missing.AssetID)
+				}
+			}
+			changes, err := s.ar.UpdateItemStatus(missingIDs, metadata.StatusUnavailable, tx)
+			if err != nil {
+				return err
+			}
+			result.StatusChanges = append(result.StatusChanges, changes...)
+		}
+
+		if req.enabled(req.RelocateMisplaced) {
+			for _, misplaced := range rec.MisplacedAssets {
+				if !canMarkMissing(misplaced.Status) {
+					result.SkippedAssets = append(result.SkippedAssets, misplaced.AssetID)
+					continue
+				}
+				change, err := s.ar.RelocateAsset(tx, misplaced.AssetID, stocktake.LocationID)
+				if err != nil {
+					return err
+				}
+				if change != nil {
+					result.StatusChanges = append(result.StatusChanges, *change)
+				}
+				result.RelocatedAssets = append(result.RelocatedAssets, misplaced.AssetID)
+			}
+		}
+
+		if req.enabled(req.ApplyStockCounts) {
+			for _, stock := range rec.Stock {
+				if stock.Counted == nil || stock.Difference == 0 {
+					continue
+				}
+				previous, err := s.stockRepo.SetStockQuantity(tx, stock.StockID, *stock.Counted, models.AdjustmentReasonStocktake)
+				if err != nil {
+					return err
+				}
+				stockChanges[stock.StockID] = [2]int{previous, *stock.Counted}
+				result.UpdatedStock = append(result.UpdatedStock, stock.StockID)
+			}
+		}
+
+		return s.sr.UpdateStocktakeStatus(tx, stocktakeID, models.StocktakeStatusApplied, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.logAppliedFixes(stocktake, result, stockChanges)
+
+	return result, nil
+}
+
+func (s *StocktakeService) logAppliedFixes(stocktake *models.Stocktake, result *ApplyResult, stockChanges map[int][2]int) {
+	s.il.CreateAssetStatusChangeLogEntries(result.StatusChanges, 0)
+
+	for _, assetID := range result.RelocatedAssets {
+		s.il.CreateAssetAuditLogEntry(
+			"stocktake_correction",
+			&models.Asset{ID: assetID, Location: models.Location{ID: stocktake.LocationID}},
+			"Lokalizacja poprawiona po inwentaryzacji #"+strconv.Itoa(stocktake.ID),
+		)
+	}
+
+	for stockID, change := range stockChanges {
+		s.a.Log("stocktake_correction", map[string]interface{}{
+			"stocktake_id":      stocktake.ID,
+			"previous_quantity": change[0],
+			"quantity":          change[1],
+			"msg":               "Stan magazynowy poprawiony po inwentaryzacji",
+		}, models.StockItem{ID: stockID})
+	}
+
+	s.a.Log("apply", map[string]interface{}{
+		"location_id":      stocktake.LocationID,
+		"status_changes":   len(result.StatusChanges),
+		"relocated_assets": result.RelocatedAssets,
+		"updated_stock":    result.UpdatedStock,
+		"skipped_assets":   result.SkippedAssets,
+		"msg":              "Zastosowano poprawki z inwentaryzacji",
+	}, stocktake)
+}
+
+func (s *StocktakeService) buildReconciliation(stocktake *models.Stocktake) (*Reconciliation, error) {
+	equipment, err := s.lr.GetLocationEquipment(strconv.Itoa(stocktake.LocationID))
+	if err != nil {
+		return nil, err
+	}
+
+	scanned, err := s.sr.GetScannedAssets(stocktake.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.sr.GetStockCounts(stocktake.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildReconciliation(stocktake, equipment.Assets, scanned, equipment.StockItems, counts), nil
+}
+
+// lockOpenStocktake takes an exclusive lock for status changes and a shared one for scans and counts
+func (s *StocktakeService) lockOpenStocktake(tx *goqu.TxDatabase, id int, exclusive bool) (*models.Stocktake, error) {
+	var stocktake *models.Stocktake
+	var err error
+
+	if exclusive {
+		stocktake, err = s.sr.LockStocktake(tx, id)
+	} else {
+		stocktake, err = s.sr.LockStocktakeShared(tx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stocktake == nil {
+		return nil, ErrStocktakeNotFound
+	}
+	if stocktake.Status != models.StocktakeStatusOpen {
+		return nil, custom_error.NewInvalidStatusTransitionError("stocktake", id, stocktake.Status, models.StocktakeStatusOpen)
+	}
+
+	return stocktake, nil
+}
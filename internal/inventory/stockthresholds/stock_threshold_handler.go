@@ -0,0 +1,104 @@
+package stockthresholds
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockThresholdHandler struct {
+	Service *StockThresholdService
+}
+
+func NewHandler(r *repository.Repository, sr *stocks.StockRepository, a *auditlog.Auditlog) *StockThresholdHandler {
+	return &StockThresholdHandler{
+		Service: NewService(r, NewRepository(r), sr, a),
+	}
+}
+
+func (h *StockThresholdHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/stocks/thresholds", security.Authorize("user"), h.GetThresholds)
+	router.PUT("/stocks/thresholds", security.Authorize("moderator"), h.SetThreshold)
+	router.DELETE("/stocks/thresholds/:id", security.Authorize("moderator"), h.DeleteThreshold)
+	router.GET("/stocks/low", security.Authorize("user"), h.GetLowStock)
+}
+
+func (h *StockThresholdHandler) GetThresholds(c *gin.Context) {
+	var query RetrieveThresholdListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	thresholds, err := h.Service.GetThresholds(query)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania minimalnych stanów", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, thresholds)
+}
+
+func (h *StockThresholdHandler) SetThreshold(c *gin.Context) {
+	var req SetThresholdRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	threshold, err := h.Service.SetThreshold(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się ustawić minimalnego stanu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, threshold)
+}
+
+func (h *StockThresholdHandler) DeleteThreshold(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	if err := h.Service.DeleteThreshold(id); err != nil {
+		h.handleError(c, "Nie udało się usunąć minimalnego stanu", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *StockThresholdHandler) GetLowStock(c *gin.Context) {
+	levels, err := h.Service.GetLowStock()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania niskich stanów", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
+
+func (h *StockThresholdHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrThresholdNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrNotStockCategory):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package stockthresholds
+
+import (
+	"fmt"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+)
+
+type StockThresholdRepository struct {
+	repository *repository.Repository
+}
+
+func NewRepository(r *repository.Repository) *StockThresholdRepository {
+	return &StockThresholdRepository{
+		repository: r,
+	}
+}
+
+// UpsertThreshold sets the minimum for a category (and location), an existing threshold is overwritten
+func (r *StockThresholdRepository) UpsertThreshold(req SetThresholdRequest) (int, error) {
+	var id int
+	_, err := r.repository.GoquDBWrapper.Insert("stock_thresholds").
+		Rows(goqu.Record{
+			"item_category_id": req.CategoryID,
+			"location_id":      req.LocationID,
+			"min_quantity":     req.MinQuantity,
+		}).
+		OnConflict(goqu.DoUpdate("item_category_id, COALESCE(location_id, 0)", goqu.Record{
+			"min_quantity": goqu.L("EXCLUDED.min_quantity"),
+			"updated_at":   goqu.L("NOW()"),
+		})).
+		Returning("id").
+		Executor().
+		ScanVal(&id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			return 0, custom_error.WrapDBError("Nieprawidłowa kategoria lub lokalizacja", string(pqErr.Code))
+		}
+		return 0, fmt.Errorf("failed to upsert stock threshold: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *StockThresholdRepository) GetThreshold(id int) (*models.StockThreshold, error) {
+	var threshold models.StockThreshold
+	found, err := r.getThresholdQuery().Where(goqu.Ex{"t.id": id}).Executor().ScanStruct(&threshold)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &threshold, nil
+}
+
+func (r *StockThresholdRepository) GetThresholds(query RetrieveThresholdListQuery) ([]models.StockThreshold, error) {
+	conditions := goqu.Ex{}
+	if query.CategoryID != 0 {
+		conditions["t.item_category_id"] = query.CategoryID
+	}
+	if query.LocationID != 0 {
+		conditions["t.location_id"] = query.LocationID
+	}
+
+	thresholds := []models.StockThreshold{}
+	err := r.getThresholdQuery().
+		Where(conditions).
+		Order(goqu.I("c.label").Asc(), goqu.I("l.name").Asc().NullsFirst()).
+		Executor().
+		ScanStructs(&thresholds)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+
+	return thresholds, nil
+}
+
+func (r *StockThresholdRepository) DeleteThreshold(id int) (bool, error) {
+	result, err := r.repository.GoquDBWrapper.Delete("stock_thresholds").Where(goqu.Ex{"id": id}).Executor().Exec()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete stock threshold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *StockThresholdRepository) getThresholdQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"t.id",
+		"t.item_category_id",
+		goqu.I("c.label").As("category_label"),
+		"t.location_id",
+		goqu.I("l.name").As("location_name"),
+		"t.min_quantity",
+		"t.updated_at",
+	).
+		From(goqu.T("stock_thresholds").As("t")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"t.item_category_id": goqu.I("c.id")})).
+		LeftJoin(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"t.location_id": goqu.I("l.id")}))
+}
@@ -0,0 +1,12 @@
+package stockthresholds
+
+type SetThresholdRequest struct {
+	CategoryID  int  `json:"category_id" binding:"required"`
+	LocationID  *int `json:"location_id"`
+	MinQuantity int  `json:"min_quantity" binding:"min=0"`
+}
+
+type RetrieveThresholdListQuery struct {
+	CategoryID int `form:"category_id"`
+	LocationID int `form:"location_id"`
+}
@@ -0,0 +1,99 @@
+package stockthresholds
+
+import (
+	"errors"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/models"
+)
+
+var (
+	ErrThresholdNotFound = errors.New("stock threshold not found")
+	ErrNotStockCategory  = errors.New("thresholds can only be set for stock categories")
+)
+
+type StockThresholdService struct {
+	r         *repository.Repository
+	tr        *StockThresholdRepository
+	stockRepo *stocks.StockRepository
+	a         *auditlog.Auditlog
+}
+
+func NewService(r *repository.Repository, tr *StockThresholdRepository, sr *stocks.StockRepository, a *auditlog.Auditlog) *StockThresholdService {
+	return &StockThresholdService{
+		r:         r,
+		tr:        tr,
+		stockRepo: sr,
+		a:         a,
+	}
+}
+
+func (s *StockThresholdService) SetThreshold(req SetThresholdRequest) (*models.StockThreshold, error) {
+	categoryType, err := s.r.GetCategoryType(req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if categoryType != "stock" {
+		return nil, ErrNotStockCategory
+	}
+
+	id, err := s.tr.UpsertThreshold(req)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold, err := s.GetThreshold(id)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(
+		"update",
+		map[string]interface{}{
+			"category_id":  req.CategoryID,
+			"location_id":  req.LocationID,
+			"min_quantity": req.MinQuantity,
+			"msg":          "Ustawiono minimalny stan",
+		},
+		threshold,
+	)
+
+	return threshold, nil
+}
+
+func (s *StockThresholdService) GetThreshold(id int) (*models.StockThreshold, error) {
+	threshold, err := s.tr.GetThreshold(id)
+	if err != nil {
+		return nil, err
+	}
+	if threshold == nil {
+		return nil, ErrThresholdNotFound
+	}
+
+	return threshold, nil
+}
+
+func (s *StockThresholdService) GetThresholds(query RetrieveThresholdListQuery) ([]models.StockThreshold, error) {
+	return s.tr.GetThresholds(query)
+}
+
+func (s *StockThresholdService) DeleteThreshold(id int) error {
+	threshold, err := s.GetThreshold(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.tr.DeleteThreshold(id); err != nil {
+		return err
+	}
+
+	go s.a.Log("delete", map[string]interface{}{"msg": "Usunięto minimalny stan"}, threshold)
+
+	return nil
+}
+
+// GetLowStock lists stock below its minimum level
+func (s *StockThresholdService) GetLowStock() ([]models.StockLevel, error) {
+	return s.stockRepo.GetLowStockLevels()
+}
@@ -0,0 +1,394 @@
+package transfers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"warehouse/internal/inventory/retirements"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrEmptyDelivery        = errors.New("delivery confirmation needs assets or stocks")
+	ErrDeliveryLineNotFound = errors.New("line is not part of the transfer")
+	ErrDeliveryLineResolved = errors.New("line is already resolved")
+	ErrReceivedQuantity     = errors.New("received quantity exceeds quantity still in transit")
+	ErrWriteOffNotMissing   = errors.New("only lines confirmed as missing can be written off")
+)
+
+// ConfirmTransfer receives everything still in transit or missing
+func (s *TransferService) ConfirmTransfer(transferID int) error {
+	return s.confirmDelivery(transferID, nil)
+}
+
+// ConfirmDelivery receives given lines, unreceived assets and short stock lines become missing
+// and the transfer stays partially delivered until all of its lines are resolved
+func (s *TransferService) ConfirmDelivery(transferID int, req ConfirmDeliveryRequest) error {
+	if len(req.Assets) == 0 && len(req.Stocks) == 0 {
+		return ErrEmptyDelivery
+	}
+	return s.confirmDelivery(transferID, &req)
+}
+
+func (s *TransferService) confirmDelivery(transferID int, req *ConfirmDeliveryRequest) error {
+	var statusChanges []models.AssetStatusChange
+	var status string
+	var advance *shipmentAdvance
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if status, statusChanges, err = s.receiveDelivery(tx, transferID, req); err != nil {
+			return err
+		}
+		if status == string(metadata.StatusCompleted) {
+			advance, err = s.advanceShipment(tx, transferID)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	action := "delivered"
+	if status == string(metadata.StatusPartiallyDelivered) {
+		action = "partially_delivered"
+	}
+	go s.createInventoryLog(action, transferID)
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, transferID)
+	s.logShipmentAdvance(advance)
+
+	return nil
+}
+
+// receiveDelivery applies a confirmation to a transfer, nil confirms everything outstanding. Returns the new transfer status.
+func (s *TransferService) receiveDelivery(tx *goqu.TxDatabase, transferID int, req *ConfirmDeliveryRequest) (string, []models.AssetStatusChange, error) {
+	current, err := s.lockOpenTransfer(tx, transferID, string(metadata.StatusCompleted))
+	if err != nil {
+		return "", nil, err
+	}
+	if current == string(metadata.StatusInTransit) {
+		if missing, err := s.tr.IsDeliveryPhotoMissing(tx, transferID); err != nil {
+			return "", nil, err
+		} else if missing {
+			return "", nil, ErrDeliveryPhotoRequired
+		}
+	}
+
+	assetLines, stockLines, err := s.tr.LockDeliveryLines(tx, transferID)
+	if err != nil {
+		return "", nil, err
+	}
+	if req == nil {
+		req = receiveEverything(assetLines, stockLines)
+	}
+
+	received, missing, err := sortAssetDelivery(assetLines, req.Assets)
+	if err != nil {
+		return "", nil, err
+	}
+	statusChanges, err := s.ar.UpdateItemStatus(received, metadata.StatusLocated, tx)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to update assets err: %w", err)
+	}
+	if err := s.tr.UpdateAssetLinesStatus(tx, transferID, received, models.TransferLineCompleted); err != nil {
+		return "", nil, err
+	}
+	if err := s.tr.UpdateAssetLinesStatus(tx, transferID, missing, models.TransferLineMissing); err != nil {
+		return "", nil, err
+	}
+	applyAssetStatus(assetLines, received, models.TransferLineCompleted)
+	applyAssetStatus(assetLines, missing, models.TransferLineMissing)
+
+	if err := s.receiveStockLines(tx, transferID, stockLines, req.Stocks); err != nil {
+		return "", nil, err
+	}
+
+	status := resolveTransferStatus(assetLines, stockLines)
+	if err := s.tr.UpdateTransferStatus(tx, transferID, status); err != nil {
+		return "", nil, err
+	}
+
+	return status, statusChanges, nil
+}
+
+func (s *TransferService) receiveStockLines(tx *goqu.TxDatabase, transferID int, lines []models.TransferStockLine, deliveries []StockDeliveryRequest) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	toLocationID, err := s.tr.GetTransferLocationById(tx, transferID)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		i := findStockLine(lines, delivery.LineID)
+		if i < 0 {
+			return fmt.Errorf("%w: stock line %d", ErrDeliveryLineNotFound, delivery.LineID)
+		}
+
+		line, err := receiveStockLine(lines[i], delivery.ReceivedQuantity)
+		if err != nil {
+			return err
+		}
+
+		if delivery.ReceivedQuantity > 0 {
+			origin := ""
+			if line.Origin != nil {
+				origin = *line.Origin
+			}
+			_, err := s.stockRepo.AddStockQuantity(tx, stocks.StockItemRequest{
+				CategoryID: line.CategoryID,
+				LocationID: toLocationID,
+				Quantity:   delivery.ReceivedQuantity,
+				Origin:     origin,
+			}, models.AdjustmentReasonTransfer)
+			if err != nil {
+				return fmt.Errorf("failed to increase stock items at destination: %w", err)
+			}
+		}
+
+		if err := s.tr.UpdateStockLine(tx, line); err != nil {
+			return err
+		}
+		lines[i] = line
+	}
+
+	return nil
+}
+
+// WriteOffDelivery resolves missing lines for good, missing stock is dropped and missing assets are retired as lost
+func (s *TransferService) WriteOffDelivery(transferID int, req WriteOffDeliveryRequest) error {
+	if len(req.Assets) == 0 && len(req.Stocks) == 0 {
+		return ErrEmptyDelivery
+	}
+
+	transfer, err := s.tr.GetTransferRow(transferID)
+	if err != nil {
+		return err
+	}
+
+	var statusChanges []models.AssetStatusChange
+	var advance *shipmentAdvance
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		if err := s.lockTransferInStatus(tx, transferID, string(metadata.StatusPartiallyDelivered), string(metadata.StatusCompleted)); err != nil {
+			return err
+		}
+
+		assetLines, stockLines, err := s.tr.LockDeliveryLines(tx, transferID)
+		if err != nil {
+			return err
+		}
+
+		for _, assetID := range req.Assets {
+			i := findAssetLine(assetLines, assetID)
+			if i < 0 {
+				return fmt.Errorf("%w: asset %d", ErrDeliveryLineNotFound, assetID)
+			}
+			if assetLines[i].Status != models.TransferLineMissing {
+				return fmt.Errorf("%w: asset %d", ErrWriteOffNotMissing, assetID)
+			}
+
+			changes, err := s.retireLostAsset(tx, assetID, transfer.FromLocationID, req)
+			if err != nil {
+				return err
+			}
+			statusChanges = append(statusChanges, changes...)
+		}
+		if err := s.tr.UpdateAssetLinesStatus(tx, transferID, req.Assets, models.TransferLineWrittenOff); err != nil {
+			return err
+		}
+		applyAssetStatus(assetLines, req.Assets, models.TransferLineWrittenOff)
+
+		for _, lineID := range req.Stocks {
+			i := findStockLine(stockLines, lineID)
+			if i < 0 {
+				return fmt.Errorf("%w: stock line %d", ErrDeliveryLineNotFound, lineID)
+			}
+
+			line, err := writeOffStockLine(stockLines[i])
+			if err != nil {
+				return err
+			}
+			if err := s.tr.UpdateStockLine(tx, line); err != nil {
+				return err
+			}
+			stockLines[i] = line
+		}
+
+		status := resolveTransferStatus(assetLines, stockLines)
+		if err := s.tr.UpdateTransferStatus(tx, transferID, status); err != nil {
+			return err
+		}
+		if status == string(metadata.StatusCompleted) {
+			advance, err = s.advanceShipment(tx, transferID)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.createInventoryLog("delivery_written_off", transferID)
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, transferID)
+	s.logShipmentAdvance(advance)
+
+	return nil
+}
+
+// retireLostAsset retires an asset lost on the way. The lifecycle does not let an asset retire while in transit,
+// so it is first put back where it was last accounted for.
+func (s *TransferService) retireLostAsset(tx *goqu.TxDatabase, assetID int, fromLocationID int, req WriteOffDeliveryRequest) ([]models.AssetStatusChange, error) {
+	restored, err := s.ar.UpdateAssetStatusAndLocation(tx, assetID, fromLocationID, models.AssetStatusAtLocation(fromLocationID))
+	if err != nil {
+		return nil, err
+	}
+	retired, err := s.ar.UpdateItemStatus([]int{assetID}, metadata.StatusRetired, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.rtr.SetRetirement(tx, retirements.RetireAssetRequest{
+		ID:          assetID,
+		Reason:      models.RetirementReasonLost,
+		Notes:       req.Notes,
+		RetiredByID: req.WrittenByID,
+	}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.kr.RemoveAssetFromKits(tx, assetID); err != nil {
+		return nil, err
+	}
+
+	return append([]models.AssetStatusChange{*restored}, retired...), nil
+}
+
+// lockOpenTransfer locks a transfer that is in transit or partially delivered and returns its status
+func (s *TransferService) lockOpenTransfer(tx *goqu.TxDatabase, transferID int, next string) (string, error) {
+	current, err := s.tr.LockTransferStatus(tx, transferID)
+	if err != nil {
+		return "", err
+	}
+	if current != string(metadata.StatusInTransit) && current != string(metadata.StatusPartiallyDelivered) {
+		return "", custom_error.NewInvalidStatusTransitionError("transfer", transferID, current, next)
+	}
+
+	return current, nil
+}
+
+// receiveEverything builds a confirmation receiving all unresolved lines in full
+func receiveEverything(assetLines []models.TransferAssetLine, stockLines []models.TransferStockLine) *ConfirmDeliveryRequest {
+	req := &ConfirmDeliveryRequest{}
+	for _, line := range assetLines {
+		if isOpenLine(line.Status) {
+			req.Assets = append(req.Assets, AssetDeliveryRequest{ID: line.AssetID, Received: true})
+		}
+	}
+	for _, line := range stockLines {
+		if isOpenLine(line.Status) {
+			req.Stocks = append(req.Stocks, StockDeliveryRequest{LineID: line.ID, ReceivedQuantity: line.Outstanding()})
+		}
+	}
+	return req
+}
+
+// sortAssetDelivery splits confirmed assets into received and missing ones, an asset already missing stays missing
+func sortAssetDelivery(lines []models.TransferAssetLine, deliveries []AssetDeliveryRequest) ([]int, []int, error) {
+	var received, missing []int
+	for _, delivery := range deliveries {
+		i := findAssetLine(lines, delivery.ID)
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%w: asset %d", ErrDeliveryLineNotFound, delivery.ID)
+		}
+		if !isOpenLine(lines[i].Status) {
+			return nil, nil, fmt.Errorf("%w: asset %d is %s", ErrDeliveryLineResolved, delivery.ID, lines[i].Status)
+		}
+
+		if delivery.Received {
+			received = append(received, delivery.ID)
+		} else if lines[i].Status == models.TransferLineInTransit {
+			missing = append(missing, delivery.ID)
+		}
+	}
+	return received, missing, nil
+}
+
+// receiveStockLine books a received quantity on a stock line, whatever is still outstanding afterwards is missing
+func receiveStockLine(line models.TransferStockLine, quantity int) (models.TransferStockLine, error) {
+	if !isOpenLine(line.Status) {
+		return line, fmt.Errorf("%w: stock line %d is %s", ErrDeliveryLineResolved, line.ID, line.Status)
+	}
+	if quantity > line.Outstanding() {
+		return line, fmt.Errorf("%w: stock line %d has %d outstanding", ErrReceivedQuantity, line.ID, line.Outstanding())
+	}
+
+	line.ReceivedQuantity += quantity
+	line.Status = models.TransferLineMissing
+	if line.Outstanding() == 0 {
+		line.Status = models.TransferLineCompleted
+	}
+	return line, nil
+}
+
+func writeOffStockLine(line models.TransferStockLine) (models.TransferStockLine, error) {
+	if line.Status != models.TransferLineMissing {
+		return line, fmt.Errorf("%w: stock line %d", ErrWriteOffNotMissing, line.ID)
+	}
+
+	line.WrittenOffQuantity += line.Outstanding()
+	line.Status = models.TransferLineCompleted
+	return line, nil
+}
+
+// resolveTransferStatus completes a transfer once none of its lines is in transit or missing
+func resolveTransferStatus(assetLines []models.TransferAssetLine, stockLines []models.TransferStockLine) string {
+	for _, line := range assetLines {
+		if isOpenLine(line.Status) {
+			return string(metadata.StatusPartiallyDelivered)
+		}
+	}
+	for _, line := range stockLines {
+		if isOpenLine(line.Status) {
+			return string(metadata.StatusPartiallyDelivered)
+		}
+	}
+	return string(metadata.StatusCompleted)
+}
+
+func isOpenLine(status string) bool {
+	return status == models.TransferLineInTransit || status == models.TransferLineMissing
+}
+
+func applyAssetStatus(lines []models.TransferAssetLine, assetIDs []int, status string) {
+	for _, assetID := range assetIDs {
+		if i := findAssetLine(lines, assetID); i >= 0 {
+			lines[i].Status = status
+		}
+	}
+}
+
+func findAssetLine(lines []models.TransferAssetLine, assetID int) int {
+	for i, line := range lines {
+		if line.AssetID == assetID {
+			return i
+		}
+	}
+	return -1
+}
+
+func findStockLine(lines []models.TransferStockLine, lineID int) int {
+	for i, line := range lines {
+		if line.ID == lineID {
+			return i
+		}
+	}
+	return -1
+}
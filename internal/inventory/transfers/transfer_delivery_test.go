@@ -0,0 +1,97 @@
+package transfers
+
+import (
+	"testing"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiveStockLineShortLeavesLineMissing(t *testing.T) {
+	line := models.TransferStockLine{ID: 1, Quantity: 10, Status: models.TransferLineInTransit}
+
+	line, err := receiveStockLine(line, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, line.ReceivedQuantity)
+	assert.Equal(t, 3, line.Outstanding())
+	assert.Equal(t, models.TransferLineMissing, line.Status)
+}
+
+func TestReceiveStockLineRestCompletesLine(t *testing.T) {
+	line := models.TransferStockLine{ID: 1, Quantity: 10, ReceivedQuantity: 7, Status: models.TransferLineMissing}
+
+	line, err := receiveStockLine(line, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, line.ReceivedQuantity)
+	assert.Equal(t, models.TransferLineCompleted, line.Status)
+}
+
+func TestReceiveStockLineRejectsMoreThanOutstanding(t *testing.T) {
+	line := models.TransferStockLine{ID: 1, Quantity: 10, ReceivedQuantity: 8, Status: models.TransferLineMissing}
+
+	_, err := receiveStockLine(line, 3)
+
+	assert.ErrorIs(t, err, ErrReceivedQuantity)
+}
+
+func TestReceiveStockLineRejectsResolvedLine(t *testing.T) {
+	line := models.TransferStockLine{ID: 1, Quantity: 10, ReceivedQuantity: 10, Status: models.TransferLineCompleted}
+
+	_, err := receiveStockLine(line, 0)
+
+	assert.ErrorIs(t, err, ErrDeliveryLineResolved)
+}
+
+func TestWriteOffStockLineOnlyMissing(t *testing.T) {
+	line := models.TransferStockLine{ID: 1, Quantity: 10, ReceivedQuantity: 6, Status: models.TransferLineMissing}
+
+	line, err := writeOffStockLine(line)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, line.WrittenOffQuantity)
+	assert.Equal(t, 0, line.Outstanding())
+	assert.Equal(t, models.TransferLineCompleted, line.Status)
+
+	_, err = writeOffStockLine(models.TransferStockLine{ID: 2, Quantity: 5, Status: models.TransferLineInTransit})
+	assert.ErrorIs(t, err, ErrWriteOffNotMissing)
+}
+
+func TestResolveTransferStatus(t *testing.T) {
+	resolved := []models.TransferAssetLine{
+		{AssetID: 1, Status: models.TransferLineCompleted},
+		{AssetID: 2, Status: models.TransferLineWrittenOff},
+	}
+	stocks := []models.TransferStockLine{{ID: 1, Status: models.TransferLineCompleted}}
+
+	assert.Equal(t, "completed", resolveTransferStatus(resolved, stocks))
+	assert.Equal(t, "partially_delivered", resolveTransferStatus(
+		append(resolved, models.TransferAssetLine{AssetID: 3, Status: models.TransferLineMissing}), stocks))
+	assert.Equal(t, "partially_delivered", resolveTransferStatus(
+		resolved, append(stocks, models.TransferStockLine{ID: 2, Status: models.TransferLineInTransit})))
+}
+
+func TestSortAssetDelivery(t *testing.T) {
+	lines := []models.TransferAssetLine{
+		{AssetID: 1, Status: models.TransferLineInTransit},
+		{AssetID: 2, Status: models.TransferLineInTransit},
+		{AssetID: 3, Status: models.TransferLineMissing},
+		{AssetID: 4, Status: models.TransferLineCompleted},
+	}
+
+	received, missing, err := sortAssetDelivery(lines, []AssetDeliveryRequest{
+		{ID: 1, Received: true},
+		{ID: 2, Received: false},
+		{ID: 3, Received: false},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, received)
+	assert.Equal(t, []int{2}, missing)
+
+	_, _, err = sortAssetDelivery(lines, []AssetDeliveryRequest{{ID: 4, Received: true}})
+	assert.ErrorIs(t, err, ErrDeliveryLineResolved)
+
+	_, _, err = sortAssetDelivery(lines, []AssetDeliveryRequest{{ID: 9, Received: true}})
+	assert.ErrorIs(t, err, ErrDeliveryLineNotFound)
+}
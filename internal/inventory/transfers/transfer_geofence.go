@@ -0,0 +1,79 @@
+package transfers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+	"warehouse/pkg/models"
+)
+
+var (
+	ErrInvalidCoordinates       = errors.New("latitude has to be within -90 and 90 and longitude within -180 and 180")
+	ErrInvalidDeliveryTimestamp = errors.New("delivery timestamp is missing or lies in the future")
+)
+
+const (
+	earthRadiusMeters     = 6371000.0
+	defaultGeofenceRadius = 500
+	// deliveryClockSkew tolerates devices whose clock runs slightly ahead of the server
+	deliveryClockSkew = 5 * time.Minute
+)
+
+// validateDeliveryLocation checks coordinate ranges and that the delivery was recorded before now
+func validateDeliveryLocation(location models.DeliveryLocation, now time.Time) error {
+	if math.IsNaN(location.Lat) || math.IsNaN(location.Lng) ||
+		location.Lat < -90 || location.Lat > 90 || location.Lng < -180 || location.Lng > 180 {
+		return ErrInvalidCoordinates
+	}
+	if location.Timestamp.IsZero() || location.Timestamp.After(now.Add(deliveryClockSkew)) {
+		return ErrInvalidDeliveryTimestamp
+	}
+	return nil
+}
+
+// distanceMeters is the great circle distance between two points
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(math.Min(1, a)))
+}
+
+// applyGeofence measures the distance of a delivery from its destination and flags it when it lies outside
+// the radius of the destination, or the default one. Destinations without coordinates are not checked.
+func applyGeofence(location models.DeliveryLocation, destination models.Location, defaultRadius int) models.DeliveryLocation {
+	location.Distance = nil
+	location.OutsideGeofence = false
+	if !destination.HasCoordinates() {
+		return location
+	}
+
+	distance := int(math.Round(distanceMeters(location.Lat, location.Lng, *destination.Latitude, *destination.Longitude)))
+	radius := defaultRadius
+	if destination.GeofenceRadius != nil {
+		radius = *destination.GeofenceRadius
+	}
+
+	location.Distance = &distance
+	location.OutsideGeofence = distance > radius
+	return location
+}
+
+// deliveryGeofenceRadius reads DELIVERY_GEOFENCE_RADIUS in meters, used for destinations without their own radius
+func deliveryGeofenceRadius() int {
+	if value := os.Getenv("DELIVERY_GEOFENCE_RADIUS"); value != "" {
+		if radius, err := strconv.Atoi(value); err == nil && radius > 0 {
+			return radius
+		}
+		log.Printf("Nieprawidłowa wartość DELIVERY_GEOFENCE_RADIUS: %s", value)
+	}
+
+	return defaultGeofenceRadius
+}
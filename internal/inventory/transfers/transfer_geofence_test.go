@@ -0,0 +1,61 @@
+package transfers
+
+import (
+	"math"
+	"testing"
+	"time"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestValidateDeliveryLocation(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, validateDeliveryLocation(models.DeliveryLocation{Lat: 52.23, Lng: 21.01, Timestamp: now.Add(-time.Hour)}, now))
+	assert.NoError(t, validateDeliveryLocation(models.DeliveryLocation{Lat: -90, Lng: 180, Timestamp: now.Add(time.Minute)}, now))
+
+	assert.ErrorIs(t, validateDeliveryLocation(models.DeliveryLocation{Lat: 91, Lng: 21, Timestamp: now}, now), ErrInvalidCoordinates)
+	assert.ErrorIs(t, validateDeliveryLocation(models.DeliveryLocation{Lat: 52, Lng: -181, Timestamp: now}, now), ErrInvalidCoordinates)
+	assert.ErrorIs(t, validateDeliveryLocation(models.DeliveryLocation{Lat: math.NaN(), Lng: 21, Timestamp: now}, now), ErrInvalidCoordinates)
+	assert.ErrorIs(t, validateDeliveryLocation(models.DeliveryLocation{Lat: 52, Lng: 21}, now), ErrInvalidDeliveryTimestamp)
+	assert.ErrorIs(t, validateDeliveryLocation(models.DeliveryLocation{Lat: 52, Lng: 21, Timestamp: now.Add(time.Hour)}, now), ErrInvalidDeliveryTimestamp)
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// Warszawa - Kraków
+	assert.InDelta(t, 252000, distanceMeters(52.2297, 21.0122, 50.0647, 19.9450), 2000)
+	assert.Zero(t, distanceMeters(52.2297, 21.0122, 52.2297, 21.0122))
+}
+
+func TestApplyGeofence(t *testing.T) {
+	destination := models.Location{ID: 2, Latitude: floatPtr(52.2297), Longitude: floatPtr(21.0122)}
+	near := models.DeliveryLocation{Lat: 52.2300, Lng: 21.0125}
+	far := models.DeliveryLocation{Lat: 52.2400, Lng: 21.0122}
+
+	location := applyGeofence(near, destination, 500)
+	assert.NotNil(t, location.Distance)
+	assert.False(t, location.OutsideGeofence)
+
+	location = applyGeofence(far, destination, 500)
+	assert.InDelta(t, 1145, *location.Distance, 10)
+	assert.True(t, location.OutsideGeofence)
+
+	destination.GeofenceRadius = intPtr(2000)
+	assert.False(t, applyGeofence(far, destination, 500).OutsideGeofence)
+}
+
+func TestApplyGeofenceSkipsDestinationWithoutCoordinates(t *testing.T) {
+	location := applyGeofence(models.DeliveryLocation{Lat: 10, Lng: 10, OutsideGeofence: true}, models.Location{ID: 2}, 500)
+
+	assert.Nil(t, location.Distance)
+	assert.False(t, location.OutsideGeofence)
+}
@@ -0,0 +1,262 @@
+package transfers
+
+import (
+	"fmt"
+	"sort"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// expandKits adds components of transferred kit parents to the request. Components which cannot leave
+// the source location are skipped, the transfer view reports such kits as incomplete.
+func (s *TransferService) expandKits(tx *goqu.TxDatabase, req *models.TransferRequest) error {
+	parentIDs := mapToIDArray(req.AssetItemCollection)
+	if len(parentIDs) == 0 {
+		return nil
+	}
+
+	components, err := s.kr.GetKitComponents(parentIDs)
+	if err != nil {
+		return err
+	}
+	stockComponents, err := s.kr.GetKitStockComponents(parentIDs)
+	if err != nil {
+		return err
+	}
+
+	requested := make(map[int]bool, len(parentIDs))
+	for _, id := range parentIDs {
+		requested[id] = true
+	}
+	for _, component := range components {
+		if requested[component.AssetID] || component.LocationID != req.FromLocationID {
+			continue
+		}
+		if !metadata.Status(component.Status).CanTransitionTo(metadata.StatusInTransit) {
+			continue
+		}
+		req.AssetItemCollection = append(req.AssetItemCollection, models.AssetItemRequest{ID: component.AssetID})
+		requested[component.AssetID] = true
+	}
+
+	for _, component := range stockComponents {
+		stock, err := s.stockRepo.LockLargestStock(tx, component.CategoryID, req.FromLocationID)
+		if err != nil {
+			return err
+		}
+		if stock == nil {
+			continue
+		}
+
+		i := findStockRequest(req.StockItemCollection, stock.ID)
+		if i < 0 {
+			if stock.Quantity >= component.Quantity {
+				req.StockItemCollection = append(req.StockItemCollection, models.StockItemRequest{ID: stock.ID, Quantity: component.Quantity})
+			}
+			continue
+		}
+		if stock.Quantity >= req.StockItemCollection[i].Quantity+component.Quantity {
+			req.StockItemCollection[i].Quantity += component.Quantity
+		}
+	}
+
+	return nil
+}
+
+// detachKitComponents restores components travelling with a kit parent removed from the transfer
+func (s *TransferService) detachKitComponents(tx *goqu.TxDatabase, req RemoveItemFromTransferRequest) ([]models.AssetStatusChange, error) {
+	components, err := s.kr.GetKitComponents([]int{req.ItemID})
+	if err != nil {
+		return nil, err
+	}
+	stockComponents, err := s.kr.GetKitStockComponents([]int{req.ItemID})
+	if err != nil {
+		return nil, err
+	}
+	if len(components) == 0 && len(stockComponents) == 0 {
+		return nil, nil
+	}
+
+	transferAssets, err := s.ar.GetTransferAssets(req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer assets: %w", err)
+	}
+	inTransfer := make(map[int]bool, len(*transferAssets))
+	for _, asset := range *transferAssets {
+		inTransfer[asset.ID] = true
+	}
+
+	var changes []models.AssetStatusChange
+	for _, component := range components {
+		if !inTransfer[component.AssetID] {
+			continue
+		}
+		change, err := s.ar.DetachAssetFromTransfer(tx, req.ID, component.AssetID, req.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+
+	if len(stockComponents) == 0 {
+		return changes, nil
+	}
+
+	transferStocks, err := s.stockRepo.GetStockItemsByTransfer(req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer stock items: %w", err)
+	}
+	inTransit := make(map[int]int)
+	for _, stock := range *transferStocks {
+		inTransit[stock.Category.ID] += stock.Quantity
+	}
+
+	for _, component := range stockComponents {
+		quantity := min(component.Quantity, inTransit[component.CategoryID])
+		if quantity == 0 {
+			continue
+		}
+
+		release := stocks.RemoveStockItemFromTransferRequest{
+			CategoryID:   component.CategoryID,
+			TransferID:   req.ID,
+			Quantity:     quantity,
+			ToLocationID: req.LocationID,
+		}
+		if release.Origin, err = decreaseStockInTransfer(tx, release); err != nil {
+			return nil, err
+		}
+		if err := s.stockRepo.RemoveZeroQuantityStock(tx, release); err != nil {
+			return nil, err
+		}
+		if err := s.stockRepo.RestoreStockToLocation(tx, release); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *TransferService) getKitWarnings(transfer *models.Transfer) ([]models.KitWarning, error) {
+	var stage string
+	switch transfer.Status {
+	case string(metadata.StatusInTransit):
+		stage = models.KitWarningStageDispatch
+	case string(metadata.StatusPartiallyDelivered), string(metadata.StatusCompleted):
+		stage = models.KitWarningStageDelivery
+	default:
+		return nil, nil
+	}
+
+	assetIDs := make([]int, len(transfer.AssetsCollection))
+	for i, asset := range transfer.AssetsCollection {
+		assetIDs[i] = asset.ID
+	}
+	if len(assetIDs) == 0 {
+		return nil, nil
+	}
+
+	memberships, err := s.kr.GetKitMemberships(assetIDs)
+	if err != nil {
+		return nil, err
+	}
+	kitIDs := append([]int{}, assetIDs...)
+	for _, membership := range memberships {
+		kitIDs = append(kitIDs, membership.KitID)
+	}
+
+	components, err := s.kr.GetKitComponents(kitIDs)
+	if err != nil {
+		return nil, err
+	}
+	stockComponents, err := s.kr.GetKitStockComponents(kitIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildKitWarnings(stage, transfer.AssetsCollection, transfer.StockItemsCollection, components, stockComponents), nil
+}
+
+// buildKitWarnings compares kit definitions with transfer contents, stock is assigned to kits in order of their IDs
+func buildKitWarnings(stage string, assets []models.Asset, stockItems []models.StockItem, components []models.KitComponent, stockComponents []models.KitStockComponent) []models.KitWarning {
+	transferred := make(map[int]string, len(assets))
+	for _, asset := range assets {
+		transferred[asset.ID] = asset.PyrCode
+	}
+	available := make(map[int]int)
+	for _, stock := range stockItems {
+		available[stock.Category.ID] += stock.Quantity
+	}
+
+	kitComponents := make(map[int][]models.KitComponent)
+	kitStocks := make(map[int][]models.KitStockComponent)
+	kitPyrCodes := make(map[int]string)
+	for _, component := range components {
+		kitComponents[component.KitID] = append(kitComponents[component.KitID], component)
+		if component.KitPyrCode != nil {
+			kitPyrCodes[component.KitID] = *component.KitPyrCode
+		}
+	}
+	for _, component := range stockComponents {
+		kitStocks[component.KitID] = append(kitStocks[component.KitID], component)
+	}
+
+	kitIDs := make([]int, 0, len(kitComponents)+len(kitStocks))
+	for kitID := range kitComponents {
+		kitIDs = append(kitIDs, kitID)
+	}
+	for kitID := range kitStocks {
+		if _, ok := kitComponents[kitID]; !ok {
+			kitIDs = append(kitIDs, kitID)
+		}
+	}
+	sort.Ints(kitIDs)
+
+	var warnings []models.KitWarning
+	for _, kitID := range kitIDs {
+		pyrCode, parentPresent := transferred[kitID]
+		if !parentPresent {
+			pyrCode = kitPyrCodes[kitID]
+		}
+
+		warning := models.KitWarning{
+			KitID:         kitID,
+			PyrCode:       pyrCode,
+			Stage:         stage,
+			ParentMissing: !parentPresent,
+		}
+		for _, component := range kitComponents[kitID] {
+			if _, ok := transferred[component.AssetID]; !ok {
+				warning.MissingAssets = append(warning.MissingAssets, component)
+			}
+		}
+		if parentPresent {
+			for _, component := range kitStocks[kitID] {
+				taken := min(component.Quantity, available[component.CategoryID])
+				available[component.CategoryID] -= taken
+				if taken < component.Quantity {
+					component.Quantity -= taken
+					warning.MissingStocks = append(warning.MissingStocks, component)
+				}
+			}
+		}
+
+		if warning.ParentMissing || len(warning.MissingAssets) > 0 || len(warning.MissingStocks) > 0 {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings
+}
+
+func findStockRequest(requests []models.StockItemRequest, stockID int) int {
+	for i, request := range requests {
+		if request.ID == stockID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,60 @@
+package transfers
+
+import (
+	"testing"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestBuildKitWarningsCompleteKit(t *testing.T) {
+	assets := []models.Asset{{ID: 1, PyrCode: "PYR-P1"}, {ID: 2, PyrCode: "PYR-R1"}}
+	stockItems := []models.StockItem{{Category: models.ItemCategory{ID: 10}, Quantity: 1}}
+	components := []models.KitComponent{{KitID: 1, KitPyrCode: strPtr("PYR-P1"), AssetID: 2}}
+	stockComponents := []models.KitStockComponent{{KitID: 1, CategoryID: 10, Quantity: 1}}
+
+	warnings := buildKitWarnings(models.KitWarningStageDispatch, assets, stockItems, components, stockComponents)
+
+	assert.Empty(t, warnings)
+}
+
+func TestBuildKitWarningsMissingComponents(t *testing.T) {
+	assets := []models.Asset{{ID: 1, PyrCode: "PYR-P1"}, {ID: 5, PyrCode: "PYR-P2"}}
+	stockItems := []models.StockItem{{Category: models.ItemCategory{ID: 10}, Quantity: 3}}
+	components := []models.KitComponent{
+		{KitID: 1, KitPyrCode: strPtr("PYR-P1"), AssetID: 2},
+		{KitID: 5, KitPyrCode: strPtr("PYR-P2"), AssetID: 6},
+	}
+	stockComponents := []models.KitStockComponent{
+		{KitID: 1, CategoryID: 10, Quantity: 2},
+		{KitID: 5, CategoryID: 10, Quantity: 2},
+	}
+
+	warnings := buildKitWarnings(models.KitWarningStageDelivery, assets, stockItems, components, stockComponents)
+
+	assert.Len(t, warnings, 2)
+	assert.Equal(t, 1, warnings[0].KitID)
+	assert.Equal(t, models.KitWarningStageDelivery, warnings[0].Stage)
+	assert.Len(t, warnings[0].MissingAssets, 1)
+	assert.Empty(t, warnings[0].MissingStocks)
+
+	assert.Equal(t, 5, warnings[1].KitID)
+	assert.Len(t, warnings[1].MissingStocks, 1)
+	assert.Equal(t, 1, warnings[1].MissingStocks[0].Quantity)
+}
+
+func TestBuildKitWarningsParentMissing(t *testing.T) {
+	assets := []models.Asset{{ID: 2, PyrCode: "PYR-R1"}}
+	components := []models.KitComponent{{KitID: 1, KitPyrCode: strPtr("PYR-P1"), AssetID: 2}}
+
+	warnings := buildKitWarnings(models.KitWarningStageDispatch, assets, nil, components, nil)
+
+	assert.Len(t, warnings, 1)
+	assert.True(t, warnings[0].ParentMissing)
+	assert.Equal(t, "PYR-P1", warnings[0].PyrCode)
+	assert.Empty(t, warnings[0].MissingAssets)
+}
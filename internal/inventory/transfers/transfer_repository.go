@@ -10,11 +10,14 @@ import (
 	"warehouse/pkg/models"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 )
 
 type TransferRepository interface {
 	CanTransferNonSerializedItems(assets []models.StockItemRequest, locationID int) (map[int]bool, error)
-	UpdateTransferStatus(transferID int, status string) error
+	UpdateTransferStatus(tx *goqu.TxDatabase, transferID int, status string) error
+	LockTransferStatus(tx *goqu.TxDatabase, transferID int) (string, error)
+	IsDeliveryPhotoMissing(tx *goqu.TxDatabase, transferID int) (bool, error)
 	GetTransferRow(transferID int) (*FlatTransfer, error)
 	GetTransferRows(conditions repository.QueryBuilder) (*[]FlatTransfer, error)
 	GetTransfersByUserAndStatus(userID int, status string) ([]FlatTransfer, error)
@@ -27,9 +30,20 @@ type TransferRepository interface {
 	HasStockItemsInTransfer(tx *goqu.TxDatabase, transferID int) (bool, error)
 	InsertTransferUsers(tx *goqu.TxDatabase, transferID int, users []models.TransferUser) error
 	GetTransferUsers(transferID int) ([]models.User, error)
-	UpdateDeliveryLocation(transferID int, latitude float64, longitude float64, timestamp time.Time) error
+	UpdateDeliveryLocation(transferID int, location models.DeliveryLocation) error
 	UpdateStockItemsTransferStatus(tx *goqu.TxDatabase, transferID int, status string) error
+	UpdateAssetsTransferStatus(tx *goqu.TxDatabase, transferID int, status string) error
+	GetDeliveryLines(transferID int) ([]models.TransferAssetLine, []models.TransferStockLine, error)
+	LockDeliveryLines(tx *goqu.TxDatabase, transferID int) ([]models.TransferAssetLine, []models.TransferStockLine, error)
+	UpdateAssetLinesStatus(tx *goqu.TxDatabase, transferID int, assetIDs []int, status string) error
+	UpdateStockLine(tx *goqu.TxDatabase, line models.TransferStockLine) error
 	SetTransferUsers(transferID int, userIDs []int) error
+	GetReturnableAssets(transferID int, locationID int) ([]ReturnableAsset, error)
+	GetReturnableStock(transferID int, locationID int) ([]ReturnableStock, error)
+	GetRecallableAssets(locationID int) ([]ReturnableAsset, error)
+	GetRecallableStock(locationID int) ([]ReturnableStock, error)
+	GetActiveReturnID(transferID int) (int, error)
+	LockReturnedTransfer(tx *goqu.TxDatabase, transferID int) (string, int, error)
 }
 
 type transferRepository struct {
@@ -78,18 +92,26 @@ func (r *transferRepository) CanTransferNonSerializedItems(stocks []models.Stock
 }
 
 type FlatTransfer struct {
-	ID                   int            `db:"transfer_id"`
-	FromLocationID       int            `db:"from_location_id"`
-	FromLocationName     string         `db:"from_location_name"`
-	FromLocationPavilion sql.NullString `db:"from_location_pavilion"`
-	ToLocationID         int            `db:"to_location_id"`
-	ToLocationName       string         `db:"to_location_name"`
-	ToLocationPavilion   sql.NullString `db:"to_location_pavilion"`
-	TransferDate         time.Time      `db:"transfer_date"`
-	Status               string         `db:"transfer_status"`
-	DeliveryLatitude     *float64       `db:"delivery_latitude"`
-	DeliveryLongitude    *float64       `db:"delivery_longitude"`
-	DeliveryTimestamp    *time.Time     `db:"delivery_timestamp"`
+	ID                    int            `db:"transfer_id"`
+	FromLocationID        int            `db:"from_location_id"`
+	FromLocationName      string         `db:"from_location_name"`
+	FromLocationPavilion  sql.NullString `db:"from_location_pavilion"`
+	ToLocationID          int            `db:"to_location_id"`
+	ToLocationName        string         `db:"to_location_name"`
+	ToLocationPavilion    sql.NullString `db:"to_location_pavilion"`
+	TransferDate          time.Time      `db:"transfer_date"`
+	Status                string         `db:"transfer_status"`
+	DeliveryLatitude      *float64       `db:"delivery_latitude"`
+	DeliveryLongitude     *float64       `db:"delivery_longitude"`
+	DeliveryTimestamp     *time.Time     `db:"delivery_timestamp"`
+	RequiresDeliveryPhoto bool           `db:"requires_delivery_photo"`
+	// Set only by GetTransferRow
+	ToLocationLatitude       *float64 `db:"to_location_latitude"`
+	ToLocationLongitude      *float64 `db:"to_location_longitude"`
+	ToLocationGeofenceRadius *int     `db:"to_location_geofence_radius"`
+	DeliveryDistance         *int     `db:"delivery_distance"`
+	DeliveryOutsideGeofence  bool     `db:"delivery_outside_geofence"`
+	ReturnOfTransferID       *int     `db:"return_of_transfer_id"`
 }
 
 func (r *transferRepository) GetTransferRow(transferID int) (*FlatTransfer, error) {
@@ -109,6 +131,13 @@ func (r *transferRepository) GetTransferRow(transferID int) (*FlatTransfer, erro
 			goqu.I("t.delivery_latitude").As("delivery_latitude"),
 			goqu.I("t.delivery_longitude").As("delivery_longitude"),
 			goqu.I("t.delivery_timestamp").As("delivery_timestamp"),
+			goqu.I("t.requires_delivery_photo").As("requires_delivery_photo"),
+			goqu.I("l2.latitude").As("to_location_latitude"),
+			goqu.I("l2.longitude").As("to_location_longitude"),
+			goqu.I("l2.geofence_radius").As("to_location_geofence_radius"),
+			goqu.I("t.delivery_distance").As("delivery_distance"),
+			goqu.I("t.delivery_outside_geofence").As("delivery_outside_geofence"),
+			goqu.I("t.return_of_transfer_id").As("return_of_transfer_id"),
 		).
 		From(goqu.T("transfers").As("t")).
 		LeftJoin(
@@ -179,9 +208,9 @@ func (r *transferRepository) GetTransferRows(conditions repository.QueryBuilder)
 	return &flatTransfers, nil
 }
 
-func (r *transferRepository) UpdateTransferStatus(transferID int, status string) error {
-	// TODO Transaction + remove transit status (do we really need this status?)
-	query := r.Repo.GoquDBWrapper.
+func (r *transferRepository) UpdateTransferStatus(tx *goqu.TxDatabase, transferID int, status string) error {
+	// TODO remove transit status (do we really need this status?)
+	query := tx.
 		Update("transfers").
 		Set(goqu.Record{
 			"status": status,
@@ -197,6 +226,24 @@ func (r *transferRepository) UpdateTransferStatus(transferID int, status string)
 	return nil
 }
 
+func (r *transferRepository) LockTransferStatus(tx *goqu.TxDatabase, transferID int) (string, error) {
+	var status string
+	found, err := tx.Select("status").
+		From("transfers").
+		Where(goqu.Ex{"id": transferID}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanVal(&status)
+	if err != nil {
+		return "", fmt.Errorf("failed to lock transfer %d: %w", transferID, err)
+	}
+	if !found {
+		return "", fmt.Errorf("no transfer found with id: %d", transferID)
+	}
+
+	return status, nil
+}
+
 func (r *transferRepository) MoveAssets(tx *goqu.TxDatabase, assets []int, locationID int, transitStatus string) error {
 	locationCase := goqu.Case()
 	transitStatusCase := goqu.Case()
@@ -217,15 +264,17 @@ func (r *transferRepository) MoveAssets(tx *goqu.TxDatabase, assets []int, locat
 		return fmt.Errorf("failed to update serialized assets: %w", err)
 	}
 
-	return nil
+	return r.Repo.RecordAssetHistory(tx, assets)
 }
 
 func (r *transferRepository) InsertTransferRecord(tx *goqu.TxDatabase, req models.TransferRequest) (int, error) {
 	query := tx.Insert("transfers").
 		Rows(goqu.Record{
-			"from_location_id": req.FromLocationID,
-			"to_location_id":   req.LocationID,
-			"status":           "in_transit",
+			"from_location_id":        req.FromLocationID,
+			"to_location_id":          req.LocationID,
+			"status":                  "in_transit",
+			"requires_delivery_photo": req.RequireDeliveryPhoto,
+			"return_of_transfer_id":   req.ReturnOfTransferID,
 		}).
 		Returning("id")
 
@@ -237,6 +286,32 @@ func (r *transferRepository) InsertTransferRecord(tx *goqu.TxDatabase, req model
 	return transferID, nil
 }
 
+// IsDeliveryPhotoMissing reports transfers requiring a delivery photo which has not been uploaded yet
+func (r *transferRepository) IsDeliveryPhotoMissing(tx *goqu.TxDatabase, transferID int) (bool, error) {
+	photos := tx.From("attachments").
+		Select(goqu.L("1")).
+		Where(
+			goqu.Ex{
+				"resource_type": models.AttachmentResourceTransfer,
+				"resource_id":   transferID,
+				"kind":          models.AttachmentKindDeliveryProof,
+			},
+			goqu.C("content_type").Like("image/%"),
+		)
+
+	var missing bool
+	_, err := tx.From("transfers").
+		Select(goqu.L("requires_delivery_photo AND NOT EXISTS ?", photos)).
+		Where(goqu.Ex{"id": transferID}).
+		Executor().
+		ScanVal(&missing)
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivery photo: %w", err)
+	}
+
+	return missing, nil
+}
+
 func (r *transferRepository) InsertAssetsTransferRecord(tx *goqu.TxDatabase, transferID int, assets []int) error {
 	var records []goqu.Record
 	for _, itemID := range assets {
@@ -319,29 +394,28 @@ func (r *transferRepository) GetTransferLocationById(tx *goqu.TxDatabase, transf
 	return locationId, nil
 }
 
-func decreaseStockInTransfer(tx *goqu.TxDatabase, transferReq stocks.RemoveStockItemFromTransferRequest) error {
-	updateResult, err := tx.Update("non_serialized_transfers").
+// decreaseStockInTransfer lowers the transfer line of a category and returns its origin
+func decreaseStockInTransfer(tx *goqu.TxDatabase, transferReq stocks.RemoveStockItemFromTransferRequest) (string, error) {
+	var origins []sql.NullString
+	err := tx.Update("non_serialized_transfers").
 		Set(goqu.Record{"quantity": goqu.L("quantity - ?", transferReq.Quantity)}).
 		Where(goqu.Ex{
 			"transfer_id":      transferReq.TransferID,
 			"item_category_id": transferReq.CategoryID,
 		}).
 		Where(goqu.C("quantity").Gte(transferReq.Quantity)).
+		Returning("origin").
 		Executor().
-		Exec()
+		ScanVals(&origins)
 	if err != nil {
-		return fmt.Errorf("failed to lower stock from transfer %d: %w", transferReq.TransferID, err)
+		return "", fmt.Errorf("failed to lower stock from transfer %d: %w", transferReq.TransferID, err)
 	}
 
-	rowsAffected, err := updateResult.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("insufficient stock for item_category_id %d at location ", transferReq.CategoryID)
+	if len(origins) == 0 {
+		return "", fmt.Errorf("insufficient stock for item_category_id %d at location ", transferReq.CategoryID)
 	}
 
-	return nil
+	return origins[0].String, nil
 }
 
 func (r *transferRepository) InsertTransferUsers(tx *goqu.TxDatabase, transferID int, users []models.TransferUser) error {
@@ -429,12 +503,14 @@ func (r *transferRepository) GetTransfersByUserAndStatus(userID int, status stri
 	return flatTransfers, nil
 }
 
-func (r *transferRepository) UpdateDeliveryLocation(transferID int, latitude float64, longitude float64, timestamp time.Time) error {
+func (r *transferRepository) UpdateDeliveryLocation(transferID int, location models.DeliveryLocation) error {
 	_, err := r.Repo.GoquDBWrapper.Update("transfers").
 		Set(goqu.Record{
-			"delivery_latitude":  latitude,
-			"delivery_longitude": longitude,
-			"delivery_timestamp": timestamp,
+			"delivery_latitude":         location.Lat,
+			"delivery_longitude":        location.Lng,
+			"delivery_timestamp":        location.Timestamp,
+			"delivery_distance":         location.Distance,
+			"delivery_outside_geofence": location.OutsideGeofence,
 		}).
 		Where(goqu.C("id").Eq(transferID)).
 		Executor().
@@ -456,6 +532,106 @@ func (r *transferRepository) UpdateStockItemsTransferStatus(tx *goqu.TxDatabase,
 	return nil
 }
 
+func (r *transferRepository) UpdateAssetsTransferStatus(tx *goqu.TxDatabase, transferID int, status string) error {
+	_, err := tx.Update("serialized_transfers").
+		Set(goqu.Record{"status": status}).
+		Where(goqu.Ex{"transfer_id": transferID}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update assets transfer status: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferRepository) GetDeliveryLines(transferID int) ([]models.TransferAssetLine, []models.TransferStockLine, error) {
+	return r.scanDeliveryLines(r.Repo.GoquDBWrapper.From, transferID, false)
+}
+
+// LockDeliveryLines locks asset and stock lines of a transfer for a delivery confirmation
+func (r *transferRepository) LockDeliveryLines(tx *goqu.TxDatabase, transferID int) ([]models.TransferAssetLine, []models.TransferStockLine, error) {
+	return r.scanDeliveryLines(tx.From, transferID, true)
+}
+
+func (r *transferRepository) scanDeliveryLines(from func(...interface{}) *goqu.SelectDataset, transferID int, lock bool) ([]models.TransferAssetLine, []models.TransferStockLine, error) {
+	assetQuery := from(goqu.T("serialized_transfers").As("st")).
+		Select("st.item_id", "i.pyr_code", "st.status", "st.resolved_at").
+		LeftJoin(goqu.T("items").As("i"), goqu.On(goqu.Ex{"st.item_id": goqu.I("i.id")})).
+		Where(goqu.Ex{"st.transfer_id": transferID}).
+		Order(goqu.I("st.id").Asc())
+	stockQuery := from(goqu.T("non_serialized_transfers").As("nst")).
+		Select(
+			"nst.id",
+			"nst.stock_id",
+			"nst.item_category_id",
+			goqu.I("c.label").As("category_label"),
+			"nst.origin",
+			"nst.quantity",
+			"nst.received_quantity",
+			"nst.written_off_quantity",
+			goqu.COALESCE(goqu.I("nst.status"), models.TransferLineInTransit).As("status"),
+		).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"nst.item_category_id": goqu.I("c.id")})).
+		Where(goqu.Ex{"nst.transfer_id": transferID}).
+		Order(goqu.I("nst.id").Asc())
+	if lock {
+		assetQuery = assetQuery.ForUpdate(exp.Wait, goqu.T("st"))
+		stockQuery = stockQuery.ForUpdate(exp.Wait, goqu.T("nst"))
+	}
+
+	assetLines := []models.TransferAssetLine{}
+	if err := assetQuery.Executor().ScanStructs(&assetLines); err != nil {
+		return nil, nil, fmt.Errorf("failed to get transfer asset lines: %w", err)
+	}
+	stockLines := []models.TransferStockLine{}
+	if err := stockQuery.Executor().ScanStructs(&stockLines); err != nil {
+		return nil, nil, fmt.Errorf("failed to get transfer stock lines: %w", err)
+	}
+
+	return assetLines, stockLines, nil
+}
+
+// UpdateAssetLinesStatus changes the delivery state of asset lines, resolved lines get the time of resolution
+func (r *transferRepository) UpdateAssetLinesStatus(tx *goqu.TxDatabase, transferID int, assetIDs []int, status string) error {
+	if len(assetIDs) == 0 {
+		return nil
+	}
+
+	record := goqu.Record{"status": status}
+	if status == models.TransferLineCompleted || status == models.TransferLineWrittenOff {
+		record["resolved_at"] = time.Now()
+	}
+
+	_, err := tx.Update("serialized_transfers").
+		Set(record).
+		Where(goqu.Ex{"transfer_id": transferID, "item_id": assetIDs}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update transfer asset lines: %w", err)
+	}
+
+	return nil
+}
+
+func (r *transferRepository) UpdateStockLine(tx *goqu.TxDatabase, line models.TransferStockLine) error {
+	_, err := tx.Update("non_serialized_transfers").
+		Set(goqu.Record{
+			"received_quantity":    line.ReceivedQuantity,
+			"written_off_quantity": line.WrittenOffQuantity,
+			"status":               line.Status,
+		}).
+		Where(goqu.Ex{"id": line.ID}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update transfer stock line %d: %w", line.ID, err)
+	}
+
+	return nil
+}
+
 func (r *transferRepository) SetTransferUsers(transferID int, userIDs []int) error {
 	return repository.WithTransaction(r.Repo.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
 		deleteQuery := tx.Delete("transfer_users").Where(goqu.Ex{"transfer_id": transferID})
@@ -1,7 +1,63 @@
 package transfers
 
+import (
+	"time"
+	"warehouse/pkg/models"
+)
+
 type RemoveItemFromTransferRequest struct {
 	ID         int `uri:"id" binding:"required"`
 	ItemID     int `uri:"item_id" binding:"required"`
 	LocationID int `json:"location_id"`
 }
+
+// ConfirmDeliveryRequest confirms transfer lines one by one, lines left out stay in transit
+type ConfirmDeliveryRequest struct {
+	Assets []AssetDeliveryRequest `json:"assets" binding:"dive"`
+	Stocks []StockDeliveryRequest `json:"stocks" binding:"dive"`
+}
+
+type AssetDeliveryRequest struct {
+	ID       int  `json:"id" binding:"required"`
+	Received bool `json:"received"`
+}
+
+type StockDeliveryRequest struct {
+	LineID           int `json:"line_id" binding:"required"`
+	ReceivedQuantity int `json:"received_quantity" binding:"min=0"`
+}
+
+// WriteOffDeliveryRequest writes off missing lines, assets are retired as lost
+type WriteOffDeliveryRequest struct {
+	Assets      []int   `json:"assets"`
+	Stocks      []int   `json:"stocks"`
+	Notes       *string `json:"notes"`
+	WrittenByID *int    `json:"-"`
+}
+
+// CreateShipmentRequest sends assets and stock through the stops in order, the last stop is the destination
+type CreateShipmentRequest struct {
+	FromLocationID       int                       `json:"from_location_id" binding:"required"`
+	Stops                []ShipmentStopRequest     `json:"stops" binding:"required,min=1,dive"`
+	Assets               []models.AssetItemRequest `json:"assets" binding:"dive"`
+	Stocks               []models.StockItemRequest `json:"stocks" binding:"dive"`
+	RequireDeliveryPhoto bool                      `json:"require_delivery_photo"`
+	Notes                *string                   `json:"notes"`
+	CreatedByID          *int                      `json:"-"`
+}
+
+type ShipmentStopRequest struct {
+	LocationID int        `json:"location_id" binding:"required"`
+	ETA        *time.Time `json:"eta"`
+}
+
+type RetrieveShipmentListQuery struct {
+	Status     string `form:"status" binding:"omitempty,oneof=in_transit completed cancelled"`
+	LocationID int    `form:"location_id"`
+}
+
+// RecallLocationRequest sends everything at a location to ToLocationID, the main warehouse by default
+type RecallLocationRequest struct {
+	LocationID   int  `json:"location_id" binding:"required"`
+	ToLocationID *int `json:"to_location_id"`
+}
@@ -0,0 +1,187 @@
+package transfers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"warehouse/internal/repository"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrTransferNotFound      = errors.New("transfer not found")
+	ErrTransferNotReturnable = errors.New("only completed transfers can be returned")
+	ErrTransferReturned      = errors.New("transfer has already been returned")
+	ErrNothingToReturn       = errors.New("nothing is left to send back")
+	ErrRecallDestination     = errors.New("recalled items cannot be sent to the recalled location")
+)
+
+// PlanReturn builds the inverse of a completed transfer from what is still at its destination now,
+// assets moved elsewhere and stock used up in the meantime are left out. A transfer is returned once,
+// unless its return gets cancelled, so stock of the same category brought by others stays.
+func (s *TransferService) PlanReturn(transferID int) (*models.TransferRequest, error) {
+	transfer, err := s.tr.GetTransferRow(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.ID == 0 {
+		return nil, ErrTransferNotFound
+	}
+	if transfer.Status != string(metadata.StatusCompleted) {
+		return nil, ErrTransferNotReturnable
+	}
+	returnID, err := s.tr.GetActiveReturnID(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if returnID != 0 {
+		return nil, fmt.Errorf("%w by transfer %d", ErrTransferReturned, returnID)
+	}
+
+	assets, err := s.tr.GetReturnableAssets(transferID, transfer.ToLocationID)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.tr.GetReturnableStock(transferID, transfer.ToLocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := planReturns(transfer.ToLocationID, transfer.FromLocationID, assets, stock)
+	if len(requests) == 0 {
+		return nil, ErrNothingToReturn
+	}
+
+	req := requests[0]
+	req.ReturnOfTransferID = &transfer.ID
+	return &req, nil
+}
+
+// PlanRecall sends everything at the location to the requested location, the main warehouse by default,
+// with one transfer per location the items came from
+func (s *TransferService) PlanRecall(req RecallLocationRequest) ([]models.TransferRequest, error) {
+	destinationID := models.DefaultEquipmentLocationID
+	if req.ToLocationID != nil {
+		destinationID = *req.ToLocationID
+	}
+	if destinationID == req.LocationID {
+		return nil, ErrRecallDestination
+	}
+
+	assets, err := s.tr.GetRecallableAssets(req.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.tr.GetRecallableStock(req.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := planReturns(req.LocationID, destinationID, assets, stock)
+	if len(requests) == 0 {
+		return nil, ErrNothingToReturn
+	}
+
+	return requests, nil
+}
+
+// StartReturns sends the planned returns in one transaction. Kits are not expanded, their components
+// are either part of the plan already or no longer at the location.
+func (s *TransferService) StartReturns(requests []models.TransferRequest) ([]int, error) {
+	transferIDs := make([]int, 0, len(requests))
+	var statusChanges [][]models.AssetStatusChange
+	var lowStock []models.StockLevel
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		for _, req := range requests {
+			if req.ReturnOfTransferID != nil {
+				if err := s.checkReturnable(tx, *req.ReturnOfTransferID); err != nil {
+					return err
+				}
+			}
+			transferID, changes, levels, err := s.startTransfer(tx, req, string(metadata.StatusInTransit))
+			if err != nil {
+				return err
+			}
+			transferIDs = append(transferIDs, transferID)
+			statusChanges = append(statusChanges, changes)
+			lowStock = append(lowStock, levels...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, transferID := range transferIDs {
+		go s.createInventoryLog("in_transfer", transferID)
+		go s.il.CreateAssetStatusChangeLogEntries(statusChanges[i], transferID)
+	}
+	go s.stockRepo.NotifyLowStock(lowStock)
+
+	return transferIDs, nil
+}
+
+// checkReturnable repeats the checks of PlanReturn under lock
+func (s *TransferService) checkReturnable(tx *goqu.TxDatabase, transferID int) error {
+	status, returnID, err := s.tr.LockReturnedTransfer(tx, transferID)
+	if err != nil {
+		return err
+	}
+	if status != string(metadata.StatusCompleted) {
+		return ErrTransferNotReturnable
+	}
+	if returnID != 0 {
+		return fmt.Errorf("%w by transfer %d", ErrTransferReturned, returnID)
+	}
+	return nil
+}
+
+// planReturns sends what can leave the location to the destination, one request per location the items came from.
+// Items of unknown source share one request, the requests are ordered by source with the unknown one first.
+func planReturns(locationID int, destinationID int, assets []ReturnableAsset, stock []ReturnableStock) []models.TransferRequest {
+	bySource := map[int]*models.TransferRequest{}
+	requestFor := func(origin *int) *models.TransferRequest {
+		source := 0
+		if origin != nil {
+			source = *origin
+		}
+		req, ok := bySource[source]
+		if !ok {
+			req = &models.TransferRequest{FromLocationID: locationID, LocationID: destinationID}
+			bySource[source] = req
+		}
+		return req
+	}
+
+	for _, asset := range assets {
+		if !metadata.Status(asset.Status).CanTransitionTo(metadata.StatusInTransit) {
+			continue
+		}
+		req := requestFor(asset.OriginLocationID)
+		req.AssetItemCollection = append(req.AssetItemCollection, models.AssetItemRequest{ID: asset.ID})
+	}
+	for _, item := range stock {
+		if item.Quantity <= 0 {
+			continue
+		}
+		req := requestFor(item.OriginLocationID)
+		req.StockItemCollection = append(req.StockItemCollection, models.StockItemRequest{ID: item.StockID, Quantity: item.Quantity})
+	}
+
+	sources := make([]int, 0, len(bySource))
+	for source := range bySource {
+		sources = append(sources, source)
+	}
+	sort.Ints(sources)
+
+	requests := make([]models.TransferRequest, len(sources))
+	for i, source := range sources {
+		requests[i] = *bySource[source]
+	}
+
+	return requests
+}
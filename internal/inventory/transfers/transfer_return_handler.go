@@ -0,0 +1,98 @@
+package transfers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/stocks"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *TransferHandler) ReturnTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	req, err := h.Service.PlanReturn(id)
+	if err != nil {
+		h.handleReturnError(c, "Nie udało się przygotować zwrotu transferu", err)
+		return
+	}
+
+	h.startReturns(c, []models.TransferRequest{*req}, "Nie udało się utworzyć zwrotu transferu")
+}
+
+func (h *TransferHandler) RecallLocation(c *gin.Context) {
+	var req RecallLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	requests, err := h.Service.PlanRecall(req)
+	if err != nil {
+		h.handleReturnError(c, "Nie udało się przygotować wycofania z lokalizacji", err)
+		return
+	}
+
+	h.startReturns(c, requests, "Nie udało się wycofać sprzętu z lokalizacji")
+}
+
+// startReturns validates the planned transfers like new ones and responds with the created transfers
+func (h *TransferHandler) startReturns(c *gin.Context, requests []models.TransferRequest, msg string) {
+	var validationErrors []ValidationError
+	for _, req := range requests {
+		reasons, err := h.Service.ValidateStock(req)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie można zweryfikować stanu", "details": err.Error()})
+			return
+		}
+		validationErrors = append(validationErrors, reasons...)
+	}
+	if len(validationErrors) > 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Warehouse equipment validation failed", "reasons": validationErrors})
+		return
+	}
+
+	transferIDs, err := h.Service.StartReturns(requests)
+	if err != nil {
+		h.handleReturnError(c, msg, err)
+		return
+	}
+
+	transfers := make([]models.Transfer, 0, len(transferIDs))
+	for _, transferID := range transferIDs {
+		transfer, err := h.Service.GetTransfer(transferID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusAccepted, gin.H{"message": "Transfery utworzone, ale nie można ich teraz pobrać", "ids": transferIDs, "details": err.Error()})
+			return
+		}
+		transfers = append(transfers, *transfer)
+	}
+
+	c.JSON(http.StatusCreated, transfers)
+}
+
+func (h *TransferHandler) handleReturnError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrTransferNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrRecallDestination):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrTransferNotReturnable), errors.Is(err, ErrTransferReturned), errors.Is(err, ErrNothingToReturn), errors.Is(err, stocks.ErrInsufficientQuantity),
+		custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package transfers
+
+import (
+	"fmt"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// ReturnableAsset is an asset which can be sent back, OriginLocationID is where it came from if known
+type ReturnableAsset struct {
+	ID               int    `db:"item_id"`
+	Status           string `db:"status"`
+	OriginLocationID *int   `db:"origin_location_id"`
+}
+
+// ReturnableStock is a quantity of a stock row which can be sent back, OriginLocationID is where it came from if known
+type ReturnableStock struct {
+	StockID          int  `db:"stock_id"`
+	Quantity         int  `db:"quantity"`
+	OriginLocationID *int `db:"origin_location_id"`
+}
+
+// GetActiveReturnID finds a return of the transfer which has not been cancelled, 0 means there is none
+func (r *transferRepository) GetActiveReturnID(transferID int) (int, error) {
+	return r.activeReturnID(r.Repo.GoquDBWrapper.From, transferID)
+}
+
+// LockReturnedTransfer locks a transfer about to be returned and reports its status and active return,
+// concurrent returns of the same transfer wait for each other
+func (r *transferRepository) LockReturnedTransfer(tx *goqu.TxDatabase, transferID int) (string, int, error) {
+	status, err := r.LockTransferStatus(tx, transferID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	returnID, err := r.activeReturnID(tx.From, transferID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return status, returnID, nil
+}
+
+func (r *transferRepository) activeReturnID(from func(...interface{}) *goqu.SelectDataset, transferID int) (int, error) {
+	var returnID int
+	_, err := from("transfers").
+		Select("id").
+		Where(
+			goqu.Ex{"return_of_transfer_id": transferID},
+			goqu.I("status").Neq(string(metadata.StatusCancelled)),
+		).
+		Order(goqu.I("id").Asc()).
+		Limit(1).
+		Executor().
+		ScanVal(&returnID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check returns of transfer %d: %w", transferID, err)
+	}
+
+	return returnID, nil
+}
+
+// GetReturnableAssets lists delivered assets of the transfer which are still at the location
+func (r *transferRepository) GetReturnableAssets(transferID int, locationID int) ([]ReturnableAsset, error) {
+	assets := []ReturnableAsset{}
+	err := r.Repo.GoquDBWrapper.
+		Select("st.item_id", "i.status").
+		From(goqu.T("serialized_transfers").As("st")).
+		Join(goqu.T("items").As("i"), goqu.On(goqu.Ex{"st.item_id": goqu.I("i.id")})).
+		Where(goqu.Ex{
+			"st.transfer_id": transferID,
+			"st.status":      models.TransferLineCompleted,
+			"i.location_id":  locationID,
+		}).
+		Order(goqu.I("st.id").Asc()).
+		Executor().
+		ScanStructs(&assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returnable assets of transfer %d: %w", transferID, err)
+	}
+
+	return assets, nil
+}
+
+// GetReturnableStock matches received stock lines of the transfer with the stock rows at the location,
+// a row gives back at most what the transfer brought even when more of it is there now
+func (r *transferRepository) GetReturnableStock(transferID int, locationID int) ([]ReturnableStock, error) {
+	quantity := goqu.L("LEAST(SUM(nst.received_quantity), s.quantity)")
+
+	stock := []ReturnableStock{}
+	err := r.Repo.GoquDBWrapper.
+		Select(goqu.I("s.id").As("stock_id"), quantity.As("quantity")).
+		From(goqu.T("non_serialized_transfers").As("nst")).
+		Join(
+			goqu.T("non_serialized_items").As("s"),
+			goqu.On(goqu.L(
+				"s.id = (SELECT MIN(x.id) FROM non_serialized_items x WHERE x.item_category_id = nst.item_category_id AND x.location_id = ? AND COALESCE(x.origin, '') = COALESCE(nst.origin, ''))",
+				locationID,
+			)),
+		).
+		Where(goqu.Ex{"nst.transfer_id": transferID}, goqu.I("nst.received_quantity").Gt(0)).
+		GroupBy("s.id", "s.quantity").
+		Having(quantity.Gt(0)).
+		Order(goqu.I("s.id").Asc()).
+		Executor().
+		ScanStructs(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returnable stock of transfer %d: %w", transferID, err)
+	}
+
+	return stock, nil
+}
+
+// GetRecallableAssets lists assets at the location with the source of the last transfer which delivered them there
+func (r *transferRepository) GetRecallableAssets(locationID int) ([]ReturnableAsset, error) {
+	origin := r.Repo.GoquDBWrapper.
+		Select("t.from_location_id").
+		From(goqu.T("serialized_transfers").As("st")).
+		Join(goqu.T("transfers").As("t"), goqu.On(goqu.Ex{"st.transfer_id": goqu.I("t.id")})).
+		Where(goqu.Ex{
+			"st.item_id":       goqu.I("i.id"),
+			"st.status":        models.TransferLineCompleted,
+			"t.to_location_id": goqu.I("i.location_id"),
+		}).
+		Order(goqu.I("st.resolved_at").Desc().NullsLast(), goqu.I("t.id").Desc()).
+		Limit(1)
+
+	assets := []ReturnableAsset{}
+	err := r.Repo.GoquDBWrapper.
+		Select(goqu.I("i.id").As("item_id"), "i.status", origin.As("origin_location_id")).
+		From(goqu.T("items").As("i")).
+		Where(goqu.Ex{"i.location_id": locationID}).
+		Order(goqu.I("i.id").Asc()).
+		Executor().
+		ScanStructs(&assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recallable assets at location %d: %w", locationID, err)
+	}
+
+	return assets, nil
+}
+
+// GetRecallableStock lists stock rows at the location with the source of the last transfer which delivered
+// the same category and origin there
+func (r *transferRepository) GetRecallableStock(locationID int) ([]ReturnableStock, error) {
+	origin := r.Repo.GoquDBWrapper.
+		Select("t.from_location_id").
+		From(goqu.T("non_serialized_transfers").As("nst")).
+		Join(goqu.T("transfers").As("t"), goqu.On(goqu.Ex{"nst.transfer_id": goqu.I("t.id")})).
+		Where(
+			goqu.Ex{
+				"nst.item_category_id": goqu.I("s.item_category_id"),
+				"t.to_location_id":     goqu.I("s.location_id"),
+			},
+			goqu.I("nst.received_quantity").Gt(0),
+			goqu.L("COALESCE(nst.origin, '') = COALESCE(s.origin, '')"),
+		).
+		Order(goqu.I("t.id").Desc()).
+		Limit(1)
+
+	stock := []ReturnableStock{}
+	err := r.Repo.GoquDBWrapper.
+		Select(goqu.I("s.id").As("stock_id"), "s.quantity", origin.As("origin_location_id")).
+		From(goqu.T("non_serialized_items").As("s")).
+		Where(goqu.Ex{"s.location_id": locationID}, goqu.I("s.quantity").Gt(0)).
+		Order(goqu.I("s.id").Asc()).
+		Executor().
+		ScanStructs(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recallable stock at location %d: %w", locationID, err)
+	}
+
+	return stock, nil
+}
@@ -0,0 +1,86 @@
+package transfers
+
+import (
+	"testing"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanReturnsSendsEverythingInOneRequestWithoutOrigin(t *testing.T) {
+	requests := planReturns(7, 1,
+		[]ReturnableAsset{{ID: 10, Status: "located"}, {ID: 11, Status: "located"}},
+		[]ReturnableStock{{StockID: 3, Quantity: 4}},
+	)
+
+	assert.Equal(t, []models.TransferRequest{{
+		FromLocationID:      7,
+		LocationID:          1,
+		AssetItemCollection: []models.AssetItemRequest{{ID: 10}, {ID: 11}},
+		StockItemCollection: []models.StockItemRequest{{ID: 3, Quantity: 4}},
+	}}, requests)
+}
+
+func TestPlanReturnsSplitsByOriginToOneDestination(t *testing.T) {
+	requests := planReturns(7, 1,
+		[]ReturnableAsset{
+			{ID: 10, Status: "located", OriginLocationID: intPtr(4)},
+			{ID: 11, Status: "located"},
+			{ID: 12, Status: "located", OriginLocationID: intPtr(4)},
+		},
+		[]ReturnableStock{
+			{StockID: 3, Quantity: 4, OriginLocationID: intPtr(2)},
+			{StockID: 5, Quantity: 1, OriginLocationID: intPtr(1)},
+		},
+	)
+
+	assert.Equal(t, []models.TransferRequest{
+		{
+			FromLocationID:      7,
+			LocationID:          1,
+			AssetItemCollection: []models.AssetItemRequest{{ID: 11}},
+		},
+		{
+			FromLocationID:      7,
+			LocationID:          1,
+			StockItemCollection: []models.StockItemRequest{{ID: 5, Quantity: 1}},
+		},
+		{
+			FromLocationID:      7,
+			LocationID:          1,
+			StockItemCollection: []models.StockItemRequest{{ID: 3, Quantity: 4}},
+		},
+		{
+			FromLocationID:      7,
+			LocationID:          1,
+			AssetItemCollection: []models.AssetItemRequest{{ID: 10}, {ID: 12}},
+		},
+	}, requests)
+}
+
+func TestPlanReturnsSkipsItemsThatCannotLeave(t *testing.T) {
+	requests := planReturns(7, 1,
+		[]ReturnableAsset{{ID: 10, Status: "in_repair"}, {ID: 11, Status: "retired"}},
+		[]ReturnableStock{{StockID: 3, Quantity: 0}},
+	)
+
+	assert.Empty(t, requests)
+}
+
+// Cancelling a recall puts stock back to the room row of each origin, even when the recall emptied and removed it
+func TestStockRestorationsOfCancelledRecall(t *testing.T) {
+	items := []models.StockItem{
+		{Category: models.ItemCategory{ID: 3}, Quantity: 4, Origin: "own"},
+		{Category: models.ItemCategory{ID: 3}, Quantity: 2, Origin: "probis"},
+		{Category: models.ItemCategory{ID: 3}, Quantity: 1, Origin: "own"},
+		{Category: models.ItemCategory{ID: 5}, Quantity: 0, Origin: "own"},
+	}
+
+	restorations := stockRestorations(12, 40, items)
+
+	assert.Equal(t, []stocks.RemoveStockItemFromTransferRequest{
+		{CategoryID: 3, TransferID: 12, Quantity: 5, ToLocationID: 40, Origin: "own"},
+		{CategoryID: 3, TransferID: 12, Quantity: 2, ToLocationID: 40, Origin: "probis"},
+	}, restorations)
+}
@@ -1,20 +1,27 @@
 package transfers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
 	"warehouse/internal/inventory/assets"
 	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/inventory/kits"
+	"warehouse/internal/inventory/reservations"
+	"warehouse/internal/inventory/retirements"
 	"warehouse/internal/inventory/stocks"
 	"warehouse/internal/repository"
 	"warehouse/internal/users"
+	custom_error "warehouse/pkg/errors"
 	"warehouse/pkg/metadata"
 	"warehouse/pkg/models"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
+var ErrDeliveryPhotoRequired = errors.New("transfer requires a delivery photo before confirmation")
+
 type TransferService struct {
 	r         *repository.Repository
 	tr        TransferRepository
@@ -22,6 +29,12 @@ type TransferService struct {
 	stockRepo *stocks.StockRepository
 	ur        users.UserRepository
 	il        *inventorylog.InventoryLog
+	kr        *kits.KitRepository
+	rr        *reservations.ReservationRepository
+	rtr       *retirements.RetirementRepository
+	shr       *ShipmentRepository
+	// geofenceRadius applies to destinations without their own radius
+	geofenceRadius int
 }
 
 type ValidationError struct {
@@ -36,6 +49,10 @@ func NewService(
 	sr *stocks.StockRepository,
 	ur users.UserRepository,
 	il *inventorylog.InventoryLog,
+	kr *kits.KitRepository,
+	rr *reservations.ReservationRepository,
+	rtr *retirements.RetirementRepository,
+	shr *ShipmentRepository,
 ) *TransferService {
 	return &TransferService{
 		r:         r,
@@ -44,27 +61,28 @@ func NewService(
 		stockRepo: sr,
 		il:        il,
 		ur:        ur,
+		kr:        kr,
+		rr:        rr,
+		rtr:       rtr,
+		shr:       shr,
+
+		geofenceRadius: deliveryGeofenceRadius(),
 	}
 }
 
 func (s *TransferService) InitTransfer(req models.TransferRequest, transitStatus string) (int, error) {
 	var transferID int
+	var statusChanges []models.AssetStatusChange
+	var lowStock []models.StockLevel
 
 	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
 		var err error
-		if transferID, err = s.tr.InsertTransferRecord(tx, req); err != nil {
-			return fmt.Errorf("failed to insert transfer record: %w", err)
-		}
-
-		if err = s.startAssetsTransfer(tx, transferID, req.AssetItemCollection, req.LocationID, transitStatus); err != nil {
-			return err
-		}
-
-		if err = s.startStockItemsTransfer(tx, transferID, req.StockItemCollection, req.FromLocationID); err != nil {
+		if err = s.expandKits(tx, &req); err != nil {
 			return err
 		}
 
-		return nil
+		transferID, statusChanges, lowStock, err = s.startTransfer(tx, req, transitStatus)
+		return err
 	})
 
 	if err != nil {
@@ -93,10 +111,38 @@ func (s *TransferService) InitTransfer(req models.TransferRequest, transitStatus
 	}
 
 	go s.createInventoryLog("in_transfer", transferID)
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, transferID)
+	go s.stockRepo.NotifyLowStock(lowStock)
 
 	return transferID, nil
 }
 
+// startTransfer registers the transfer and sends its assets and stock on the way
+func (s *TransferService) startTransfer(tx *goqu.TxDatabase, req models.TransferRequest, transitStatus string) (int, []models.AssetStatusChange, []models.StockLevel, error) {
+	transferID, err := s.tr.InsertTransferRecord(tx, req)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to insert transfer record: %w", err)
+	}
+
+	if req.ReservationID != nil {
+		if err = s.rr.FulfillReservation(tx, *req.ReservationID, req.FromLocationID, transferID); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	statusChanges, err := s.startAssetsTransfer(tx, transferID, req.AssetItemCollection, req.LocationID, transitStatus)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	lowStock, err := s.startStockItemsTransfer(tx, transferID, req.StockItemCollection, req.FromLocationID)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	return transferID, statusChanges, lowStock, nil
+}
+
 func (s *TransferService) GetTransfer(transferID int) (*models.Transfer, error) {
 	flatTransfer, err := s.tr.GetTransferRow(transferID)
 	if err != nil {
@@ -125,15 +171,23 @@ func (s *TransferService) GetTransfer(transferID int) (*models.Transfer, error)
 			Name:     flatTransfer.ToLocationName,
 			Pavilion: pavilionTo,
 		},
-		Status:       flatTransfer.Status,
-		TransferDate: flatTransfer.TransferDate,
+		Status:                flatTransfer.Status,
+		TransferDate:          flatTransfer.TransferDate,
+		RequiresDeliveryPhoto: flatTransfer.RequiresDeliveryPhoto,
+		ReturnOfTransferID:    flatTransfer.ReturnOfTransferID,
 	}
 
+	transfer.ToLocation.Latitude = flatTransfer.ToLocationLatitude
+	transfer.ToLocation.Longitude = flatTransfer.ToLocationLongitude
+	transfer.ToLocation.GeofenceRadius = flatTransfer.ToLocationGeofenceRadius
+
 	if flatTransfer.DeliveryLatitude != nil && flatTransfer.DeliveryLongitude != nil && flatTransfer.DeliveryTimestamp != nil {
 		transfer.DeliveryLocation = &models.DeliveryLocation{
-			Lat:       *flatTransfer.DeliveryLatitude,
-			Lng:       *flatTransfer.DeliveryLongitude,
-			Timestamp: *flatTransfer.DeliveryTimestamp,
+			Lat:             *flatTransfer.DeliveryLatitude,
+			Lng:             *flatTransfer.DeliveryLongitude,
+			Timestamp:       *flatTransfer.DeliveryTimestamp,
+			Distance:        flatTransfer.DeliveryDistance,
+			OutsideGeofence: flatTransfer.DeliveryOutsideGeofence,
 		}
 	}
 
@@ -158,6 +212,14 @@ func (s *TransferService) GetTransfer(transferID int) (*models.Transfer, error)
 	}
 	transfer.Users = users
 
+	if transfer.AssetLines, transfer.StockLines, err = s.tr.GetDeliveryLines(transferID); err != nil {
+		return nil, fmt.Errorf("failed to get transfer delivery lines: %w", err)
+	}
+
+	if transfer.KitWarnings, err = s.getKitWarnings(transfer); err != nil {
+		return nil, fmt.Errorf("failed to check transfer kits: %w", err)
+	}
+
 	return transfer, nil
 }
 
@@ -201,7 +263,7 @@ func (s *TransferService) RemoveStockItemFromTransfer(transferReq stocks.RemoveS
 	var err error
 
 	return repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
-		if err = decreaseStockInTransfer(tx, transferReq); err != nil {
+		if transferReq.Origin, err = decreaseStockInTransfer(tx, transferReq); err != nil {
 			return err
 		}
 
@@ -254,100 +316,117 @@ func (s *TransferService) ValidateStock(transferRequest models.TransferRequest)
 		}
 	}
 
-	return validationState, nil
-}
-
-func (s *TransferService) completeStockItemsTransfer(tx *goqu.TxDatabase, transferID int) error {
-	HasStockItems, err := s.tr.HasStockItemsInTransfer(tx, transferID)
+	reservationErrors, err := s.validateReservations(transferRequest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !HasStockItems {
-		return nil
+	return append(validationState, reservationErrors...), nil
+}
+
+// validateReservations rejects assets and stock promised to other planned transfers, holds of the
+// reservation the transfer fulfils are not counted
+func (s *TransferService) validateReservations(transferRequest models.TransferRequest) ([]ValidationError, error) {
+	var validationState []ValidationError
+
+	exceptID := 0
+	if transferRequest.ReservationID != nil {
+		exceptID = *transferRequest.ReservationID
 	}
+	now := time.Now()
 
-	if err := s.stockRepo.IncreaseStockAtDestination(tx, transferID); err != nil {
-		return fmt.Errorf("failed to increase stock items at destination: %w", err)
+	if len(transferRequest.AssetItemCollection) > 0 {
+		held, err := s.rr.GetHeldAssets(mapToIDArray(transferRequest.AssetItemCollection), exceptID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate reserved assets: %w", err)
+		}
+		if len(held) > 0 {
+			validationState = append(validationState, ValidationError{
+				Message:  fmt.Sprintf("Serialized assets are reserved for another transfer: %v", held),
+				Property: "assets",
+			})
+		}
 	}
 
-	// Zamiast usuwać wpisy, aktualizujemy ich status
-	if err := s.tr.UpdateStockItemsTransferStatus(tx, transferID, "completed"); err != nil {
-		return fmt.Errorf("failed to update stock items transfer status: %w", err)
+	if len(transferRequest.StockItemCollection) > 0 {
+		stockIDs := make([]int, len(transferRequest.StockItemCollection))
+		requested := make(map[int]int, len(transferRequest.StockItemCollection))
+		for i, stock := range transferRequest.StockItemCollection {
+			stockIDs[i] = stock.ID
+			requested[stock.ID] += stock.Quantity
+		}
+
+		unreserved, err := s.rr.GetUnreservedStock(stockIDs, exceptID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate reserved stocks: %w", err)
+		}
+		for _, stockID := range stockIDs {
+			quantity, ok := requested[stockID]
+			if !ok {
+				continue
+			}
+			delete(requested, stockID)
+			if available, found := unreserved[stockID]; found && available < quantity {
+				validationState = append(validationState, ValidationError{
+					Message:  fmt.Sprintf("Non-serialized stock %d is reserved for another transfer, %d left", stockID, max(available, 0)),
+					Property: "stocks",
+				})
+			}
+		}
 	}
 
-	return nil
+	return validationState, nil
 }
 
-func (s *TransferService) startAssetsTransfer(tx *goqu.TxDatabase, transferID int, assets []models.AssetItemRequest, locationID int, transitStatus string) error {
+func (s *TransferService) startAssetsTransfer(tx *goqu.TxDatabase, transferID int, assets []models.AssetItemRequest, locationID int, transitStatus string) ([]models.AssetStatusChange, error) {
 	if len(assets) == 0 {
-		return nil
+		return nil, nil
 	}
 	idList := mapToIDArray(assets)
 
+	changes, err := s.ar.CheckStatusTransition(tx, idList, metadata.Status(transitStatus))
+	if err != nil {
+		return nil, err
+	}
+
 	if err := s.tr.InsertAssetsTransferRecord(tx, transferID, idList); err != nil {
-		return fmt.Errorf("failed to insert serialized asset transfer record: %w", err)
+		return nil, fmt.Errorf("failed to insert serialized asset transfer record: %w", err)
 	}
 
 	if err := s.tr.MoveAssets(tx, idList, locationID, transitStatus); err != nil {
-		return fmt.Errorf("failed to move serialized assets: %w", err)
+		return nil, fmt.Errorf("failed to move serialized assets: %w", err)
 	}
 
-	return nil
+	return changes, nil
 }
 
-func (s *TransferService) startStockItemsTransfer(tx *goqu.TxDatabase, transferID int, stocks []models.StockItemRequest, fromLocationID int) error {
+func (s *TransferService) startStockItemsTransfer(tx *goqu.TxDatabase, transferID int, stocks []models.StockItemRequest, fromLocationID int) ([]models.StockLevel, error) {
 	if len(stocks) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// TODO Prevent remove or figure out a way to keep what was transfered
 	if err := s.tr.InsertStockItemsTransferRecord(tx, transferID, stocks); err != nil {
-		return fmt.Errorf("failed to insert non-serialized asset transfer record: %w", err)
+		return nil, fmt.Errorf("failed to insert non-serialized asset transfer record: %w", err)
 	}
-	if err := s.stockRepo.DecreaseStockItemsQuantity(tx, stocks, fromLocationID); err != nil {
-		return fmt.Errorf("failed to move non-serialized assets: %w", err)
+	lowStock, err := s.stockRepo.DecreaseStockItemsQuantity(tx, stocks, fromLocationID, models.AdjustmentReasonTransfer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move non-serialized assets: %w", err)
 	}
 
-	return nil
+	return lowStock, nil
 }
 
-func (s *TransferService) ConfirmTransfer(transferID int, status string) error {
-	var err error
-	// TODO get only ids?
-	assets, err := s.ar.GetTransferAssets(transferID)
-	assetIDs := func(assets []models.Asset) []int {
-		var ids []int
-		for _, asset := range assets {
-			ids = append(ids, asset.ID)
-		}
-		return ids
-	}(*assets)
-
-	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
-		if len(assetIDs) > 0 {
-			if err := s.ar.UpdateItemStatus(assetIDs, metadata.StatusLocated, tx); err != nil {
-				return fmt.Errorf("unable to update assets err: %w", err)
-			}
-		}
-
-		if err := s.completeStockItemsTransfer(tx, transferID); err != nil {
-			return fmt.Errorf("unable to update stock items err: %w", err)
-		}
-
-		err = s.tr.UpdateTransferStatus(transferID, status)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	})
-
+// lockTransferInStatus locks transfer row and makes sure it is still in expected status before moving it to next
+func (s *TransferService) lockTransferInStatus(tx *goqu.TxDatabase, transferID int, expected string, next string) error {
+	current, err := s.tr.LockTransferStatus(tx, transferID)
 	if err != nil {
 		return err
 	}
 
-	go s.createInventoryLog("delivered", transferID)
+	if current != expected {
+		return custom_error.NewInvalidStatusTransitionError("transfer", transferID, current, next)
+	}
 
 	return nil
 }
@@ -373,77 +452,142 @@ func mapToIDArray(assetsReq []models.AssetItemRequest) []int {
 }
 
 func (s *TransferService) CancelTransfer(transfer *models.Transfer) error {
+	var statusChanges []models.AssetStatusChange
+
 	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
-		// Pobierz aktywa w jednym zapytaniu
-		assets, err := s.ar.GetTransferAssets(transfer.ID)
-		if err != nil {
-			return fmt.Errorf("failed to get transfer assets: %w", err)
+		if err := s.lockTransferInStatus(tx, transfer.ID, string(metadata.StatusInTransit), string(metadata.StatusCancelled)); err != nil {
+			return err
 		}
 
-		// Aktualizuj status aktywów wsadowo
-		if len(*assets) > 0 {
-			assetIDs := make([]int, len(*assets))
-			for i, asset := range *assets {
-				assetIDs[i] = asset.ID
-			}
+		var err error
+		statusChanges, err = s.cancelTransfer(tx, transfer)
+		return err
+	})
 
-			// Przywróć aktywa do oryginalnej lokalizacji i zaktualizuj status
-			for _, assetID := range assetIDs {
-				if err := s.ar.UpdateAssetStatusAndLocation(tx, assetID, transfer.FromLocation.ID, metadata.StatusLocated); err != nil {
-					return fmt.Errorf("failed to restore asset %d to original location: %w", assetID, err)
-				}
-			}
+	if err != nil {
+		return err
+	}
 
-			// Dodaj wpisy do logu asynchronicznie
-			go func(assets []models.Asset) {
-				for _, asset := range assets {
-					s.il.CreateAssetAuditLogEntry("cancelled", &asset, "Asset returned to original location")
-				}
-			}(*assets)
+	go s.createInventoryLog("cancelled", transfer.ID)
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, transfer.ID)
+
+	return nil
+}
+
+// stockRestorations sums transfer lines per category and origin, lines emptied by partial removals are skipped
+func stockRestorations(transferID, locationID int, items []models.StockItem) []stocks.RemoveStockItemFromTransferRequest {
+	type key struct {
+		categoryID int
+		origin     string
+	}
+
+	restorations := []stocks.RemoveStockItemFromTransferRequest{}
+	index := map[key]int{}
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			continue
+		}
+		k := key{item.Category.ID, item.Origin}
+		if i, ok := index[k]; ok {
+			restorations[i].Quantity += item.Quantity
+			continue
 		}
+		index[k] = len(restorations)
+		restorations = append(restorations, stocks.RemoveStockItemFromTransferRequest{
+			CategoryID:   item.Category.ID,
+			TransferID:   transferID,
+			Quantity:     item.Quantity,
+			ToLocationID: locationID,
+			Origin:       item.Origin,
+		})
+	}
 
-		// Sprawdź i przywróć pozycje magazynowe
-		hasStockItems, err := s.tr.HasStockItemsInTransfer(tx, transfer.ID)
+	return restorations
+}
+
+// cancelTransfer returns assets and stock of a locked transfer to its source location, a cancelled leg ends its shipment
+func (s *TransferService) cancelTransfer(tx *goqu.TxDatabase, transfer *models.Transfer) ([]models.AssetStatusChange, error) {
+	var statusChanges []models.AssetStatusChange
+
+	// Pobierz aktywa w jednym zapytaniu
+	assets, err := s.ar.GetTransferAssets(transfer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer assets: %w", err)
+	}
+
+	// Przywróć aktywa do oryginalnej lokalizacji i zaktualizuj status
+	restoredStatus := models.AssetStatusAtLocation(transfer.FromLocation.ID)
+	for _, asset := range *assets {
+		change, err := s.ar.UpdateAssetStatusAndLocation(tx, asset.ID, transfer.FromLocation.ID, restoredStatus)
 		if err != nil {
-			return fmt.Errorf("failed to check stock items in transfer: %w", err)
+			return nil, fmt.Errorf("failed to restore asset %d to original location: %w", asset.ID, err)
 		}
+		statusChanges = append(statusChanges, *change)
+	}
 
-		if hasStockItems {
-			stockItems, err := s.stockRepo.GetStockItemsByTransfer(transfer.ID)
-			if err != nil {
-				return fmt.Errorf("failed to get stock items: %w", err)
-			}
+	// Sprawdź i przywróć pozycje magazynowe
+	hasStockItems, err := s.tr.HasStockItemsInTransfer(tx, transfer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check stock items in transfer: %w", err)
+	}
 
-			// Przywróć pozycje magazynowe
-			for _, item := range *stockItems {
-				if err := s.stockRepo.RestoreStockToLocation(tx, stocks.RemoveStockItemFromTransferRequest{
-					CategoryID:   item.Category.ID,
-					TransferID:   transfer.ID,
-					Quantity:     item.Quantity,
-					ToLocationID: transfer.FromLocation.ID,
-				}); err != nil {
-					return fmt.Errorf("failed to restore stock item %d to original location: %w", item.Category.ID, err)
-				}
-			}
+	if hasStockItems {
+		stockItems, err := s.stockRepo.GetStockItemsByTransfer(transfer.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stock items: %w", err)
+		}
 
-			// Aktualizuj status pozycji magazynowych w transferze
-			if err := s.tr.UpdateStockItemsTransferStatus(tx, transfer.ID, "cancelled"); err != nil {
-				return fmt.Errorf("failed to update stock items transfer status: %w", err)
+		// Przywróć pozycje magazynowe, każde pochodzenie do własnego wiersza
+		for _, restore := range stockRestorations(transfer.ID, transfer.FromLocation.ID, *stockItems) {
+			if err := s.stockRepo.RestoreStockToLocation(tx, restore); err != nil {
+				return nil, fmt.Errorf("failed to restore stock item %d to original location: %w", restore.CategoryID, err)
 			}
 		}
 
-		if err := s.tr.UpdateTransferStatus(transfer.ID, "cancelled"); err != nil {
-			return fmt.Errorf("failed to update transfer status: %w", err)
+		// Aktualizuj status pozycji magazynowych w transferze
+		if err := s.tr.UpdateStockItemsTransferStatus(tx, transfer.ID, "cancelled"); err != nil {
+			return nil, fmt.Errorf("failed to update stock items transfer status: %w", err)
+		}
+	}
+
+	if err := s.tr.UpdateAssetsTransferStatus(tx, transfer.ID, "cancelled"); err != nil {
+		return nil, fmt.Errorf("failed to update assets transfer status: %w", err)
+	}
+
+	if err := s.tr.UpdateTransferStatus(tx, transfer.ID, "cancelled"); err != nil {
+		return nil, fmt.Errorf("failed to update transfer status: %w", err)
+	}
+
+	if err := s.shr.CancelShipmentOfTransfer(tx, transfer.ID); err != nil {
+		return nil, err
+	}
+
+	return statusChanges, nil
+}
+
+func (s *TransferService) RemoveAssetFromTransfer(req RemoveItemFromTransferRequest) error {
+	var statusChanges []models.AssetStatusChange
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		change, err := s.ar.DetachAssetFromTransfer(tx, req.ID, req.ItemID, req.LocationID)
+		if err != nil {
+			return err
+		}
+		statusChanges = append(statusChanges, *change)
+
+		kitChanges, err := s.detachKitComponents(tx, req)
+		if err != nil {
+			return err
 		}
+		statusChanges = append(statusChanges, kitChanges...)
 
 		return nil
 	})
-
 	if err != nil {
 		return err
 	}
 
-	go s.createInventoryLog("cancelled", transfer.ID)
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, req.ID)
 
 	return nil
 }
@@ -457,21 +601,41 @@ func (s *TransferService) GetTransfersByUserAndStatus(userID int, status string)
 	return transfers, nil
 }
 
-func (s *TransferService) UpdateDeliveryLocation(transferID int, latitude float64, longitude float64, timestamp time.Time) error {
-	err := s.tr.UpdateDeliveryLocation(transferID, latitude, longitude, timestamp)
+// UpdateDeliveryLocation records where the transfer was delivered and flags deliveries outside the destination geofence
+func (s *TransferService) UpdateDeliveryLocation(transferID int, location models.DeliveryLocation) (*models.DeliveryLocation, error) {
+	if err := validateDeliveryLocation(location, time.Now()); err != nil {
+		return nil, err
+	}
 
+	transfer, err := s.tr.GetTransferRow(transferID)
 	if err != nil {
-		return fmt.Errorf("failed to update delivery location: %w", err)
+		return nil, err
 	}
-	go s.createDeliveryLocationAssetLog(transferID, latitude, longitude, timestamp)
+	destination := models.Location{
+		ID:             transfer.ToLocationID,
+		Latitude:       transfer.ToLocationLatitude,
+		Longitude:      transfer.ToLocationLongitude,
+		GeofenceRadius: transfer.ToLocationGeofenceRadius,
+	}
+	location = applyGeofence(location, destination, s.geofenceRadius)
 
-	return nil
+	if err := s.tr.UpdateDeliveryLocation(transferID, location); err != nil {
+		return nil, fmt.Errorf("failed to update delivery location: %w", err)
+	}
+
+	go s.createDeliveryLocationAssetLog(transferID, location.Lat, location.Lng, location.Timestamp)
+	if location.OutsideGeofence {
+		go s.il.CreateDeliveryGeofenceLogEntry(transferID, destination.ID, location)
+	}
+
+	return &location, nil
 }
 
 func (s *TransferService) createDeliveryLocationAssetLog(transferID int, latitude float64, longitude float64, timestamp time.Time) {
 	assets, err := s.ar.GetTransferAssets(transferID)
 	if err != nil {
 		log.Printf("failed to get transfer assets: %v", err)
+		return
 	}
 
 	for _, asset := range *assets {
@@ -0,0 +1,368 @@
+package transfers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"warehouse/internal/inventory/stocks"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrShipmentNotFound      = errors.New("shipment not found")
+	ErrShipmentStops         = errors.New("every stop has to differ from the previous one")
+	ErrShipmentETAOrder      = errors.New("ETA of a stop cannot precede ETA of the previous stop")
+	ErrShipmentLegNotCurrent = errors.New("only the current leg of a shipment in transit can be confirmed")
+	ErrShipmentLegPartial    = errors.New("current leg is partially delivered, its shortfalls have to be resolved first")
+)
+
+// shipmentAdvance is what happened to a shipment after one of its legs was completed
+type shipmentAdvance struct {
+	shipmentID int
+	// status is set when the shipment ended
+	status string
+	// transferID is the transfer of the next leg
+	transferID    int
+	statusChanges []models.AssetStatusChange
+	lowStock      []models.StockLevel
+}
+
+// ValidateShipment checks the first leg the same way as a standalone transfer
+func (s *TransferService) ValidateShipment(req CreateShipmentRequest) ([]ValidationError, error) {
+	return s.ValidateStock(models.TransferRequest{
+		FromLocationID:      req.FromLocationID,
+		LocationID:          req.Stops[0].LocationID,
+		AssetItemCollection: req.Assets,
+		StockItemCollection: req.Stocks,
+	})
+}
+
+// CreateShipment plans the legs and sends everything on the first one
+func (s *TransferService) CreateShipment(req CreateShipmentRequest) (*models.Shipment, error) {
+	legs, err := planShipmentLegs(req.FromLocationID, req.Stops)
+	if err != nil {
+		return nil, err
+	}
+
+	var shipmentID, transferID int
+	var statusChanges []models.AssetStatusChange
+	var lowStock []models.StockLevel
+
+	err = repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if shipmentID, err = s.shr.InsertShipment(tx, req, legs[len(legs)-1].ToLocationID); err != nil {
+			return err
+		}
+		if err = s.shr.InsertShipmentLegs(tx, shipmentID, legs); err != nil {
+			return err
+		}
+
+		transferReq := models.TransferRequest{
+			FromLocationID:       req.FromLocationID,
+			LocationID:           legs[0].ToLocationID,
+			AssetItemCollection:  req.Assets,
+			StockItemCollection:  req.Stocks,
+			RequireDeliveryPhoto: req.RequireDeliveryPhoto,
+		}
+		if err = s.expandKits(tx, &transferReq); err != nil {
+			return err
+		}
+		if transferID, statusChanges, lowStock, err = s.startTransfer(tx, transferReq, string(metadata.StatusInTransit)); err != nil {
+			return err
+		}
+
+		return s.shr.SetLegTransfer(tx, shipmentID, legs[0].Number, transferID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.createInventoryLog("in_transfer", transferID)
+	go s.il.CreateAssetStatusChangeLogEntries(statusChanges, transferID)
+	go s.stockRepo.NotifyLowStock(lowStock)
+	go s.logShipment("create", shipmentID)
+
+	return s.GetShipment(shipmentID)
+}
+
+func (s *TransferService) GetShipment(id int) (*models.Shipment, error) {
+	shipment, err := s.shr.GetShipment(id)
+	if err != nil {
+		return nil, err
+	}
+	if shipment == nil {
+		return nil, ErrShipmentNotFound
+	}
+
+	resolveShipmentLegs(shipment)
+	return shipment, nil
+}
+
+func (s *TransferService) GetShipments(query RetrieveShipmentListQuery) ([]models.Shipment, error) {
+	shipments, err := s.shr.GetShipments(query)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range shipments {
+		resolveShipmentLegs(&shipments[i])
+	}
+	return shipments, nil
+}
+
+// ConfirmShipmentLeg receives everything sent on the current leg, which starts the next one
+func (s *TransferService) ConfirmShipmentLeg(shipmentID int, legNumber int) error {
+	shipment, err := s.GetShipment(shipmentID)
+	if err != nil {
+		return err
+	}
+
+	leg := currentShipmentLeg(shipment)
+	if leg == nil || leg.Number != legNumber || leg.TransferID == nil {
+		return fmt.Errorf("%w: shipment %d is %s", ErrShipmentLegNotCurrent, shipmentID, shipment.Status)
+	}
+
+	return s.ConfirmTransfer(*leg.TransferID)
+}
+
+// CancelShipment stops the shipment, whatever travels on the current leg returns to where the leg started
+func (s *TransferService) CancelShipment(shipmentID int) error {
+	var transferID int
+	var statusChanges []models.AssetStatusChange
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		shipment, err := s.shr.LockShipment(tx, shipmentID)
+		if err != nil {
+			return err
+		}
+		if shipment == nil {
+			return ErrShipmentNotFound
+		}
+		if shipment.Status != models.ShipmentStatusInTransit {
+			return custom_error.NewInvalidStatusTransitionError("shipment", shipmentID, shipment.Status, models.ShipmentStatusCancelled)
+		}
+
+		resolveShipmentLegs(shipment)
+		if leg := currentShipmentLeg(shipment); leg != nil && leg.TransferID != nil {
+			current, err := s.tr.LockTransferStatus(tx, *leg.TransferID)
+			if err != nil {
+				return err
+			}
+
+			switch current {
+			case string(metadata.StatusInTransit):
+				transferID = *leg.TransferID
+				statusChanges, err = s.cancelTransfer(tx, &models.Transfer{ID: transferID, FromLocation: models.Location{ID: leg.FromLocationID}})
+				if err != nil {
+					return err
+				}
+			case string(metadata.StatusPartiallyDelivered):
+				return fmt.Errorf("%w: transfer %d", ErrShipmentLegPartial, *leg.TransferID)
+			}
+		}
+
+		return s.shr.UpdateShipmentStatus(tx, shipmentID, models.ShipmentStatusCancelled)
+	})
+	if err != nil {
+		return err
+	}
+
+	if transferID != 0 {
+		go s.createInventoryLog("cancelled", transferID)
+		go s.il.CreateAssetStatusChangeLogEntries(statusChanges, transferID)
+	}
+	go s.logShipment("cancelled", shipmentID)
+
+	return nil
+}
+
+// advanceShipment starts the next leg after a leg transfer got completed, or completes the shipment after its last leg.
+// Returns nil for transfers outside shipments.
+func (s *TransferService) advanceShipment(tx *goqu.TxDatabase, transferID int) (*shipmentAdvance, error) {
+	shipmentID, err := s.shr.GetShipmentIDByTransfer(tx, transferID)
+	if err != nil || shipmentID == 0 {
+		return nil, err
+	}
+
+	shipment, err := s.shr.LockShipment(tx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if shipment == nil || shipment.Status != models.ShipmentStatusInTransit {
+		return nil, nil
+	}
+
+	next := nextShipmentLeg(shipment.Legs, transferID)
+	advance := &shipmentAdvance{shipmentID: shipmentID}
+	if next == nil {
+		advance.status = models.ShipmentStatusCompleted
+		return advance, s.shr.UpdateShipmentStatus(tx, shipmentID, models.ShipmentStatusCompleted)
+	}
+	if next.TransferID != nil {
+		return nil, nil
+	}
+
+	req, err := s.forwardRequest(tx, transferID, next, shipment.RequiresDeliveryPhoto)
+	if err != nil {
+		return nil, err
+	}
+	// Everything got lost on the way, there is nothing left to send further
+	if len(req.AssetItemCollection) == 0 && len(req.StockItemCollection) == 0 {
+		advance.status = models.ShipmentStatusCancelled
+		return advance, s.shr.UpdateShipmentStatus(tx, shipmentID, models.ShipmentStatusCancelled)
+	}
+
+	if advance.transferID, advance.statusChanges, advance.lowStock, err = s.startTransfer(tx, req, string(metadata.StatusInTransit)); err != nil {
+		return nil, fmt.Errorf("failed to start leg %d of shipment %d: %w", next.Number, shipmentID, err)
+	}
+	if err := s.shr.SetLegTransfer(tx, shipmentID, next.Number, advance.transferID); err != nil {
+		return nil, err
+	}
+
+	return advance, nil
+}
+
+// forwardRequest sends on the next leg what the completed transfer delivered, written off items stay behind
+func (s *TransferService) forwardRequest(tx *goqu.TxDatabase, transferID int, next *models.ShipmentLeg, requiresPhoto bool) (models.TransferRequest, error) {
+	req := models.TransferRequest{
+		FromLocationID:       next.FromLocationID,
+		LocationID:           next.ToLocationID,
+		RequireDeliveryPhoto: requiresPhoto,
+	}
+
+	assetLines, stockLines, err := s.tr.LockDeliveryLines(tx, transferID)
+	if err != nil {
+		return req, err
+	}
+
+	for _, line := range assetLines {
+		if line.Status == models.TransferLineCompleted {
+			req.AssetItemCollection = append(req.AssetItemCollection, models.AssetItemRequest{ID: line.AssetID})
+		}
+	}
+
+	for _, line := range stockLines {
+		if line.ReceivedQuantity == 0 {
+			continue
+		}
+
+		stockID, err := s.shr.FindStockRow(tx, line.CategoryID, next.FromLocationID, line.Origin)
+		if err != nil {
+			return req, err
+		}
+		if stockID == 0 {
+			return req, fmt.Errorf("%w: category %d is no longer at location %d", stocks.ErrInsufficientQuantity, line.CategoryID, next.FromLocationID)
+		}
+
+		if i := findStockRequest(req.StockItemCollection, stockID); i >= 0 {
+			req.StockItemCollection[i].Quantity += line.ReceivedQuantity
+		} else {
+			req.StockItemCollection = append(req.StockItemCollection, models.StockItemRequest{ID: stockID, Quantity: line.ReceivedQuantity})
+		}
+	}
+
+	return req, nil
+}
+
+func (s *TransferService) logShipmentAdvance(advance *shipmentAdvance) {
+	if advance == nil {
+		return
+	}
+
+	action := "leg_started"
+	if advance.status != "" {
+		action = advance.status
+	}
+	if advance.transferID != 0 {
+		go s.createInventoryLog("in_transfer", advance.transferID)
+		go s.il.CreateAssetStatusChangeLogEntries(advance.statusChanges, advance.transferID)
+		go s.stockRepo.NotifyLowStock(advance.lowStock)
+	}
+	go s.logShipment(action, advance.shipmentID)
+}
+
+func (s *TransferService) logShipment(action string, shipmentID int) {
+	shipment, err := s.GetShipment(shipmentID)
+	if err != nil {
+		log.Printf("Unable to get shipment id: %d for auditlog error: %v", shipmentID, err)
+		return
+	}
+
+	s.il.CreateShipmentLogEntry(action, shipment)
+}
+
+// planShipmentLegs turns stops into legs, each leg starts where the previous one ended
+func planShipmentLegs(fromLocationID int, stops []ShipmentStopRequest) ([]models.ShipmentLeg, error) {
+	legs := make([]models.ShipmentLeg, len(stops))
+	previous := fromLocationID
+	for i, stop := range stops {
+		if stop.LocationID == previous {
+			return nil, fmt.Errorf("%w: stop %d", ErrShipmentStops, i+1)
+		}
+		if i > 0 && stop.ETA != nil && stops[i-1].ETA != nil && stop.ETA.Before(*stops[i-1].ETA) {
+			return nil, fmt.Errorf("%w: stop %d", ErrShipmentETAOrder, i+1)
+		}
+
+		legs[i] = models.ShipmentLeg{
+			Number:         i + 1,
+			FromLocationID: previous,
+			ToLocationID:   stop.LocationID,
+			ETA:            stop.ETA,
+		}
+		previous = stop.LocationID
+	}
+
+	return legs, nil
+}
+
+// resolveShipmentLegs fills leg statuses, the current leg and the ETA of a shipment. A started leg reports
+// the status of its transfer, legs which never started are planned or cancelled with the shipment.
+func resolveShipmentLegs(shipment *models.Shipment) {
+	shipment.CurrentLeg = nil
+	shipment.ETA = nil
+
+	for i := range shipment.Legs {
+		leg := &shipment.Legs[i]
+		switch {
+		case leg.TransferID != nil && leg.TransferStatus != nil:
+			leg.Status = *leg.TransferStatus
+		case shipment.Status == models.ShipmentStatusCancelled:
+			leg.Status = models.ShipmentLegCancelled
+		default:
+			leg.Status = models.ShipmentLegPlanned
+		}
+
+		if shipment.CurrentLeg == nil && shipment.Status == models.ShipmentStatusInTransit && leg.Status != string(metadata.StatusCompleted) {
+			number := leg.Number
+			shipment.CurrentLeg = &number
+			shipment.ETA = leg.ETA
+		}
+	}
+}
+
+func currentShipmentLeg(shipment *models.Shipment) *models.ShipmentLeg {
+	if shipment.CurrentLeg == nil {
+		return nil
+	}
+	for i := range shipment.Legs {
+		if shipment.Legs[i].Number == *shipment.CurrentLeg {
+			return &shipment.Legs[i]
+		}
+	}
+	return nil
+}
+
+// nextShipmentLeg returns the leg following the one of the transfer, nil after the last leg
+func nextShipmentLeg(legs []models.ShipmentLeg, transferID int) *models.ShipmentLeg {
+	for i, leg := range legs {
+		if leg.TransferID != nil && *leg.TransferID == transferID && i+1 < len(legs) {
+			return &legs[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package transfers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/stocks"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *TransferHandler) GetShipments(c *gin.Context) {
+	var query RetrieveShipmentListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowe parametry zapytania", "details": err.Error()})
+		return
+	}
+
+	shipments, err := h.Service.GetShipments(query)
+	if err != nil {
+		h.handleShipmentError(c, "Błąd pobierania przesyłek", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipments)
+}
+
+func (h *TransferHandler) GetShipment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	shipment, err := h.Service.GetShipment(id)
+	if err != nil {
+		h.handleShipmentError(c, "Błąd pobierania przesyłki", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+func (h *TransferHandler) CreateShipment(c *gin.Context) {
+	var req CreateShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if len(req.Assets) == 0 && len(req.Stocks) == 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nie można utworzyć pustej przesyłki"})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CreatedByID = &userID
+	}
+
+	validationErrors, err := h.Service.ValidateShipment(req)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Nie można zweryfikować stanu", "details": err.Error()})
+		return
+	}
+	if len(validationErrors) > 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Warehouse equipment validation failed", "reasons": validationErrors})
+		return
+	}
+
+	shipment, err := h.Service.CreateShipment(req)
+	if err != nil {
+		h.handleShipmentError(c, "Nie udało się utworzyć przesyłki", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+func (h *TransferHandler) ConfirmShipmentLeg(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+	leg, err := strconv.Atoi(c.Param("leg"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy numer etapu"})
+		return
+	}
+
+	if err := h.Service.ConfirmShipmentLeg(id, leg); err != nil {
+		h.handleShipmentError(c, "Nie udało się potwierdzić etapu przesyłki", err)
+		return
+	}
+
+	shipment, err := h.Service.GetShipment(id)
+	if err != nil {
+		h.handleShipmentError(c, "Błąd pobierania przesyłki", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+func (h *TransferHandler) CancelShipment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	if err := h.Service.CancelShipment(id); err != nil {
+		h.handleShipmentError(c, "Nie udało się anulować przesyłki", err)
+		return
+	}
+
+	shipment, err := h.Service.GetShipment(id)
+	if err != nil {
+		h.handleShipmentError(c, "Błąd pobierania przesyłki", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+func (h *TransferHandler) handleShipmentError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrShipmentNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrShipmentStops), errors.Is(err, ErrShipmentETAOrder):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrDeliveryPhotoRequired):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "code": "delivery_photo_required", "details": err.Error()})
+	case errors.Is(err, ErrShipmentLegNotCurrent), errors.Is(err, ErrShipmentLegPartial), errors.Is(err, stocks.ErrInsufficientQuantity),
+		custom_error.IsInvalidStatusTransition(err):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package transfers
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+)
+
+type ShipmentRepository struct {
+	repository *repository.Repository
+}
+
+func NewShipmentRepository(r *repository.Repository) *ShipmentRepository {
+	return &ShipmentRepository{
+		repository: r,
+	}
+}
+
+func (r *ShipmentRepository) InsertShipment(tx *goqu.TxDatabase, req CreateShipmentRequest, toLocationID int) (int, error) {
+	record := goqu.Record{
+		"from_location_id":        req.FromLocationID,
+		"to_location_id":          toLocationID,
+		"requires_delivery_photo": req.RequireDeliveryPhoto,
+		"notes":                   req.Notes,
+		"created_by_id":           req.CreatedByID,
+	}
+
+	var id int
+	if _, err := tx.Insert("shipments").Rows(record).Returning("id").Executor().ScanVal(&id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return 0, custom_error.WrapDBError("Nieprawidłowa lokalizacja", string(pqErr.Code))
+		}
+		return 0, fmt.Errorf("failed to insert shipment: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *ShipmentRepository) InsertShipmentLegs(tx *goqu.TxDatabase, shipmentID int, legs []models.ShipmentLeg) error {
+	rows := make([]interface{}, len(legs))
+	for i, leg := range legs {
+		rows[i] = goqu.Record{
+			"shipment_id":      shipmentID,
+			"leg_number":       leg.Number,
+			"from_location_id": leg.FromLocationID,
+			"to_location_id":   leg.ToLocationID,
+			"eta":              leg.ETA,
+		}
+	}
+
+	if _, err := tx.Insert("shipment_legs").Rows(rows...).Executor().Exec(); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return custom_error.WrapDBError("Nieprawidłowa lokalizacja etapu", string(pqErr.Code))
+		}
+		return fmt.Errorf("failed to insert shipment legs: %w", err)
+	}
+
+	return nil
+}
+
+// LockShipment locks a shipment for a status change, returns nil when there is none
+func (r *ShipmentRepository) LockShipment(tx *goqu.TxDatabase, id int) (*models.Shipment, error) {
+	var shipment models.Shipment
+	found, err := tx.Select("id", "from_location_id", "to_location_id", "status", "requires_delivery_photo").
+		From("shipments").
+		Where(goqu.Ex{"id": id}).
+		ForUpdate(exp.Wait).
+		Executor().
+		ScanStruct(&shipment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock shipment: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	legs, err := r.scanLegs(tx.From, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	shipment.Legs = legs
+
+	return &shipment, nil
+}
+
+// GetShipmentIDByTransfer returns the shipment a transfer is a leg of, zero for standalone transfers
+func (r *ShipmentRepository) GetShipmentIDByTransfer(tx *goqu.TxDatabase, transferID int) (int, error) {
+	var shipmentID int
+	_, err := tx.Select("shipment_id").
+		From("shipment_legs").
+		Where(goqu.Ex{"transfer_id": transferID}).
+		Executor().
+		ScanVal(&shipmentID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get shipment of transfer %d: %w", transferID, err)
+	}
+
+	return shipmentID, nil
+}
+
+func (r *ShipmentRepository) SetLegTransfer(tx *goqu.TxDatabase, shipmentID int, legNumber int, transferID int) error {
+	_, err := tx.Update("shipment_legs").
+		Set(goqu.Record{"transfer_id": transferID}).
+		Where(goqu.Ex{"shipment_id": shipmentID, "leg_number": legNumber}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to start shipment leg: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ShipmentRepository) UpdateShipmentStatus(tx *goqu.TxDatabase, id int, status string) error {
+	record := goqu.Record{"status": status}
+	if status == models.ShipmentStatusCompleted {
+		record["completed_at"] = time.Now()
+	}
+
+	_, err := tx.Update("shipments").
+		Set(record).
+		Where(goqu.Ex{"id": id}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update shipment status: %w", err)
+	}
+
+	return nil
+}
+
+// CancelShipmentOfTransfer ends the shipment a cancelled transfer is a leg of, the rest of its legs never start
+func (r *ShipmentRepository) CancelShipmentOfTransfer(tx *goqu.TxDatabase, transferID int) error {
+	_, err := tx.Update("shipments").
+		Set(goqu.Record{"status": models.ShipmentStatusCancelled}).
+		Where(
+			goqu.Ex{"status": models.ShipmentStatusInTransit},
+			goqu.I("id").In(tx.From("shipment_legs").Select("shipment_id").Where(goqu.Ex{"transfer_id": transferID})),
+		).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to cancel shipment of transfer %d: %w", transferID, err)
+	}
+
+	return nil
+}
+
+// FindStockRow returns the stock row of category and origin at a location, zero when there is none
+func (r *ShipmentRepository) FindStockRow(tx *goqu.TxDatabase, categoryID int, locationID int, origin *string) (int, error) {
+	value := ""
+	if origin != nil {
+		value = *origin
+	}
+
+	var stockID int
+	_, err := tx.Select("id").
+		From("non_serialized_items").
+		Where(goqu.Ex{"item_category_id": categoryID, "location_id": locationID}).
+		Where(goqu.L("COALESCE(origin, '') = ?", value)).
+		Order(goqu.I("id").Asc()).
+		Limit(1).
+		Executor().
+		ScanVal(&stockID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find stock of category %d at location %d: %w", categoryID, locationID, err)
+	}
+
+	return stockID, nil
+}
+
+func (r *ShipmentRepository) GetShipment(id int) (*models.Shipment, error) {
+	shipments, err := r.scanShipments(r.getShipmentQuery().Where(goqu.Ex{"s.id": id}))
+	if err != nil {
+		return nil, err
+	}
+	if len(shipments) == 0 {
+		return nil, nil
+	}
+
+	return &shipments[0], nil
+}
+
+func (r *ShipmentRepository) GetShipments(query RetrieveShipmentListQuery) ([]models.Shipment, error) {
+	conditions := goqu.Ex{}
+	if query.Status != "" {
+		conditions["s.status"] = query.Status
+	}
+
+	dataset := r.getShipmentQuery().Where(conditions).Order(goqu.I("s.created_at").Desc(), goqu.I("s.id").Desc())
+	if query.LocationID != 0 {
+		dataset = dataset.Where(goqu.I("s.id").In(
+			r.repository.GoquDBWrapper.From("shipment_legs").Select("shipment_id").Where(goqu.Or(
+				goqu.Ex{"from_location_id": query.LocationID},
+				goqu.Ex{"to_location_id": query.LocationID},
+			)),
+		))
+	}
+
+	return r.scanShipments(dataset)
+}
+
+func (r *ShipmentRepository) scanShipments(dataset *goqu.SelectDataset) ([]models.Shipment, error) {
+	shipments := []models.Shipment{}
+	if err := dataset.Executor().ScanStructs(&shipments); err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if len(shipments) == 0 {
+		return shipments, nil
+	}
+
+	ids := make([]int, len(shipments))
+	for i, shipment := range shipments {
+		ids[i] = shipment.ID
+	}
+	legs, err := r.scanLegs(r.repository.GoquDBWrapper.From, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byShipment := make(map[int][]models.ShipmentLeg, len(shipments))
+	for _, leg := range legs {
+		byShipment[leg.ShipmentID] = append(byShipment[leg.ShipmentID], leg)
+	}
+	for i := range shipments {
+		shipments[i].Legs = byShipment[shipments[i].ID]
+	}
+
+	return shipments, nil
+}
+
+func (r *ShipmentRepository) scanLegs(from func(...interface{}) *goqu.SelectDataset, shipmentIDs []int) ([]models.ShipmentLeg, error) {
+	legs := []models.ShipmentLeg{}
+	err := from(goqu.T("shipment_legs").As("sl")).
+		Select(
+			"sl.id",
+			"sl.shipment_id",
+			"sl.leg_number",
+			"sl.from_location_id",
+			goqu.I("fl.name").As("from_location_name"),
+			"sl.to_location_id",
+			goqu.I("tl.name").As("to_location_name"),
+			"sl.eta",
+			"sl.transfer_id",
+			goqu.I("t.status").As("transfer_status"),
+		).
+		Join(goqu.T("locations").As("fl"), goqu.On(goqu.Ex{"sl.from_location_id": goqu.I("fl.id")})).
+		Join(goqu.T("locations").As("tl"), goqu.On(goqu.Ex{"sl.to_location_id": goqu.I("tl.id")})).
+		LeftJoin(goqu.T("transfers").As("t"), goqu.On(goqu.Ex{"sl.transfer_id": goqu.I("t.id")})).
+		Where(goqu.Ex{"sl.shipment_id": shipmentIDs}).
+		Order(goqu.I("sl.shipment_id").Asc(), goqu.I("sl.leg_number").Asc()).
+		Executor().
+		ScanStructs(&legs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipment legs: %w", err)
+	}
+
+	return legs, nil
+}
+
+func (r *ShipmentRepository) getShipmentQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"s.id",
+		"s.from_location_id",
+		goqu.I("fl.name").As("from_location_name"),
+		"s.to_location_id",
+		goqu.I("tl.name").As("to_location_name"),
+		"s.status",
+		"s.requires_delivery_photo",
+		"s.notes",
+		"s.created_by_id",
+		"s.created_at",
+		"s.completed_at",
+	).
+		From(goqu.T("shipments").As("s")).
+		Join(goqu.T("locations").As("fl"), goqu.On(goqu.Ex{"s.from_location_id": goqu.I("fl.id")})).
+		Join(goqu.T("locations").As("tl"), goqu.On(goqu.Ex{"s.to_location_id": goqu.I("tl.id")}))
+}
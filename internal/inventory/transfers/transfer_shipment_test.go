@@ -0,0 +1,93 @@
+package transfers
+
+import (
+	"testing"
+	"time"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanShipmentLegs(t *testing.T) {
+	hubETA := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	roomETA := hubETA.Add(2 * time.Hour)
+
+	legs, err := planShipmentLegs(1, []ShipmentStopRequest{
+		{LocationID: 5, ETA: &hubETA},
+		{LocationID: 9, ETA: &roomETA},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ShipmentLeg{
+		{Number: 1, FromLocationID: 1, ToLocationID: 5, ETA: &hubETA},
+		{Number: 2, FromLocationID: 5, ToLocationID: 9, ETA: &roomETA},
+	}, legs)
+}
+
+func TestPlanShipmentLegsRejectsInvalidStops(t *testing.T) {
+	hubETA := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	earlier := hubETA.Add(-time.Hour)
+
+	_, err := planShipmentLegs(1, []ShipmentStopRequest{{LocationID: 1}})
+	assert.ErrorIs(t, err, ErrShipmentStops)
+
+	_, err = planShipmentLegs(1, []ShipmentStopRequest{{LocationID: 5}, {LocationID: 5}})
+	assert.ErrorIs(t, err, ErrShipmentStops)
+
+	_, err = planShipmentLegs(1, []ShipmentStopRequest{{LocationID: 5, ETA: &hubETA}, {LocationID: 9, ETA: &earlier}})
+	assert.ErrorIs(t, err, ErrShipmentETAOrder)
+}
+
+func TestResolveShipmentLegsInTransit(t *testing.T) {
+	completed, inTransit := "completed", "in_transit"
+	eta := time.Date(2024, 5, 10, 11, 0, 0, 0, time.UTC)
+	shipment := &models.Shipment{
+		Status: models.ShipmentStatusInTransit,
+		Legs: []models.ShipmentLeg{
+			{Number: 1, TransferID: intPtr(10), TransferStatus: &completed},
+			{Number: 2, TransferID: intPtr(11), TransferStatus: &inTransit, ETA: &eta},
+			{Number: 3},
+		},
+	}
+
+	resolveShipmentLegs(shipment)
+
+	assert.Equal(t, "completed", shipment.Legs[0].Status)
+	assert.Equal(t, "in_transit", shipment.Legs[1].Status)
+	assert.Equal(t, models.ShipmentLegPlanned, shipment.Legs[2].Status)
+	assert.Equal(t, 2, *shipment.CurrentLeg)
+	assert.Equal(t, &eta, shipment.ETA)
+	assert.Equal(t, 11, *currentShipmentLeg(shipment).TransferID)
+}
+
+func TestResolveShipmentLegsClosed(t *testing.T) {
+	completed, cancelled := "completed", "cancelled"
+	shipment := &models.Shipment{
+		Status: models.ShipmentStatusCancelled,
+		Legs: []models.ShipmentLeg{
+			{Number: 1, TransferID: intPtr(10), TransferStatus: &completed},
+			{Number: 2, TransferID: intPtr(11), TransferStatus: &cancelled},
+			{Number: 3},
+		},
+	}
+
+	resolveShipmentLegs(shipment)
+
+	assert.Equal(t, models.ShipmentLegCancelled, shipment.Legs[2].Status)
+	assert.Nil(t, shipment.CurrentLeg)
+	assert.Nil(t, shipment.ETA)
+	assert.Nil(t, currentShipmentLeg(shipment))
+}
+
+func TestNextShipmentLeg(t *testing.T) {
+	legs := []models.ShipmentLeg{
+		{Number: 1, TransferID: intPtr(10)},
+		{Number: 2, TransferID: intPtr(11)},
+		{Number: 3},
+	}
+
+	assert.Equal(t, 2, nextShipmentLeg(legs, 10).Number)
+	assert.Equal(t, 3, nextShipmentLeg(legs, 11).Number)
+	assert.Nil(t, nextShipmentLeg(legs[:2], 11))
+	assert.Nil(t, nextShipmentLeg(legs, 99))
+}
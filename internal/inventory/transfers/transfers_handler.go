@@ -1,16 +1,21 @@
 package transfers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"warehouse/internal/inventory/assets"
 	inventorylog "warehouse/internal/inventory/inventory_log"
+	"warehouse/internal/inventory/kits"
+	"warehouse/internal/inventory/reservations"
+	"warehouse/internal/inventory/retirements"
 	"warehouse/internal/inventory/stocks"
 	"warehouse/internal/repository"
 	"warehouse/internal/users"
 	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
 	"warehouse/pkg/metadata"
 	"warehouse/pkg/models"
 	"warehouse/pkg/security"
@@ -28,15 +33,16 @@ func NewHandler(
 	r *repository.Repository,
 	tr TransferRepository,
 	ar *assets.AssetsRepository,
+	stockRepo *stocks.StockRepository,
 	ur users.UserRepository,
+	rr *reservations.ReservationRepository,
 	a *auditlog.Auditlog,
 ) *TransferHandler {
-	stockRepo := stocks.NewRepository(r)
 	inventorylog := inventorylog.NewInventoryLog(a)
 
 	return &TransferHandler{
 		TransferRepository: tr,
-		Service:            &TransferService{r, tr, ar, stockRepo, ur, inventorylog},
+		Service:            &TransferService{r, tr, ar, stockRepo, ur, inventorylog, kits.NewRepository(r), rr, retirements.NewRepository(r), NewShipmentRepository(r), deliveryGeofenceRadius()},
 		AssetRepo:          ar,
 	}
 }
@@ -46,12 +52,21 @@ func (h *TransferHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/transfers", h.RetrieveTransferList)
 	router.GET("/transfers/users/:user_id", h.GetTransfersByUserAndStatus)
 	router.POST("/transfers", h.CreateTransfer)
+	router.POST("/transfers/:id/return", h.ReturnTransfer)
+	router.POST("/transfers/recall", h.RecallLocation)
 	router.PATCH("/transfers/:id/confirm", h.ConfirmTransfer)
+	router.PATCH("/transfers/:id/delivery", h.ConfirmDelivery)
+	router.PATCH("/transfers/:id/write-off", security.Authorize("moderator"), h.WriteOffDelivery)
 	router.PATCH("/transfers/:id/cancel", h.CancelTransfer)
 	router.PATCH("/transfers/:id/assets/:item_id/restore-to-location", h.RemoveAssetFromTransfer)
 	router.PATCH("/transfers/:id/categories/:category_id/restore-to-location", h.RemoveStockItemFromTransfer)
 	router.PATCH("/transfers/:id/delivery-location", h.UpdateDeliveryLocation)
 	router.PUT("/transfers/:id/users", h.UpdateTransferUsers)
+	router.GET("/shipments", h.GetShipments)
+	router.GET("/shipments/:id", h.GetShipment)
+	router.POST("/shipments", h.CreateShipment)
+	router.PATCH("/shipments/:id/legs/:leg/confirm", h.ConfirmShipmentLeg)
+	router.PATCH("/shipments/:id/cancel", h.CancelShipment)
 }
 
 func (h *TransferHandler) GetTransfer(c *gin.Context) {
@@ -133,7 +148,13 @@ func (h *TransferHandler) CreateTransfer(c *gin.Context) {
 	}
 
 	transferID, err := h.Service.InitTransfer(req, itemTransitStatus)
-	if err != nil {
+	if errors.Is(err, reservations.ErrReservationNotFound) || errors.Is(err, reservations.ErrReservationUnavailable) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Reservation cannot be used for this transfer", "code": "reservation_unavailable", "details": err.Error()})
+		return
+	} else if custom_error.IsInvalidStatusTransition(err) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Asset cannot be transferred in its current status", "details": err.Error()})
+		return
+	} else if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Unable to transfer items", "details": err.Error()})
 		return
 	}
@@ -164,8 +185,11 @@ func (h *TransferHandler) RemoveAssetFromTransfer(c *gin.Context) {
 		return
 	}
 
-	err := h.AssetRepo.RemoveAssetFromTransfer(req.ID, req.ItemID, req.LocationID)
-	if err != nil {
+	err := h.Service.RemoveAssetFromTransfer(req)
+	if custom_error.IsInvalidStatusTransition(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Asset cannot be restored in its current status", "details": err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -230,9 +254,10 @@ func (h *TransferHandler) GetTransfersByUserAndStatus(c *gin.Context) {
 	}
 
 	validStatuses := map[string]bool{
-		"in_transit": true,
-		"completed":  true,
-		"cancelled":  true,
+		"in_transit":          true,
+		"partially_delivered": true,
+		"completed":           true,
+		"cancelled":           true,
 	}
 	if !validStatuses[status] {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy status transferu"})
@@ -267,9 +292,8 @@ func (h *TransferHandler) ConfirmTransfer(c *gin.Context) {
 		return
 	}
 
-	err = h.Service.ConfirmTransfer(transferID, "completed")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to confirm transfer", "details": err.Error()})
+	if err := h.Service.ConfirmTransfer(transferID); err != nil {
+		h.handleDeliveryError(c, "Unable to confirm transfer", err)
 		return
 	}
 
@@ -278,6 +302,76 @@ func (h *TransferHandler) ConfirmTransfer(c *gin.Context) {
 	})
 }
 
+func (h *TransferHandler) ConfirmDelivery(c *gin.Context) {
+	transferID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID parameter, must be an integer"})
+		return
+	}
+
+	var req ConfirmDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if err := h.Service.ConfirmDelivery(transferID, req); err != nil {
+		h.handleDeliveryError(c, "Nie udało się potwierdzić dostawy", err)
+		return
+	}
+
+	transfer, err := h.Service.GetTransfer(transferID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Nie można pobrać transferu", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func (h *TransferHandler) WriteOffDelivery(c *gin.Context) {
+	transferID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID parameter, must be an integer"})
+		return
+	}
+
+	var req WriteOffDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.WrittenByID = &userID
+	}
+
+	if err := h.Service.WriteOffDelivery(transferID, req); err != nil {
+		h.handleDeliveryError(c, "Nie udało się spisać braków dostawy", err)
+		return
+	}
+
+	transfer, err := h.Service.GetTransfer(transferID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Nie można pobrać transferu", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func (h *TransferHandler) handleDeliveryError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrDeliveryPhotoRequired):
+		c.JSON(http.StatusConflict, gin.H{"error": "Transfer requires a delivery photo", "code": "delivery_photo_required", "details": err.Error()})
+	case errors.Is(err, ErrEmptyDelivery), errors.Is(err, ErrDeliveryLineNotFound), errors.Is(err, ErrReceivedQuantity):
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrDeliveryLineResolved), errors.Is(err, ErrWriteOffNotMissing), custom_error.IsInvalidStatusTransition(err):
+		c.JSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+	}
+}
+
 func (h *TransferHandler) CancelTransfer(c *gin.Context) {
 	transferID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -297,7 +391,10 @@ func (h *TransferHandler) CancelTransfer(c *gin.Context) {
 	}
 
 	err = h.Service.CancelTransfer(transfer)
-	if err != nil {
+	if custom_error.IsInvalidStatusTransition(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Transfer cannot be cancelled in its current status", "details": err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to cancel transfer", "details": err.Error()})
 		return
 	}
@@ -326,18 +423,21 @@ func (h *TransferHandler) UpdateDeliveryLocation(c *gin.Context) {
 		return
 	}
 
-	if transfer.Status != "in_transit" && transfer.Status != "completed" {
+	if transfer.Status != "in_transit" && transfer.Status != "partially_delivered" && transfer.Status != "completed" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Można zaktualizować lokalizację tylko dla transferów w trakcie lub zakończonych"})
 		return
 	}
 
-	err = h.Service.UpdateDeliveryLocation(transferID, req.DeliveryLocation.Lat, req.DeliveryLocation.Lng, req.DeliveryLocation.Timestamp)
-	if err != nil {
+	location, err := h.Service.UpdateDeliveryLocation(transferID, req.DeliveryLocation)
+	if errors.Is(err, ErrInvalidCoordinates) || errors.Is(err, ErrInvalidDeliveryTimestamp) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowa lokalizacja dostawy", "details": err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Nie można zaktualizować lokalizacji dostawy", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Lokalizacja dostawy została zaktualizowana"})
+	c.JSON(http.StatusOK, gin.H{"message": "Lokalizacja dostawy została zaktualizowana", "delivery_location": location})
 }
 
 func (h *TransferHandler) UpdateTransferUsers(c *gin.Context) {
@@ -0,0 +1,157 @@
+package transfertemplates
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"warehouse/internal/inventory/reservations"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferTemplateHandler struct {
+	Service *TransferTemplateService
+}
+
+func NewHandler(r *repository.Repository, rr *reservations.ReservationRepository, a *auditlog.Auditlog) *TransferTemplateHandler {
+	return &TransferTemplateHandler{
+		Service: NewService(r, NewRepository(r), rr, a),
+	}
+}
+
+func (h *TransferTemplateHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/transfer-templates", security.Authorize("user"), h.GetTemplates)
+	router.GET("/transfer-templates/:id", security.Authorize("user"), h.GetTemplate)
+	router.POST("/transfer-templates", security.Authorize("moderator"), h.CreateTemplate)
+	router.PUT("/transfer-templates/:id", security.Authorize("moderator"), h.UpdateTemplate)
+	router.DELETE("/transfer-templates/:id", security.Authorize("moderator"), h.DeleteTemplate)
+	router.POST("/transfer-templates/:id/draft", security.Authorize("user"), h.DraftTransfer)
+}
+
+func (h *TransferTemplateHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.Service.GetTemplates()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Błąd pobierania szablonów transferów", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *TransferTemplateHandler) GetTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	template, err := h.Service.GetTemplate(id)
+	if err != nil {
+		h.handleError(c, "Błąd pobierania szablonu transferu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *TransferTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req SaveTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	if userID, err := security.GetUserIDFromContext(c); err == nil {
+		req.CreatedByID = &userID
+	}
+
+	template, err := h.Service.CreateTemplate(req)
+	if err != nil {
+		h.handleError(c, "Nie udało się utworzyć szablonu transferu", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *TransferTemplateHandler) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	var req SaveTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	template, err := h.Service.UpdateTemplate(id, req)
+	if err != nil {
+		h.handleError(c, "Nie udało się zmienić szablonu transferu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *TransferTemplateHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	if err := h.Service.DeleteTemplate(id); err != nil {
+		h.handleError(c, "Nie udało się usunąć szablonu transferu", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DraftTransfer only returns the picked items, the client keeps the draft and creates the transfer itself
+func (h *TransferTemplateHandler) DraftTransfer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format ID"})
+		return
+	}
+
+	var req DraftTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Nieprawidłowy format danych", "details": err.Error()})
+		return
+	}
+
+	draft, err := h.Service.DraftTransfer(id, req)
+	if err != nil {
+		h.handleError(c, "Nie udało się przygotować transferu z szablonu", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+func (h *TransferTemplateHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, ErrTemplateNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": msg, "details": err.Error()})
+	case errors.Is(err, ErrDuplicateTemplateLine), errors.Is(err, ErrDraftDestination), errors.Is(err, ErrSameLocation):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+	default:
+		switch err.(type) {
+		case *custom_error.UniqueViolationError:
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": msg, "details": err.Error()})
+		case *custom_error.ForeignKeyViolationError:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": msg, "details": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": msg, "details": err.Error()})
+		}
+	}
+}
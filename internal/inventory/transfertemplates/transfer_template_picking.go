@@ -0,0 +1,71 @@
+package transfertemplates
+
+import (
+	"sort"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+)
+
+// pickTemplateItems fills the template lines from the candidates. Assets keep their order, oldest PYR code
+// first, stock goes from the largest rows to split the quantity as little as possible. Items of the preferred
+// origin are taken before any other. Lines which cannot be filled are reported as shortages.
+func pickTemplateItems(lines []models.TransferTemplateLine, assets []candidateAsset, stock []candidateStock, preferredOrigin string) ([]models.AssetItemRequest, []models.StockItemRequest, []models.TransferDraftShortage) {
+	preferred := func(origin *string) bool {
+		return preferredOrigin != "" && origin != nil && metadata.NewOrigin(*origin).String() == preferredOrigin
+	}
+
+	assets = append([]candidateAsset(nil), assets...)
+	sort.SliceStable(assets, func(i, j int) bool {
+		return preferred(assets[i].Origin) && !preferred(assets[j].Origin)
+	})
+	stock = append([]candidateStock(nil), stock...)
+	sort.SliceStable(stock, func(i, j int) bool {
+		if preferred(stock[i].Origin) != preferred(stock[j].Origin) {
+			return preferred(stock[i].Origin)
+		}
+		return stock[i].Quantity > stock[j].Quantity
+	})
+
+	pickedAssets := []models.AssetItemRequest{}
+	pickedStock := []models.StockItemRequest{}
+	shortages := []models.TransferDraftShortage{}
+
+	for _, line := range lines {
+		picked := 0
+		if line.CategoryType == "stock" {
+			for _, row := range stock {
+				if picked == line.Quantity {
+					break
+				}
+				if row.CategoryID != line.CategoryID || row.Quantity <= 0 {
+					continue
+				}
+				quantity := min(row.Quantity, line.Quantity-picked)
+				pickedStock = append(pickedStock, models.StockItemRequest{ID: row.ID, Quantity: quantity})
+				picked += quantity
+			}
+		} else {
+			for _, asset := range assets {
+				if picked == line.Quantity {
+					break
+				}
+				if asset.CategoryID != line.CategoryID || !metadata.Status(asset.Status).CanTransitionTo(metadata.StatusInTransit) {
+					continue
+				}
+				pickedAssets = append(pickedAssets, models.AssetItemRequest{ID: asset.ID})
+				picked++
+			}
+		}
+
+		if picked < line.Quantity {
+			shortages = append(shortages, models.TransferDraftShortage{
+				CategoryID:    line.CategoryID,
+				CategoryLabel: line.CategoryLabel,
+				Requested:     line.Quantity,
+				Picked:        picked,
+			})
+		}
+	}
+
+	return pickedAssets, pickedStock, shortages
+}
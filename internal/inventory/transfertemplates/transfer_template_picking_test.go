@@ -0,0 +1,76 @@
+package transfertemplates
+
+import (
+	"testing"
+	"warehouse/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestPickTemplateItemsTakesOldestAssetsFirst(t *testing.T) {
+	lines := []models.TransferTemplateLine{{CategoryID: 1, CategoryType: "asset", Quantity: 2}}
+	assets := []candidateAsset{
+		{ID: 30, CategoryID: 1, PyrCode: strPtr("PYR-P1"), Status: "available"},
+		{ID: 10, CategoryID: 1, PyrCode: strPtr("PYR-P2"), Status: "in_repair"},
+		{ID: 20, CategoryID: 1, PyrCode: strPtr("PYR-P10"), Status: "available"},
+		{ID: 40, CategoryID: 1, PyrCode: strPtr("PYR-P11"), Status: "available"},
+	}
+
+	pickedAssets, pickedStock, shortages := pickTemplateItems(lines, assets, nil, "")
+
+	assert.Equal(t, []models.AssetItemRequest{{ID: 30}, {ID: 20}}, pickedAssets)
+	assert.Empty(t, pickedStock)
+	assert.Empty(t, shortages)
+}
+
+func TestPickTemplateItemsPrefersOrigin(t *testing.T) {
+	lines := []models.TransferTemplateLine{
+		{CategoryID: 1, CategoryType: "asset", Quantity: 1},
+		{CategoryID: 2, CategoryType: "stock", Quantity: 5},
+	}
+	assets := []candidateAsset{
+		{ID: 30, CategoryID: 1, Origin: strPtr("own"), Status: "available"},
+		{ID: 20, CategoryID: 1, Origin: strPtr("Rental Co"), Status: "available"},
+	}
+	stock := []candidateStock{
+		{ID: 7, CategoryID: 2, Origin: strPtr("own"), Quantity: 50},
+		{ID: 8, CategoryID: 2, Origin: strPtr("rental-co"), Quantity: 3},
+	}
+
+	pickedAssets, pickedStock, shortages := pickTemplateItems(lines, assets, stock, "rental-co")
+
+	assert.Equal(t, []models.AssetItemRequest{{ID: 20}}, pickedAssets)
+	assert.Equal(t, []models.StockItemRequest{{ID: 8, Quantity: 3}, {ID: 7, Quantity: 2}}, pickedStock)
+	assert.Empty(t, shortages)
+}
+
+func TestPickTemplateItemsReportsShortages(t *testing.T) {
+	lines := []models.TransferTemplateLine{
+		{CategoryID: 1, CategoryLabel: "Laptop", CategoryType: "asset", Quantity: 2},
+		{CategoryID: 2, CategoryLabel: "Extension cord", CategoryType: "stock", Quantity: 4},
+	}
+	assets := []candidateAsset{{ID: 30, CategoryID: 1, Status: "located"}}
+	stock := []candidateStock{
+		{ID: 7, CategoryID: 2, Quantity: 1},
+		{ID: 8, CategoryID: 2, Quantity: 0},
+		{ID: 9, CategoryID: 3, Quantity: 10},
+	}
+
+	pickedAssets, pickedStock, shortages := pickTemplateItems(lines, assets, stock, "")
+
+	assert.Equal(t, []models.AssetItemRequest{{ID: 30}}, pickedAssets)
+	assert.Equal(t, []models.StockItemRequest{{ID: 7, Quantity: 1}}, pickedStock)
+	assert.Equal(t, []models.TransferDraftShortage{
+		{CategoryID: 1, CategoryLabel: "Laptop", Requested: 2, Picked: 1},
+		{CategoryID: 2, CategoryLabel: "Extension cord", Requested: 4, Picked: 1},
+	}, shortages)
+}
+
+func TestValidateTemplateLinesRejectsDuplicateCategories(t *testing.T) {
+	assert.NoError(t, validateTemplateLines([]TemplateLineRequest{{CategoryID: 1, Quantity: 1}, {CategoryID: 2, Quantity: 2}}))
+	assert.ErrorIs(t, validateTemplateLines([]TemplateLineRequest{{CategoryID: 1, Quantity: 1}, {CategoryID: 1, Quantity: 2}}), ErrDuplicateTemplateLine)
+}
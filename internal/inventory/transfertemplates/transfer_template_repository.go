@@ -0,0 +1,242 @@
+package transfertemplates
+
+import (
+	"fmt"
+	"time"
+	"warehouse/internal/repository"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+)
+
+type TransferTemplateRepository struct {
+	repository *repository.Repository
+}
+
+// candidateAsset is an asset at the source location which may be picked for a template line
+type candidateAsset struct {
+	ID         int     `db:"id"`
+	CategoryID int     `db:"item_category_id"`
+	PyrCode    *string `db:"pyr_code"`
+	Origin     *string `db:"origin"`
+	Status     string  `db:"status"`
+}
+
+// candidateStock is a stock row at the source location, Quantity is lowered by reservations before picking
+type candidateStock struct {
+	ID         int     `db:"id"`
+	CategoryID int     `db:"item_category_id"`
+	Origin     *string `db:"origin"`
+	Quantity   int     `db:"quantity"`
+}
+
+func NewRepository(r *repository.Repository) *TransferTemplateRepository {
+	return &TransferTemplateRepository{
+		repository: r,
+	}
+}
+
+func (r *TransferTemplateRepository) InsertTemplate(tx *goqu.TxDatabase, req SaveTemplateRequest) (int, error) {
+	record := goqu.Record{
+		"name":                req.Name,
+		"default_location_id": req.DefaultLocationID,
+		"notes":               req.Notes,
+		"created_by_id":       req.CreatedByID,
+	}
+
+	var id int
+	if _, err := tx.Insert("transfer_templates").Rows(record).Returning("id").Executor().ScanVal(&id); err != nil {
+		return 0, wrapTemplateError(err, "failed to insert transfer template")
+	}
+
+	return id, nil
+}
+
+func (r *TransferTemplateRepository) UpdateTemplate(tx *goqu.TxDatabase, id int, req SaveTemplateRequest) (bool, error) {
+	result, err := tx.Update("transfer_templates").
+		Set(goqu.Record{
+			"name":                req.Name,
+			"default_location_id": req.DefaultLocationID,
+			"notes":               req.Notes,
+			"updated_at":          time.Now(),
+		}).
+		Where(goqu.Ex{"id": id}).
+		Executor().
+		Exec()
+	if err != nil {
+		return false, wrapTemplateError(err, "failed to update transfer template")
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update transfer template: %w", err)
+	}
+
+	return updated > 0, nil
+}
+
+// ReplaceTemplateLines swaps all lines of a template for the requested ones
+func (r *TransferTemplateRepository) ReplaceTemplateLines(tx *goqu.TxDatabase, id int, lines []TemplateLineRequest) error {
+	if _, err := tx.Delete("transfer_template_lines").Where(goqu.Ex{"template_id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to remove transfer template lines: %w", err)
+	}
+
+	rows := make([]goqu.Record, len(lines))
+	for i, line := range lines {
+		rows[i] = goqu.Record{
+			"template_id":      id,
+			"item_category_id": line.CategoryID,
+			"quantity":         line.Quantity,
+		}
+	}
+	if _, err := tx.Insert("transfer_template_lines").Rows(rows).Executor().Exec(); err != nil {
+		return wrapTemplateError(err, "failed to insert transfer template lines")
+	}
+
+	return nil
+}
+
+func (r *TransferTemplateRepository) DeleteTemplate(id int) error {
+	if _, err := r.repository.GoquDBWrapper.Delete("transfer_templates").Where(goqu.Ex{"id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to delete transfer template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TransferTemplateRepository) GetTemplate(id int) (*models.TransferTemplate, error) {
+	templates, err := r.scanTemplates(r.getTemplateQuery().Where(goqu.Ex{"t.id": id}))
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+
+	return &templates[0], nil
+}
+
+func (r *TransferTemplateRepository) GetTemplates() ([]models.TransferTemplate, error) {
+	return r.scanTemplates(r.getTemplateQuery().Order(goqu.I("t.name").Asc()))
+}
+
+// GetCandidateAssets lists assets of the categories at the location, oldest PYR codes first. Codes of one
+// category differ only in their number, so a shorter code is an older one.
+func (r *TransferTemplateRepository) GetCandidateAssets(categoryIDs []int, locationID int) ([]candidateAsset, error) {
+	assets := []candidateAsset{}
+	if len(categoryIDs) == 0 {
+		return assets, nil
+	}
+
+	err := r.repository.GoquDBWrapper.
+		Select("id", "item_category_id", "pyr_code", "origin", "status").
+		From("items").
+		Where(goqu.Ex{"item_category_id": categoryIDs, "location_id": locationID}).
+		Order(
+			goqu.L("pyr_code IS NULL").Asc(),
+			goqu.L("LENGTH(pyr_code)").Asc(),
+			goqu.I("pyr_code").Asc(),
+			goqu.I("id").Asc(),
+		).
+		Executor().
+		ScanStructs(&assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assets at location %d: %w", locationID, err)
+	}
+
+	return assets, nil
+}
+
+func (r *TransferTemplateRepository) GetCandidateStock(categoryIDs []int, locationID int) ([]candidateStock, error) {
+	stock := []candidateStock{}
+	if len(categoryIDs) == 0 {
+		return stock, nil
+	}
+
+	err := r.repository.GoquDBWrapper.
+		Select("id", "item_category_id", "origin", "quantity").
+		From("non_serialized_items").
+		Where(goqu.Ex{"item_category_id": categoryIDs, "location_id": locationID}, goqu.I("quantity").Gt(0)).
+		Order(goqu.I("id").Asc()).
+		Executor().
+		ScanStructs(&stock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock at location %d: %w", locationID, err)
+	}
+
+	return stock, nil
+}
+
+func (r *TransferTemplateRepository) scanTemplates(query *goqu.SelectDataset) ([]models.TransferTemplate, error) {
+	templates := []models.TransferTemplate{}
+	if err := query.Executor().ScanStructs(&templates); err != nil {
+		return nil, fmt.Errorf("unable to execute SQL: %w", err)
+	}
+	if len(templates) == 0 {
+		return templates, nil
+	}
+
+	ids := make([]int, len(templates))
+	index := make(map[int]int, len(templates))
+	for i := range templates {
+		ids[i] = templates[i].ID
+		index[templates[i].ID] = i
+		templates[i].Lines = []models.TransferTemplateLine{}
+	}
+
+	var lines []models.TransferTemplateLine
+	err := r.repository.GoquDBWrapper.
+		Select(
+			"tl.template_id",
+			"tl.item_category_id",
+			goqu.I("c.label").As("category_label"),
+			goqu.I("c.category_type").As("category_type"),
+			"tl.quantity",
+		).
+		From(goqu.T("transfer_template_lines").As("tl")).
+		Join(goqu.T("item_category").As("c"), goqu.On(goqu.Ex{"tl.item_category_id": goqu.I("c.id")})).
+		Where(goqu.Ex{"tl.template_id": ids}).
+		Order(goqu.I("tl.id").Asc()).
+		Executor().
+		ScanStructs(&lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfer template lines: %w", err)
+	}
+
+	for _, line := range lines {
+		i := index[line.TemplateID]
+		templates[i].Lines = append(templates[i].Lines, line)
+	}
+
+	return templates, nil
+}
+
+func (r *TransferTemplateRepository) getTemplateQuery() *goqu.SelectDataset {
+	return r.repository.GoquDBWrapper.Select(
+		"t.id",
+		"t.name",
+		"t.default_location_id",
+		goqu.I("l.name").As("default_location_name"),
+		"t.notes",
+		"t.created_by_id",
+		"t.created_at",
+		"t.updated_at",
+	).
+		From(goqu.T("transfer_templates").As("t")).
+		LeftJoin(goqu.T("locations").As("l"), goqu.On(goqu.Ex{"t.default_location_id": goqu.I("l.id")}))
+}
+
+// wrapTemplateError reports a taken name and unknown categories or locations as custom errors
+func wrapTemplateError(err error, msg string) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23505":
+			return custom_error.WrapDBError("Szablon o tej nazwie już istnieje", string(pqErr.Code))
+		case "23503":
+			return custom_error.WrapDBError("Nieprawidłowa kategoria lub lokalizacja", string(pqErr.Code))
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
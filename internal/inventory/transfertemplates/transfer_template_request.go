@@ -0,0 +1,21 @@
+package transfertemplates
+
+type SaveTemplateRequest struct {
+	Name              string                `json:"name" binding:"required"`
+	DefaultLocationID *int                  `json:"default_location_id"`
+	Notes             *string               `json:"notes"`
+	Lines             []TemplateLineRequest `json:"lines" binding:"required,min=1,dive"`
+	CreatedByID       *int                  `json:"-"`
+}
+
+type TemplateLineRequest struct {
+	CategoryID int `json:"category_id" binding:"required"`
+	Quantity   int `json:"quantity" binding:"required,gte=1"`
+}
+
+// DraftTransferRequest picks items for a template at the source location, the destination defaults to the one of the template
+type DraftTransferRequest struct {
+	FromLocationID  int     `json:"from_location_id" binding:"required"`
+	LocationID      *int    `json:"location_id"`
+	PreferredOrigin *string `json:"preferred_origin"`
+}
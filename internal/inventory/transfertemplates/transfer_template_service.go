@@ -0,0 +1,248 @@
+package transfertemplates
+
+import (
+	"errors"
+	"time"
+	"warehouse/internal/inventory/reservations"
+	"warehouse/internal/repository"
+	"warehouse/pkg/auditlog"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var (
+	ErrTemplateNotFound      = errors.New("transfer template not found")
+	ErrDuplicateTemplateLine = errors.New("every category can appear only once in a template")
+	ErrDraftDestination      = errors.New("template has no default destination, location_id is required")
+	ErrSameLocation          = errors.New("transfer from and to location cannot be the same")
+)
+
+type TransferTemplateService struct {
+	r  *repository.Repository
+	tr *TransferTemplateRepository
+	rr *reservations.ReservationRepository
+	a  *auditlog.Auditlog
+}
+
+func NewService(r *repository.Repository, tr *TransferTemplateRepository, rr *reservations.ReservationRepository, a *auditlog.Auditlog) *TransferTemplateService {
+	return &TransferTemplateService{
+		r:  r,
+		tr: tr,
+		rr: rr,
+		a:  a,
+	}
+}
+
+func (s *TransferTemplateService) GetTemplates() ([]models.TransferTemplate, error) {
+	return s.tr.GetTemplates()
+}
+
+func (s *TransferTemplateService) GetTemplate(id int) (*models.TransferTemplate, error) {
+	template, err := s.tr.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	return template, nil
+}
+
+func (s *TransferTemplateService) CreateTemplate(req SaveTemplateRequest) (*models.TransferTemplate, error) {
+	if err := validateTemplateLines(req.Lines); err != nil {
+		return nil, err
+	}
+
+	var id int
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		var err error
+		if id, err = s.tr.InsertTemplate(tx, req); err != nil {
+			return err
+		}
+		return s.tr.ReplaceTemplateLines(tx, id, req.Lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.logTemplate(id, "create", "Dodano szablon transferu")
+}
+
+// UpdateTemplate replaces the name, destination, notes and all lines of a template
+func (s *TransferTemplateService) UpdateTemplate(id int, req SaveTemplateRequest) (*models.TransferTemplate, error) {
+	if err := validateTemplateLines(req.Lines); err != nil {
+		return nil, err
+	}
+
+	err := repository.WithTransaction(s.r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		updated, err := s.tr.UpdateTemplate(tx, id, req)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return ErrTemplateNotFound
+		}
+		return s.tr.ReplaceTemplateLines(tx, id, req.Lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.logTemplate(id, "update", "Zmieniono szablon transferu")
+}
+
+func (s *TransferTemplateService) DeleteTemplate(id int) error {
+	template, err := s.GetTemplate(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tr.DeleteTemplate(id); err != nil {
+		return err
+	}
+
+	go s.a.Log("delete", map[string]interface{}{
+		"name": template.Name,
+		"msg":  "Usunięto szablon transferu",
+	}, template)
+
+	return nil
+}
+
+// DraftTransfer picks available assets and stock for the template at the source location. The draft is not
+// persisted and nothing is held: the client edits it and dispatches it through POST /transfers, which validates
+// the items again.
+func (s *TransferTemplateService) DraftTransfer(id int, req DraftTransferRequest) (*models.TransferDraft, error) {
+	template, err := s.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	locationID := template.DefaultLocationID
+	if req.LocationID != nil {
+		locationID = req.LocationID
+	}
+	if locationID == nil {
+		return nil, ErrDraftDestination
+	}
+	if *locationID == req.FromLocationID {
+		return nil, ErrSameLocation
+	}
+
+	var assetCategories, stockCategories []int
+	for _, line := range template.Lines {
+		if line.CategoryType == "stock" {
+			stockCategories = append(stockCategories, line.CategoryID)
+		} else {
+			assetCategories = append(assetCategories, line.CategoryID)
+		}
+	}
+
+	assets, err := s.availableAssets(assetCategories, req.FromLocationID)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.availableStock(stockCategories, req.FromLocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferredOrigin := ""
+	if req.PreferredOrigin != nil {
+		preferredOrigin = metadata.NewOrigin(*req.PreferredOrigin).String()
+	}
+	pickedAssets, pickedStock, shortages := pickTemplateItems(template.Lines, assets, stock, preferredOrigin)
+
+	return &models.TransferDraft{
+		TemplateID: template.ID,
+		Transfer: models.TransferRequest{
+			FromLocationID:      req.FromLocationID,
+			LocationID:          *locationID,
+			AssetItemCollection: pickedAssets,
+			StockItemCollection: pickedStock,
+		},
+		Shortages: shortages,
+	}, nil
+}
+
+// availableAssets leaves out assets held by reservations
+func (s *TransferTemplateService) availableAssets(categoryIDs []int, locationID int) ([]candidateAsset, error) {
+	assets, err := s.tr.GetCandidateAssets(categoryIDs, locationID)
+	if err != nil || len(assets) == 0 {
+		return assets, err
+	}
+
+	ids := make([]int, len(assets))
+	for i, asset := range assets {
+		ids[i] = asset.ID
+	}
+	held, err := s.rr.GetHeldAssets(ids, 0, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	isHeld := make(map[int]bool, len(held))
+	for _, id := range held {
+		isHeld[id] = true
+	}
+
+	available := make([]candidateAsset, 0, len(assets))
+	for _, asset := range assets {
+		if !isHeld[asset.ID] {
+			available = append(available, asset)
+		}
+	}
+
+	return available, nil
+}
+
+// availableStock lowers stock quantities by what reservations hold
+func (s *TransferTemplateService) availableStock(categoryIDs []int, locationID int) ([]candidateStock, error) {
+	stock, err := s.tr.GetCandidateStock(categoryIDs, locationID)
+	if err != nil || len(stock) == 0 {
+		return stock, err
+	}
+
+	ids := make([]int, len(stock))
+	for i, row := range stock {
+		ids[i] = row.ID
+	}
+	unreserved, err := s.rr.GetUnreservedStock(ids, 0, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range stock {
+		if quantity, ok := unreserved[stock[i].ID]; ok {
+			stock[i].Quantity = max(quantity, 0)
+		}
+	}
+
+	return stock, nil
+}
+
+func (s *TransferTemplateService) logTemplate(id int, action, msg string) (*models.TransferTemplate, error) {
+	template, err := s.GetTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.a.Log(action, map[string]interface{}{
+		"name": template.Name,
+		"msg":  msg,
+	}, template)
+
+	return template, nil
+}
+
+func validateTemplateLines(lines []TemplateLineRequest) error {
+	seen := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if seen[line.CategoryID] {
+			return ErrDuplicateTemplateLine
+		}
+		seen[line.CategoryID] = true
+	}
+	return nil
+}
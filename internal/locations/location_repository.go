@@ -11,6 +11,8 @@ import (
 	"github.com/lib/pq"
 )
 
+var locationColumns = []interface{}{"id", "name", "details", "pavilion", "latitude", "longitude", "geofence_radius"}
+
 type LocationRepository struct {
 	Repository *repository.Repository
 }
@@ -26,7 +28,7 @@ func NewLocationRepository(r *repository.Repository) *LocationRepository {
 
 func (r *LocationRepository) GetLocations() (*[]models.Location, error) {
 	var locations = []models.Location{}
-	query := r.Repository.GoquDBWrapper.Select("id", "name", "details", "pavilion").From("locations").Order(goqu.C("id").Asc())
+	query := r.Repository.GoquDBWrapper.Select(locationColumns...).From("locations").Order(goqu.C("id").Asc())
 	if err := query.Executor().ScanStructs(&locations); err != nil {
 		return nil, fmt.Errorf("unable to execute SQL: %w", err)
 	}
@@ -53,9 +55,12 @@ func (r *LocationRepository) GetLocationEquipment(locationID string) (*models.Lo
 func (r *LocationRepository) PersistLocation(location *models.Location) error {
 	query := r.Repository.GoquDBWrapper.Insert("locations").
 		Rows(goqu.Record{
-			"name":     location.Name,
-			"details":  location.Details,
-			"pavilion": location.Pavilion,
+			"name":            location.Name,
+			"details":         location.Details,
+			"pavilion":        location.Pavilion,
+			"latitude":        location.Latitude,
+			"longitude":       location.Longitude,
+			"geofence_radius": location.GeofenceRadius,
 		}).
 		Returning("id")
 
@@ -84,6 +89,13 @@ func (r *LocationRepository) UpdateLocation(locationID string, req UpdateLocatio
 	if req.Pavilion != nil {
 		updates["pavilion"] = *req.Pavilion
 	}
+	if req.Latitude != nil && req.Longitude != nil {
+		updates["latitude"] = *req.Latitude
+		updates["longitude"] = *req.Longitude
+	}
+	if req.GeofenceRadius != nil {
+		updates["geofence_radius"] = *req.GeofenceRadius
+	}
 	if len(updates) == 0 {
 		return models.Location{}, fmt.Errorf("no fields to update")
 	}
@@ -92,7 +104,7 @@ func (r *LocationRepository) UpdateLocation(locationID string, req UpdateLocatio
 		Update("locations").
 		Set(updates).
 		Where(goqu.Ex{"id": locationID}).
-		Returning("id", "name", "details", "pavilion")
+		Returning(locationColumns...)
 
 	var loc models.Location
 
@@ -141,7 +153,8 @@ func (r *LocationRepository) getLocationAssets(locationID string) ([]models.Asse
 			"c.item_category",
 			"c.label",
 		)
-	query = r.prepareQueryConditions(query, locationID)
+	query = r.prepareQueryConditions(query, locationID).
+		Where(goqu.I("i.retired_at").IsNull())
 	rows, err := query.Executor().Query()
 
 	if err != nil {
@@ -233,6 +246,7 @@ func (r *LocationRepository) SearchLocationItems(locationID string, searchQuery
 		Where(goqu.Ex{
 			"i.location_id": locationID,
 		}).
+		Where(goqu.I("i.retired_at").IsNull()).
 		Where(goqu.Or(
 			goqu.I("i.item_serial").ILike("%"+searchQuery+"%"),
 			goqu.I("c.item_category").ILike("%"+searchQuery+"%"),
@@ -269,7 +283,7 @@ func (r *LocationRepository) SearchLocationItems(locationID string, searchQuery
 func (r *LocationRepository) GetLocationDetails(locationID string) (*models.Location, error) {
 	var location models.Location
 	query := r.Repository.GoquDBWrapper.
-		Select("id", "name", "details", "pavilion").
+		Select(locationColumns...).
 		From("locations").
 		Where(goqu.Ex{"id": locationID})
 
@@ -4,4 +4,8 @@ type UpdateLocationRequest struct {
 	Name     *string `json:"name,omitempty"`
 	Details  *string `json:"details,omitempty"`
 	Pavilion *string `json:"pavilion,omitempty"`
+	// Coordinates are set together, geofence radius is in meters
+	Latitude       *float64 `json:"latitude,omitempty" binding:"omitempty,gte=-90,lte=90,required_with=Longitude"`
+	Longitude      *float64 `json:"longitude,omitempty" binding:"omitempty,gte=-180,lte=180,required_with=Latitude"`
+	GeofenceRadius *int     `json:"geofence_radius,omitempty" binding:"omitempty,gt=0"`
 }
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// RecordAssetHistory snapshots the current location and status of assets, it has to follow every change of them
+func (r *Repository) RecordAssetHistory(tx *goqu.TxDatabase, assetIDs []int) error {
+	if len(assetIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.Insert("asset_location_history").
+		Cols("item_id", "location_id", "status").
+		FromQuery(tx.From("items").Select("id", "location_id", "status").Where(goqu.Ex{"id": assetIDs})).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to record asset history: %w", err)
+	}
+
+	return nil
+}
+
+// RecordAssetRemoval closes the history of an asset that is about to be deleted
+func (r *Repository) RecordAssetRemoval(tx *goqu.TxDatabase, assetID int) error {
+	_, err := tx.Insert("asset_location_history").
+		Rows(goqu.Record{"item_id": assetID, "location_id": nil, "status": nil}).
+		Executor().
+		Exec()
+	if err != nil {
+		return fmt.Errorf("failed to record asset removal: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"fmt"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+func (r *Repository) GetCategoryAttributes(categoryID int) ([]models.CategoryAttribute, error) {
+	attributes := []models.CategoryAttribute{}
+	err := r.GoquDBWrapper.Select("id", "item_category_id", "key", "label", "type", "required", "options", "position").
+		From("category_attributes").
+		Where(goqu.Ex{"item_category_id": categoryID}).
+		Order(goqu.I("position").Asc(), goqu.I("id").Asc()).
+		Executor().
+		ScanStructs(&attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category attributes: %w", err)
+	}
+
+	return attributes, nil
+}
+
+// ReplaceCategoryAttributes swaps the whole attribute schema of a category
+func (r *Repository) ReplaceCategoryAttributes(categoryID int, attributes []models.CategoryAttribute) error {
+	return WithTransaction(r.GoquDBWrapper, func(tx *goqu.TxDatabase) error {
+		if _, err := tx.Delete("category_attributes").Where(goqu.Ex{"item_category_id": categoryID}).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to clear category attributes: %w", err)
+		}
+		if len(attributes) == 0 {
+			return nil
+		}
+
+		rows := make([]interface{}, 0, len(attributes))
+		for i, attr := range attributes {
+			rows = append(rows, goqu.Record{
+				"item_category_id": categoryID,
+				"key":              attr.Key,
+				"label":            attr.Label,
+				"type":             attr.Type,
+				"required":         attr.Required,
+				"options":          attr.Options,
+				"position":         i,
+			})
+		}
+
+		if _, err := tx.Insert("category_attributes").Rows(rows...).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to insert category attributes: %w", err)
+		}
+
+		return nil
+	})
+}
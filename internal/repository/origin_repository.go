@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	custom_error "warehouse/pkg/errors"
+	"warehouse/pkg/metadata"
+	"warehouse/pkg/models"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUnknownOrigin  = errors.New("unknown origin")
+	ErrInactiveOrigin = errors.New("origin is inactive")
+)
+
+func (r *Repository) GetOrigins(activeOnly bool) ([]models.Origin, error) {
+	query := r.getOriginQuery()
+	if activeOnly {
+		query = query.Where(goqu.Ex{"active": true})
+	}
+
+	origins := []models.Origin{}
+	if err := query.Order(goqu.I("name").Asc()).Executor().ScanStructs(&origins); err != nil {
+		return nil, fmt.Errorf("failed to query origins: %w", err)
+	}
+
+	return origins, nil
+}
+
+func (r *Repository) GetOrigin(id int) (*models.Origin, error) {
+	return r.getOriginBy(goqu.Ex{"id": id})
+}
+
+func (r *Repository) GetOriginBySlug(slug string) (*models.Origin, error) {
+	return r.getOriginBy(goqu.Ex{"slug": slug})
+}
+
+// ResolveOrigin normalizes the value and ensures it points to an active origin
+func (r *Repository) ResolveOrigin(value string) (*models.Origin, error) {
+	slug := metadata.NewOrigin(value).String()
+	if slug == "" {
+		return nil, ErrUnknownOrigin
+	}
+
+	origin, err := r.GetOriginBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if origin == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOrigin, slug)
+	}
+	if !origin.Active {
+		return nil, fmt.Errorf("%w: %s", ErrInactiveOrigin, slug)
+	}
+
+	return origin, nil
+}
+
+func (r *Repository) PersistOrigin(origin *models.Origin) error {
+	_, err := r.GoquDBWrapper.Insert("origins").
+		Rows(goqu.Record{
+			"slug":          origin.Slug,
+			"name":          origin.Name,
+			"is_rental":     origin.IsRental,
+			"contact_name":  origin.ContactName,
+			"contact_email": origin.ContactEmail,
+			"contact_phone": origin.ContactPhone,
+			"notes":         origin.Notes,
+			"active":        origin.Active,
+		}).
+		Returning("id").
+		Executor().
+		ScanVal(&origin.ID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return custom_error.WrapDBError("Zduplikowany identyfikator pochodzenia", string(pqErr.Code))
+		}
+		return fmt.Errorf("failed to insert origin: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateOrigin(id int, record goqu.Record) error {
+	if _, err := r.GoquDBWrapper.Update("origins").Set(record).Where(goqu.Ex{"id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to update origin: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteOrigin(id int) error {
+	if _, err := r.GoquDBWrapper.Delete("origins").Where(goqu.Ex{"id": id}).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to delete origin: %w", err)
+	}
+
+	return nil
+}
+
+// IsOriginUsed reports whether any asset, stock, stock transfer or rental contract still references the slug
+func (r *Repository) IsOriginUsed(slug string) (bool, error) {
+	var used bool
+	query := r.GoquDBWrapper.Select(goqu.L(
+		"EXISTS (SELECT 1 FROM items WHERE origin = ?) OR EXISTS (SELECT 1 FROM non_serialized_items WHERE origin = ?) "+
+			"OR EXISTS (SELECT 1 FROM non_serialized_transfers WHERE origin = ?) OR EXISTS (SELECT 1 FROM rental_contracts WHERE origin = ?)",
+		slug, slug, slug, slug,
+	))
+	if _, err := query.Executor().ScanVal(&used); err != nil {
+		return false, fmt.Errorf("failed to check origin usage: %w", err)
+	}
+
+	return used, nil
+}
+
+func (r *Repository) getOriginBy(condition goqu.Ex) (*models.Origin, error) {
+	var origin models.Origin
+	found, err := r.getOriginQuery().Where(condition).Executor().ScanStruct(&origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query origin: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &origin, nil
+}
+
+func (r *Repository) getOriginQuery() *goqu.SelectDataset {
+	return r.GoquDBWrapper.Select(
+		"id",
+		"slug",
+		"name",
+		"is_rental",
+		"contact_name",
+		"contact_email",
+		"contact_phone",
+		"notes",
+		"active",
+		"created_at",
+	).From("origins")
+}
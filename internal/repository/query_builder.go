@@ -7,3 +7,13 @@ type QueryBuilder interface {
 	BuildConditions(aliases map[string]string) goqu.Ex
 	HasConditions() bool
 }
+
+// AttributeFilter is implemented by query builders able to filter assets by custom attributes
+type AttributeFilter interface {
+	AttributeFilters() map[string]string
+}
+
+// RetiredFilter is implemented by query builders able to include retired assets, which are skipped by default
+type RetiredFilter interface {
+	IncludeRetired() bool
+}
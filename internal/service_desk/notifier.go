@@ -0,0 +1,30 @@
+package service_desk
+
+import (
+	"time"
+	"warehouse/internal/repository"
+	"warehouse/pkg/notify"
+)
+
+// Notifier files notifications as new service desk requests so they land in the team's queue
+type Notifier struct {
+	repository *ServiceDeskRepository
+}
+
+func NewNotifier(r *repository.Repository) *Notifier {
+	return &Notifier{repository: NewServiceDeskRepository(r)}
+}
+
+func (n *Notifier) Notify(notification notify.Notification) error {
+	now := time.Now()
+	return n.repository.CreateRequest(&Request{
+		Title:       notification.Title,
+		Description: notification.Message,
+		Type:        RequestTypeReplacement,
+		Status:      StatusNew,
+		CreatedBy:   "system",
+		Priority:    PriorityMedium,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
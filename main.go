@@ -15,6 +15,7 @@ import (
 	"warehouse/internal/core/routes"
 	"warehouse/internal/database"
 	"warehouse/internal/middleware"
+	"warehouse/pkg/metadata"
 )
 
 func init() {
@@ -57,6 +58,10 @@ func main() {
 		return
 	}
 
+	if err := metadata.ConfigurePyrCodeFromEnv(); err != nil {
+		log.Fatalf("Invalid PYR code configuration: %v", err)
+	}
+
 	// Start server
 	container := container.NewAppContainer(db)
 	router := setupRouter(container)
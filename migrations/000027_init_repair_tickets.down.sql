@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS repair_tickets;
+UPDATE items SET status = 'unavailable' WHERE status = 'in_repair';
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE repair_tickets (
+    id SERIAL PRIMARY KEY,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    status VARCHAR(32) NOT NULL DEFAULT 'open',
+    vendor VARCHAR(255),
+    cost NUMERIC(10, 2),
+    notes TEXT,
+    expected_return_date DATE,
+    service_desk_request_id INT REFERENCES service_desk_requests(id) ON DELETE SET NULL,
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP
+);
+
+-- Jeden otwarty ticket naprawy na zasób
+CREATE UNIQUE INDEX idx_repair_tickets_open_item ON repair_tickets (item_id) WHERE status = 'open';
+CREATE INDEX idx_repair_tickets_item_id ON repair_tickets (item_id);
+CREATE INDEX idx_repair_tickets_service_desk_request_id ON repair_tickets (service_desk_request_id);
+
+COMMIT;
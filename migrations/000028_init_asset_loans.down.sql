@@ -0,0 +1,6 @@
+BEGIN;
+
+UPDATE items SET status = CASE WHEN location_id = 1 THEN 'available' ELSE 'located' END WHERE status = 'on_loan';
+DROP TABLE IF EXISTS asset_loans;
+
+COMMIT;
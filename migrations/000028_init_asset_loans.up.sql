@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE asset_loans (
+    id SERIAL PRIMARY KEY,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    recipient_name VARCHAR(255),
+    notes TEXT,
+    checked_out_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    checked_out_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    due_at TIMESTAMP NOT NULL,
+    returned_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    returned_at TIMESTAMP,
+    CONSTRAINT chk_asset_loans_holder CHECK (user_id IS NOT NULL OR recipient_name IS NOT NULL)
+);
+
+-- Zasób może być wypożyczony tylko jednej osobie naraz
+CREATE UNIQUE INDEX idx_asset_loans_active_item ON asset_loans (item_id) WHERE returned_at IS NULL;
+CREATE INDEX idx_asset_loans_user_id ON asset_loans (user_id);
+CREATE INDEX idx_asset_loans_due_at ON asset_loans (due_at) WHERE returned_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS stocktake_stock_counts;
+DROP TABLE IF EXISTS stocktake_asset_scans;
+DROP TABLE IF EXISTS stocktakes;
+
+COMMIT;
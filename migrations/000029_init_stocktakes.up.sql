@@ -0,0 +1,36 @@
+BEGIN;
+
+CREATE TABLE stocktakes (
+    id SERIAL PRIMARY KEY,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    status VARCHAR(32) NOT NULL DEFAULT 'open',
+    started_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMP,
+    applied_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    applied_at TIMESTAMP
+);
+
+-- Tylko jedna otwarta inwentaryzacja na lokalizację
+CREATE UNIQUE INDEX idx_stocktakes_open_location ON stocktakes (location_id) WHERE status = 'open';
+
+CREATE TABLE stocktake_asset_scans (
+    id SERIAL PRIMARY KEY,
+    stocktake_id INT NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    scanned_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    scanned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (stocktake_id, item_id)
+);
+
+CREATE TABLE stocktake_stock_counts (
+    id SERIAL PRIMARY KEY,
+    stocktake_id INT NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+    stock_id INT NOT NULL REFERENCES non_serialized_items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity >= 0),
+    counted_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    counted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (stocktake_id, stock_id)
+);
+
+COMMIT;
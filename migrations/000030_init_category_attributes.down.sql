@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_items_attributes;
+ALTER TABLE items DROP COLUMN IF EXISTS attributes;
+DROP TABLE IF EXISTS category_attributes;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE category_attributes (
+    id SERIAL PRIMARY KEY,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    key VARCHAR(64) NOT NULL,
+    label VARCHAR(255) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    options JSONB,
+    position INT NOT NULL DEFAULT 0,
+    UNIQUE (item_category_id, key)
+);
+
+ALTER TABLE items ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}'::jsonb;
+CREATE INDEX idx_items_attributes ON items USING GIN (attributes);
+
+COMMIT;
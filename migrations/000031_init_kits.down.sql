@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS kit_stock_components;
+DROP TABLE IF EXISTS kit_components;
+
+COMMIT;
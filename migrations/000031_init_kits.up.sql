@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE kit_components (
+    id SERIAL PRIMARY KEY,
+    kit_item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    component_item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    CHECK (kit_item_id <> component_item_id),
+    UNIQUE (component_item_id)
+);
+
+CREATE INDEX idx_kit_components_kit ON kit_components(kit_item_id);
+
+CREATE TABLE kit_stock_components (
+    id SERIAL PRIMARY KEY,
+    kit_item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    UNIQUE (kit_item_id, item_category_id)
+);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE transfers DROP COLUMN IF EXISTS requires_delivery_photo;
+DROP TABLE IF EXISTS attachments;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    resource_type VARCHAR(32) NOT NULL,
+    resource_id INT NOT NULL,
+    kind VARCHAR(32) NOT NULL DEFAULT 'document',
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(128) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(512) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(512),
+    min_role VARCHAR(16) NOT NULL DEFAULT 'user',
+    uploaded_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachments_resource ON attachments(resource_type, resource_id);
+
+ALTER TABLE transfers ADD COLUMN requires_delivery_photo BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMIT;
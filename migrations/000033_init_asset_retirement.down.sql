@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_items_retired_at;
+
+ALTER TABLE items
+    DROP COLUMN IF EXISTS retired_by_id,
+    DROP COLUMN IF EXISTS retirement_notes,
+    DROP COLUMN IF EXISTS retirement_reason,
+    DROP COLUMN IF EXISTS retired_at;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE items
+    ADD COLUMN retired_at TIMESTAMP,
+    ADD COLUMN retirement_reason VARCHAR(50),
+    ADD COLUMN retirement_notes TEXT,
+    ADD COLUMN retired_by_id INT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_items_retired_at ON items(retired_at) WHERE retired_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS rental_contract_stocks;
+DROP TABLE IF EXISTS rental_contract_assets;
+DROP TABLE IF EXISTS rental_contracts;
+
+COMMIT;
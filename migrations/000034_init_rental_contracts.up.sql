@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE rental_contracts (
+    id SERIAL PRIMARY KEY,
+    origin VARCHAR(128) NOT NULL,
+    reference VARCHAR(255),
+    rented_from DATE NOT NULL,
+    due_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    notes TEXT,
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    returned_at TIMESTAMP,
+    CONSTRAINT chk_rental_contracts_period CHECK (due_date >= rented_from)
+);
+
+CREATE INDEX idx_rental_contracts_origin_status ON rental_contracts (origin, status);
+
+CREATE TABLE rental_contract_assets (
+    contract_id INT NOT NULL REFERENCES rental_contracts(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE RESTRICT,
+    returned_at TIMESTAMP,
+    PRIMARY KEY (contract_id, item_id)
+);
+
+-- Zasób może należeć tylko do jednej niezwróconej umowy
+CREATE UNIQUE INDEX idx_rental_contract_assets_active_item ON rental_contract_assets (item_id) WHERE returned_at IS NULL;
+
+CREATE TABLE rental_contract_stocks (
+    contract_id INT NOT NULL REFERENCES rental_contracts(id) ON DELETE CASCADE,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    returned_quantity INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (contract_id, item_category_id),
+    CONSTRAINT chk_rental_contract_stocks_returned CHECK (returned_quantity >= 0 AND returned_quantity <= quantity)
+);
+
+COMMIT;
@@ -0,0 +1,37 @@
+BEGIN;
+
+CREATE TABLE origins (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(128) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    is_rental BOOLEAN NOT NULL DEFAULT FALSE,
+    contact_name VARCHAR(255),
+    contact_email VARCHAR(255),
+    contact_phone VARCHAR(64),
+    notes TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO origins (slug, name, is_rental) VALUES
+    ('druga-era', 'Druga Era', TRUE),
+    ('probis', 'Probis', TRUE),
+    ('netland', 'Netland', TRUE),
+    ('targowe', 'Targowe', TRUE),
+    ('dj-sound', 'DJ Sound', TRUE),
+    ('oki-event', 'Oki Event', TRUE),
+    ('personal', 'Prywatne', FALSE),
+    ('other', 'Inne', FALSE);
+
+-- Wartości zapisane przed wprowadzeniem tabeli pozostają poprawne
+INSERT INTO origins (slug, name)
+SELECT DISTINCT origin, origin FROM (
+    SELECT origin FROM items
+    UNION SELECT origin FROM non_serialized_items
+    UNION SELECT origin FROM non_serialized_transfers
+    UNION SELECT origin FROM rental_contracts
+) existing
+WHERE origin IS NOT NULL AND origin <> ''
+ON CONFLICT (slug) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS pyr_code_sequences;
+
+COMMIT;
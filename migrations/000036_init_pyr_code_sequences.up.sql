@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Licznik numerów kodów PYR per kategoria, scope rozdziela numerację gdy wzorzec zawiera rok
+CREATE TABLE pyr_code_sequences (
+    category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    scope VARCHAR(16) NOT NULL DEFAULT '',
+    last_value INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (category_id, scope)
+);
+
+-- Numeracja kontynuuje najwyższy istniejący numer w formacie PYR-<pyr_id><n>
+INSERT INTO pyr_code_sequences (category_id, scope, last_value)
+SELECT c.id, '', COALESCE(MAX(CAST(SUBSTRING(i.pyr_code FROM '^PYR-' || c.pyr_id || '(\d+)(-\d+)?$') AS INTEGER)), 0)
+FROM item_category c
+LEFT JOIN items i ON i.item_category_id = c.id AND i.pyr_code ~ ('^PYR-' || c.pyr_id || '\d+(-\d+)?$')
+GROUP BY c.id;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- Minimalny stan kategorii, bez lokalizacji dotyczy sumy we wszystkich lokalizacjach
+CREATE TABLE stock_thresholds (
+    id SERIAL PRIMARY KEY,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    location_id INT REFERENCES locations(id) ON DELETE CASCADE,
+    min_quantity INT NOT NULL CHECK (min_quantity >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_stock_thresholds_category_location ON stock_thresholds (item_category_id, COALESCE(location_id, 0));
+
+COMMIT;
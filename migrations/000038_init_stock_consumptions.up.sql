@@ -0,0 +1,20 @@
+BEGIN;
+
+-- Zużycie materiałów eksploatacyjnych, kategoria i lokalizacja są kopiowane bo pusty wiersz stanu może zostać usunięty
+CREATE TABLE stock_consumptions (
+    id SERIAL PRIMARY KEY,
+    stock_item_id INT REFERENCES non_serialized_items(id) ON DELETE SET NULL,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    origin VARCHAR(128),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason TEXT NOT NULL,
+    used_at_location_id INT REFERENCES locations(id) ON DELETE SET NULL,
+    service_desk_request_id INT REFERENCES service_desk_requests(id) ON DELETE SET NULL,
+    consumed_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    consumed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_consumptions_category_consumed_at ON stock_consumptions (item_category_id, consumed_at);
+
+COMMIT;
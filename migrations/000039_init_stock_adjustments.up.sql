@@ -0,0 +1,33 @@
+BEGIN;
+
+-- Dziennik zmian ilości materiałów, suma zatwierdzonych zmian wiersza odpowiada jego aktualnej ilości
+CREATE TABLE stock_adjustments (
+    id SERIAL PRIMARY KEY,
+    stock_item_id INT REFERENCES non_serialized_items(id) ON DELETE SET NULL,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    origin VARCHAR(128),
+    delta INT NOT NULL CHECK (delta <> 0),
+    reason VARCHAR(32) NOT NULL CHECK (reason IN (
+        'count_correction', 'damaged', 'found', 'lost',
+        'opening', 'created', 'removed', 'transfer', 'stocktake', 'import', 'consumption', 'rental'
+    )),
+    status VARCHAR(16) NOT NULL DEFAULT 'applied' CHECK (status IN ('pending', 'applied', 'rejected')),
+    notes TEXT,
+    requested_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reviewed_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_notes TEXT
+);
+
+CREATE INDEX idx_stock_adjustments_stock_item ON stock_adjustments (stock_item_id);
+CREATE INDEX idx_stock_adjustments_pending ON stock_adjustments (status) WHERE status = 'pending';
+
+-- Stan początkowy istniejących wierszy
+INSERT INTO stock_adjustments (stock_item_id, item_category_id, location_id, origin, delta, reason)
+SELECT id, item_category_id, location_id, origin, quantity, 'opening'
+FROM non_serialized_items
+WHERE quantity <> 0;
+
+COMMIT;
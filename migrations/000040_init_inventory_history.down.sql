@@ -0,0 +1,15 @@
+BEGIN;
+
+DELETE FROM stock_adjustments WHERE reason = 'relocated';
+ALTER TABLE stock_adjustments DROP CONSTRAINT stock_adjustments_reason_check;
+ALTER TABLE stock_adjustments ADD CONSTRAINT stock_adjustments_reason_check CHECK (reason IN (
+    'count_correction', 'damaged', 'found', 'lost',
+    'opening', 'created', 'removed', 'transfer', 'stocktake', 'import', 'consumption', 'rental'
+));
+
+DROP INDEX IF EXISTS idx_stock_adjustments_location_applied;
+ALTER TABLE stock_adjustments DROP COLUMN IF EXISTS applied_at;
+
+DROP TABLE IF EXISTS asset_location_history;
+
+COMMIT;
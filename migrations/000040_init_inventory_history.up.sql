@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Historia lokalizacji i statusów zasobów, bez klucza obcego do items żeby przetrwała usunięcie zasobu
+CREATE TABLE asset_location_history (
+    id BIGSERIAL PRIMARY KEY,
+    item_id INT NOT NULL,
+    location_id INT REFERENCES locations(id) ON DELETE CASCADE,
+    status VARCHAR(32),
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_asset_location_history_item ON asset_location_history (item_id, changed_at);
+CREATE INDEX idx_asset_location_history_location ON asset_location_history (location_id, changed_at);
+
+-- Stan początkowy istniejących zasobów
+INSERT INTO asset_location_history (item_id, location_id, status)
+SELECT id, location_id, status FROM items;
+
+-- Moment w którym zmiana ilości weszła w życie, zmiany oczekujące na zatwierdzenie go nie mają
+ALTER TABLE stock_adjustments ADD COLUMN applied_at TIMESTAMP;
+UPDATE stock_adjustments SET applied_at = COALESCE(reviewed_at, requested_at) WHERE status = 'applied';
+
+CREATE INDEX idx_stock_adjustments_location_applied ON stock_adjustments (location_id, applied_at) WHERE status = 'applied';
+
+ALTER TABLE stock_adjustments DROP CONSTRAINT stock_adjustments_reason_check;
+ALTER TABLE stock_adjustments ADD CONSTRAINT stock_adjustments_reason_check CHECK (reason IN (
+    'count_correction', 'damaged', 'found', 'lost',
+    'opening', 'created', 'removed', 'relocated', 'transfer', 'stocktake', 'import', 'consumption', 'rental'
+));
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+DELETE FROM stock_adjustments WHERE reason IN ('split', 'merge');
+ALTER TABLE stock_adjustments DROP CONSTRAINT stock_adjustments_reason_check;
+ALTER TABLE stock_adjustments ADD CONSTRAINT stock_adjustments_reason_check CHECK (reason IN (
+    'count_correction', 'damaged', 'found', 'lost',
+    'opening', 'created', 'removed', 'relocated', 'transfer', 'stocktake', 'import', 'consumption', 'rental'
+));
+
+ALTER TABLE stock_adjustments DROP COLUMN IF EXISTS counterpart_stock_item_id;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+-- Wiersz po drugiej stronie podziału lub scalenia
+ALTER TABLE stock_adjustments ADD COLUMN counterpart_stock_item_id INT REFERENCES non_serialized_items(id) ON DELETE SET NULL;
+
+ALTER TABLE stock_adjustments DROP CONSTRAINT stock_adjustments_reason_check;
+ALTER TABLE stock_adjustments ADD CONSTRAINT stock_adjustments_reason_check CHECK (reason IN (
+    'count_correction', 'damaged', 'found', 'lost',
+    'opening', 'created', 'removed', 'relocated', 'transfer', 'stocktake', 'import', 'consumption', 'rental',
+    'split', 'merge'
+));
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS reservation_stocks;
+DROP TABLE IF EXISTS reservation_assets;
+DROP TABLE IF EXISTS reservations;
+
+COMMIT;
@@ -0,0 +1,39 @@
+BEGIN;
+
+-- Rezerwacje zasobów i materiałów pod planowane transfery, wygasłe rezerwacje przestają blokować dostępność bez zmiany statusu
+CREATE TABLE reservations (
+    id SERIAL PRIMARY KEY,
+    from_location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    to_location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    planned_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'fulfilled', 'cancelled')),
+    transfer_id INT REFERENCES transfers(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_reservations_locations CHECK (from_location_id <> to_location_id),
+    CONSTRAINT chk_reservations_expiry CHECK (expires_at >= planned_at)
+);
+
+CREATE TABLE reservation_assets (
+    reservation_id INT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    PRIMARY KEY (reservation_id, item_id)
+);
+
+-- Kategoria jest kopiowana bo pusty wiersz stanu może zostać usunięty, taka rezerwacja pokazuje wtedy konflikt
+CREATE TABLE reservation_stocks (
+    id SERIAL PRIMARY KEY,
+    reservation_id INT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    stock_item_id INT REFERENCES non_serialized_items(id) ON DELETE SET NULL,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_reservations_active ON reservations (expires_at) WHERE status = 'active';
+CREATE INDEX idx_reservations_planned_at ON reservations (planned_at);
+CREATE INDEX idx_reservation_assets_item_id ON reservation_assets (item_id);
+CREATE INDEX idx_reservation_stocks_stock_item_id ON reservation_stocks (stock_item_id);
+
+COMMIT;
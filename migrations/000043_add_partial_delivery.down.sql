@@ -0,0 +1,15 @@
+BEGIN;
+
+UPDATE transfers SET status = 'in_transit' WHERE status = 'partially_delivered';
+UPDATE non_serialized_transfers SET status = 'in_transit' WHERE status = 'missing';
+
+ALTER TABLE non_serialized_transfers
+    DROP COLUMN IF EXISTS written_off_quantity,
+    DROP COLUMN IF EXISTS received_quantity;
+
+ALTER TABLE serialized_transfers
+    DROP CONSTRAINT IF EXISTS serialized_transfers_status_check,
+    DROP COLUMN IF EXISTS resolved_at,
+    DROP COLUMN IF EXISTS status;
+
+COMMIT;
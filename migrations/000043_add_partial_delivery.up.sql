@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Potwierdzanie dostawy pozycja po pozycji, braki czekają na późniejsze potwierdzenie albo spisanie
+ALTER TABLE serialized_transfers
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'in_transit',
+    ADD COLUMN resolved_at TIMESTAMP;
+
+UPDATE serialized_transfers st
+SET status = t.status
+FROM transfers t
+WHERE st.transfer_id = t.id AND t.status IN ('completed', 'cancelled');
+
+ALTER TABLE serialized_transfers
+    ADD CONSTRAINT serialized_transfers_status_check CHECK (status IN ('in_transit', 'missing', 'completed', 'written_off', 'cancelled'));
+
+ALTER TABLE non_serialized_transfers
+    ADD COLUMN received_quantity INT NOT NULL DEFAULT 0,
+    ADD COLUMN written_off_quantity INT NOT NULL DEFAULT 0;
+
+-- Zakończone transfery dostarczyły całą ilość
+UPDATE non_serialized_transfers SET received_quantity = quantity WHERE status = 'completed';
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_transfers_delivery_outside_geofence;
+
+ALTER TABLE transfers
+    DROP COLUMN IF EXISTS delivery_outside_geofence,
+    DROP COLUMN IF EXISTS delivery_distance;
+
+ALTER TABLE locations
+    DROP CONSTRAINT IF EXISTS locations_geofence_radius_check,
+    DROP CONSTRAINT IF EXISTS locations_coordinates_check,
+    DROP CONSTRAINT IF EXISTS locations_longitude_check,
+    DROP CONSTRAINT IF EXISTS locations_latitude_check,
+    DROP COLUMN IF EXISTS geofence_radius,
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude;
+
+COMMIT;
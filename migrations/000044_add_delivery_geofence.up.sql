@@ -0,0 +1,20 @@
+BEGIN;
+
+-- Współrzędne lokalizacji i promień, w którym dostawa jest uznana za zgodną z miejscem docelowym
+ALTER TABLE locations
+    ADD COLUMN latitude DECIMAL(10, 8),
+    ADD COLUMN longitude DECIMAL(11, 8),
+    ADD COLUMN geofence_radius INT,
+    ADD CONSTRAINT locations_latitude_check CHECK (latitude BETWEEN -90 AND 90),
+    ADD CONSTRAINT locations_longitude_check CHECK (longitude BETWEEN -180 AND 180),
+    ADD CONSTRAINT locations_coordinates_check CHECK ((latitude IS NULL) = (longitude IS NULL)),
+    ADD CONSTRAINT locations_geofence_radius_check CHECK (geofence_radius > 0);
+
+-- Odległość zgłoszonej dostawy od lokalizacji docelowej w metrach
+ALTER TABLE transfers
+    ADD COLUMN delivery_distance INT,
+    ADD COLUMN delivery_outside_geofence BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_transfers_delivery_outside_geofence ON transfers (delivery_outside_geofence) WHERE delivery_outside_geofence;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS shipment_legs;
+DROP TABLE IF EXISTS shipments;
+
+COMMIT;
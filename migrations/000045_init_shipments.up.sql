@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Przesyłki wieloetapowe, każdy etap to osobny transfer uruchamiany po zakończeniu poprzedniego
+CREATE TABLE shipments (
+    id SERIAL PRIMARY KEY,
+    from_location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    to_location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_transit' CHECK (status IN ('in_transit', 'completed', 'cancelled')),
+    requires_delivery_photo BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT,
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+-- Etap bez transferu jest zaplanowany, transfer powstaje gdy rzeczy dotrą na początek etapu
+CREATE TABLE shipment_legs (
+    id SERIAL PRIMARY KEY,
+    shipment_id INT NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    leg_number INT NOT NULL CHECK (leg_number > 0),
+    from_location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    to_location_id INT NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    eta TIMESTAMP,
+    transfer_id INT UNIQUE REFERENCES transfers(id) ON DELETE SET NULL,
+    CONSTRAINT uq_shipment_legs_number UNIQUE (shipment_id, leg_number),
+    CONSTRAINT chk_shipment_legs_locations CHECK (from_location_id <> to_location_id)
+);
+
+CREATE INDEX idx_shipments_status ON shipments (status);
+
+COMMIT;
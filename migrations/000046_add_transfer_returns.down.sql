@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_transfers_return_of_transfer_id;
+
+ALTER TABLE transfers
+    DROP COLUMN IF EXISTS return_of_transfer_id;
+
+COMMIT;
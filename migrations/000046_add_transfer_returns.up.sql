@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Transfer zwrotny zabiera z miejsca docelowego to, co z danego transferu jeszcze tam zostało
+ALTER TABLE transfers
+    ADD COLUMN return_of_transfer_id INT REFERENCES transfers(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transfers_return_of_transfer_id ON transfers (return_of_transfer_id) WHERE return_of_transfer_id IS NOT NULL;
+
+COMMIT;
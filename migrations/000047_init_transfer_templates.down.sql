@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS transfer_template_lines;
+DROP TABLE IF EXISTS transfer_templates;
+
+COMMIT;
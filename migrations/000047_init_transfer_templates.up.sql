@@ -0,0 +1,22 @@
+BEGIN;
+
+-- Szablony transferów, lista ilości w kategoriach zamiast konkretnych egzemplarzy
+CREATE TABLE transfer_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) NOT NULL UNIQUE,
+    default_location_id INT REFERENCES locations(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_by_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE transfer_template_lines (
+    id SERIAL PRIMARY KEY,
+    template_id INT NOT NULL REFERENCES transfer_templates(id) ON DELETE CASCADE,
+    item_category_id INT NOT NULL REFERENCES item_category(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    CONSTRAINT uq_transfer_template_lines_category UNIQUE (template_id, item_category_id)
+);
+
+COMMIT;
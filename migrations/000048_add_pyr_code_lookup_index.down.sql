@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_items_pyr_code_key_length;
+
+COMMIT;
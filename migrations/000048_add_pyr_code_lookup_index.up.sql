@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Podpowiedzi kodów PYR zawężają kandydatów po długości klucza wyszukiwania (jak metadata.PyrCodeLookupKey)
+CREATE INDEX idx_items_pyr_code_key_length
+    ON items (LENGTH(regexp_replace(translate(upper(pyr_code), 'OI', '01'), '[^A-Z0-9]', '', 'g')))
+    WHERE pyr_code IS NOT NULL AND retired_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,34 @@
+package custom_error
+
+import (
+	"errors"
+	"fmt"
+)
+
+// InvalidStatusTransitionError is returned when a resource is asked to move into a status
+// that is not reachable from its current one.
+type InvalidStatusTransitionError struct {
+	Resource   string
+	ResourceID int
+	From       string
+	To         string
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("illegal status transition for %s %d: %s -> %s", e.Resource, e.ResourceID, e.From, e.To)
+}
+
+func NewInvalidStatusTransitionError(resource string, resourceID int, from, to string) *InvalidStatusTransitionError {
+	return &InvalidStatusTransitionError{
+		Resource:   resource,
+		ResourceID: resourceID,
+		From:       from,
+		To:         to,
+	}
+}
+
+// IsInvalidStatusTransition reports whether err (or any error it wraps) is an InvalidStatusTransitionError
+func IsInvalidStatusTransition(err error) bool {
+	var transitionErr *InvalidStatusTransitionError
+	return errors.As(err, &transitionErr)
+}
@@ -1,28 +1,229 @@
 package metadata
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
-type PyrCode struct {
-	init     string
-	category string
-	id       string
+const (
+	DefaultPyrCodePrefix  = "PYR"
+	DefaultPyrCodePattern = "{prefix}-{category}{n}"
+)
+
+var pyrCodeTokenRegex = regexp.MustCompile(`\{([a-z]+)(?::(\d+))?\}`)
+
+var ErrInvalidCheckDigit = errors.New("invalid PYR code check digit")
+
+// PyrCodeFormat describes how codes are rendered, supported tokens are
+// {prefix}, {category}, {year}, {n} (or {n:4} for a zero padded number) and {check},
+// the check digit is computed over everything before it so it has to close the pattern
+type PyrCodeFormat struct {
+	Prefix  string
+	Pattern string
 }
 
-const Init string = "PYR"
+var pyrCodeFormat = PyrCodeFormat{Prefix: DefaultPyrCodePrefix, Pattern: DefaultPyrCodePattern}
 
-func (pyr *PyrCode) GeneratePyrCode() string {
+// ConfigurePyrCodeFromEnv reads PYR_CODE_PREFIX and PYR_CODE_PATTERN, unset values keep the defaults
+func ConfigurePyrCodeFromEnv() error {
+	format := PyrCodeFormat{Prefix: DefaultPyrCodePrefix, Pattern: DefaultPyrCodePattern}
+	if prefix, ok := os.LookupEnv("PYR_CODE_PREFIX"); ok {
+		format.Prefix = prefix
+	}
+	if pattern := os.Getenv("PYR_CODE_PATTERN"); pattern != "" {
+		format.Pattern = pattern
+	}
+
+	return SetPyrCodeFormat(format)
+}
+
+func SetPyrCodeFormat(format PyrCodeFormat) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+	pyrCodeFormat = format
+	return nil
+}
+
+func CurrentPyrCodeFormat() PyrCodeFormat {
+	return pyrCodeFormat
+}
+
+func (f PyrCodeFormat) Validate() error {
+	seen := map[string]bool{}
+	for _, match := range pyrCodeTokenRegex.FindAllStringSubmatch(f.Pattern, -1) {
+		switch match[1] {
+		case "prefix", "category", "year", "n", "check":
+			seen[match[1]] = true
+		default:
+			return fmt.Errorf("unknown PYR code pattern token: %s", match[0])
+		}
+	}
+	if !seen["category"] || !seen["n"] {
+		return fmt.Errorf("PYR code pattern must contain {category} and {n}: %s", f.Pattern)
+	}
+	if seen["check"] && (strings.Count(f.Pattern, "{check}") != 1 || !strings.HasSuffix(f.Pattern, "{check}")) {
+		return fmt.Errorf("{check} has to be the last token of PYR code pattern: %s", f.Pattern)
+	}
+
+	return nil
+}
 
-	return pyr.init + "-" + pyr.category + pyr.id
+// SequenceScope separates numbering per year when the pattern contains {year}, otherwise numbers never reset
+func (f PyrCodeFormat) SequenceScope(at time.Time) string {
+	if strings.Contains(f.Pattern, "{year}") {
+		return strconv.Itoa(at.Year())
+	}
+	return ""
 }
 
-func NewPyrCode(pyrID string, assetID int) PyrCode {
-	var code PyrCode
+func (f PyrCodeFormat) HasCheckDigit() bool {
+	return strings.Contains(f.Pattern, "{check}")
+}
 
-	code.init = Init
-	code.category = pyrID
-	code.id = strconv.Itoa(assetID)
+func (f PyrCodeFormat) Render(category string, number int, at time.Time) string {
+	code := pyrCodeTokenRegex.ReplaceAllStringFunc(f.Pattern, func(token string) string {
+		match := pyrCodeTokenRegex.FindStringSubmatch(token)
+		switch match[1] {
+		case "prefix":
+			return f.Prefix
+		case "category":
+			return category
+		case "year":
+			return strconv.Itoa(at.Year())
+		case "n":
+			width, _ := strconv.Atoi(match[2])
+			return fmt.Sprintf("%0*d", width, number)
+		case "check":
+			return ""
+		}
+		return token
+	})
 
+	if f.HasCheckDigit() {
+		code += PyrCodeCheckDigit(code)
+	}
 	return code
 }
+
+// ValidateCheckDigit verifies codes rendered by this format, codes in other shapes pass. A legacy code without
+// a check digit can look like one with it, so a failure is a hint of a typo rather than proof.
+func (f PyrCodeFormat) ValidateCheckDigit(code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !f.HasCheckDigit() || !f.matcher().MatchString(code) {
+		return nil
+	}
+	if PyrCodeCheckDigit(code[:len(code)-1]) != code[len(code)-1:] {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// matcher builds a regexp recognizing codes in this format
+func (f PyrCodeFormat) matcher() *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("^")
+	last := 0
+	for _, loc := range pyrCodeTokenRegex.FindAllStringSubmatchIndex(f.Pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(strings.ToUpper(f.Pattern[last:loc[0]])))
+		switch f.Pattern[loc[2]:loc[3]] {
+		case "prefix":
+			expr.WriteString(regexp.QuoteMeta(strings.ToUpper(f.Prefix)))
+		case "category":
+			expr.WriteString("[A-Z0-9]+")
+		case "year":
+			expr.WriteString(`\d{4}`)
+		case "n":
+			if loc[4] >= 0 {
+				expr.WriteString(`\d{` + f.Pattern[loc[4]:loc[5]] + `,}`)
+			} else {
+				expr.WriteString(`\d+`)
+			}
+		case "check":
+			expr.WriteString(`\d`)
+		}
+		last = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(strings.ToUpper(f.Pattern[last:])))
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String())
+}
+
+// PyrCodeCheckDigit computes a Luhn digit over the code, letters count as their base 36 value
+func PyrCodeCheckDigit(code string) string {
+	var digits []int
+	for _, r := range strings.ToUpper(code) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		case r >= 'A' && r <= 'Z':
+			value := int(r-'A') + 10
+			digits = append(digits, value/10, value%10)
+		}
+	}
+
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := digits[i]
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return strconv.Itoa((10 - sum%10) % 10)
+}
+
+// ValidatePyrCode checks the check digit of a code against the configured format
+func ValidatePyrCode(code string) error {
+	return pyrCodeFormat.ValidateCheckDigit(code)
+}
+
+// PyrCodeLookupKey reduces a code to a form insensitive to typical typos: case, separators,
+// whitespace and O/0, I/1 confusion
+func PyrCodeLookupKey(value string) string {
+	var key strings.Builder
+	for _, r := range strings.ToUpper(value) {
+		switch {
+		case r == 'O':
+			key.WriteRune('0')
+		case r == 'I':
+			key.WriteRune('1')
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+type PyrCode struct {
+	format   PyrCodeFormat
+	category string
+	number   int
+	issuedAt time.Time
+}
+
+func (pyr *PyrCode) GeneratePyrCode() string {
+	return pyr.format.Render(pyr.category, pyr.number, pyr.issuedAt)
+}
+
+// NewPyrCode builds a code in the configured format, number comes from the category sequence
+func NewPyrCode(pyrID string, number int) PyrCode {
+	return PyrCode{
+		format:   pyrCodeFormat,
+		category: pyrID,
+		number:   number,
+		issuedAt: time.Now(),
+	}
+}
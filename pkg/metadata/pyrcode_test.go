@@ -1,7 +1,9 @@
 package metadata
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -35,3 +37,64 @@ func TestNewPyrCode(t *testing.T) {
 		})
 	}
 }
+
+func TestPyrCodeFormatRender(t *testing.T) {
+	at := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		format   PyrCodeFormat
+		expected string
+	}{
+		{"Default", PyrCodeFormat{Prefix: "PYR", Pattern: DefaultPyrCodePattern}, "PYR-LT7"},
+		{"Year", PyrCodeFormat{Prefix: "PYR", Pattern: "{prefix}{year}-{category}{n}"}, "PYR2025-LT7"},
+		{"Padding", PyrCodeFormat{Prefix: "EV", Pattern: "{prefix}-{category}-{n:4}"}, "EV-LT-0007"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.format.Validate())
+			assert.Equal(t, tt.expected, tt.format.Render("LT", 7, at))
+		})
+	}
+}
+
+func TestPyrCodeFormatValidate(t *testing.T) {
+	assert.Error(t, PyrCodeFormat{Pattern: "{prefix}-{n}"}.Validate())
+	assert.Error(t, PyrCodeFormat{Pattern: "{prefix}-{category}"}.Validate())
+	assert.Error(t, PyrCodeFormat{Pattern: "{category}{n}{month}"}.Validate())
+}
+
+func TestPyrCodeFormatSequenceScope(t *testing.T) {
+	at := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "", PyrCodeFormat{Pattern: DefaultPyrCodePattern}.SequenceScope(at))
+	assert.Equal(t, "2025", PyrCodeFormat{Pattern: "{year}-{category}{n}"}.SequenceScope(at))
+}
+
+func TestPyrCodeCheckDigit(t *testing.T) {
+	format := PyrCodeFormat{Prefix: "PYR", Pattern: "{prefix}-{category}{n}{check}"}
+	assert.NoError(t, format.Validate())
+
+	code := format.Render("LT", 12, time.Now())
+	assert.Equal(t, "PYR-LT12"+PyrCodeCheckDigit("PYR-LT12"), code)
+	assert.NoError(t, format.ValidateCheckDigit(code))
+	assert.NoError(t, format.ValidateCheckDigit(strings.ToLower(code)+" "))
+
+	wrong := code[:len(code)-1] + string(rune('0'+(code[len(code)-1]-'0'+1)%10))
+	assert.ErrorIs(t, format.ValidateCheckDigit(wrong), ErrInvalidCheckDigit)
+
+	// legacy codes issued before the check digit was enabled are not validated
+	assert.NoError(t, format.ValidateCheckDigit("PYR-LT-12"))
+	assert.Error(t, PyrCodeFormat{Pattern: "{category}{check}{n}"}.Validate())
+}
+
+func TestPyrCodeCheckDigitCatchesTransposition(t *testing.T) {
+	assert.NotEqual(t, PyrCodeCheckDigit("PYR-LT12"), PyrCodeCheckDigit("PYR-LT21"))
+}
+
+func TestPyrCodeLookupKey(t *testing.T) {
+	assert.Equal(t, "PYRLT10", PyrCodeLookupKey(" pyr-lt10\n"))
+	assert.Equal(t, "PYRLT10", PyrCodeLookupKey("PYR-LTIO"))
+	assert.Equal(t, "M0N1", PyrCodeLookupKey("mon1"))
+}
@@ -1,6 +1,10 @@
 package metadata
 
-import "fmt"
+import (
+	"fmt"
+
+	custom_error "warehouse/pkg/errors"
+)
 
 type Status string
 
@@ -12,8 +16,34 @@ const (
 	StatusAvailable   Status = "available"
 	StatusUnavailable Status = "unavailable"
 	StatusCancelled   Status = "cancelled"
+	StatusInRepair    Status = "in_repair"
+	StatusOnLoan      Status = "on_loan"
+	StatusRetired     Status = "retired"
+
+	// StatusPartiallyDelivered is a transfer with lines still in transit or missing after a delivery confirmation
+	StatusPartiallyDelivered Status = "partially_delivered"
 )
 
+// assetTransitions describes the asset lifecycle, every status change of an asset has to be listed here
+// or, for location corrections made without a transfer, in assetRelocations
+var assetTransitions = map[Status][]Status{
+	StatusInStock:     {StatusAvailable, StatusInTransit, StatusUnavailable, StatusInRepair, StatusRetired},
+	StatusAvailable:   {StatusInTransit, StatusUnavailable, StatusInRepair, StatusOnLoan, StatusRetired},
+	StatusInTransit:   {StatusLocated, StatusAvailable},
+	StatusLocated:     {StatusInTransit, StatusUnavailable, StatusInRepair, StatusOnLoan, StatusRetired},
+	StatusUnavailable: {StatusAvailable, StatusLocated, StatusInRepair, StatusRetired},
+	StatusInRepair:    {StatusAvailable, StatusUnavailable, StatusRetired},
+	StatusOnLoan:      {StatusAvailable, StatusLocated},
+}
+
+// assetRelocations lists status changes of an asset found at another location than recorded, the status
+// follows the location it was found at. Only assets resting at a location can be relocated.
+var assetRelocations = map[Status][]Status{
+	StatusInStock:   {StatusAvailable, StatusLocated},
+	StatusAvailable: {StatusAvailable, StatusLocated},
+	StatusLocated:   {StatusLocated, StatusAvailable},
+}
+
 func NewStatus(value string) (Status, error) {
 	status := Status(value)
 	if !status.isValid() {
@@ -24,9 +54,45 @@ func NewStatus(value string) (Status, error) {
 
 func (s Status) isValid() bool {
 	switch s {
-	case StatusInStock, StatusInTransit, StatusLocated, StatusCompleted, StatusAvailable, StatusUnavailable, StatusCancelled:
+	case StatusInStock, StatusInTransit, StatusLocated, StatusCompleted, StatusAvailable, StatusUnavailable, StatusCancelled, StatusInRepair, StatusOnLoan, StatusRetired:
 		return true
 	default:
 		return false
 	}
 }
+
+// CanTransitionTo reports whether an asset in status s may be moved into status next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range assetTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAssetTransition returns InvalidStatusTransitionError when the asset lifecycle forbids the move.
+func ValidateAssetTransition(assetID int, from Status, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return custom_error.NewInvalidStatusTransitionError("asset", assetID, string(from), string(to))
+	}
+	return nil
+}
+
+// CanRelocateTo reports whether an asset in status s may be relocated to a location whose resting status is next.
+func (s Status) CanRelocateTo(next Status) bool {
+	for _, allowed := range assetRelocations[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateAssetRelocation returns InvalidStatusTransitionError when the asset cannot be relocated.
+func ValidateAssetRelocation(assetID int, from Status, to Status) error {
+	if !from.CanRelocateTo(to) {
+		return custom_error.NewInvalidStatusTransitionError("asset", assetID, string(from), string(to))
+	}
+	return nil
+}
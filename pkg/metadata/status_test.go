@@ -0,0 +1,91 @@
+package metadata
+
+import (
+	"errors"
+	"testing"
+
+	custom_error "warehouse/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from     Status
+		to       Status
+		expected bool
+	}{
+		{StatusAvailable, StatusInTransit, true},
+		{StatusInTransit, StatusLocated, true},
+		{StatusInTransit, StatusAvailable, true},
+		{StatusLocated, StatusInTransit, true},
+		{StatusUnavailable, StatusAvailable, true},
+		{StatusInStock, StatusInTransit, true},
+		{StatusLocated, StatusInRepair, true},
+		{StatusInRepair, StatusAvailable, true},
+		{StatusInRepair, StatusInTransit, false},
+		{StatusAvailable, StatusOnLoan, true},
+		{StatusOnLoan, StatusLocated, true},
+		{StatusOnLoan, StatusInTransit, false},
+		{StatusLocated, StatusLocated, false},
+		{StatusLocated, StatusAvailable, false},
+		{StatusAvailable, StatusLocated, false},
+		{StatusUnavailable, StatusInTransit, false},
+		{StatusCompleted, StatusInTransit, false},
+		{StatusLocated, StatusRetired, true},
+		{StatusInRepair, StatusRetired, true},
+		{StatusInTransit, StatusRetired, false},
+		{StatusOnLoan, StatusRetired, false},
+		{StatusRetired, StatusAvailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestValidateAssetTransition(t *testing.T) {
+	assert.NoError(t, ValidateAssetTransition(1, StatusAvailable, StatusInTransit))
+
+	err := ValidateAssetTransition(7, StatusLocated, StatusLocated)
+	var transitionErr *custom_error.InvalidStatusTransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, 7, transitionErr.ResourceID)
+	assert.Equal(t, "located", transitionErr.From)
+}
+
+func TestCanRelocateTo(t *testing.T) {
+	tests := []struct {
+		from     Status
+		to       Status
+		expected bool
+	}{
+		{StatusLocated, StatusAvailable, true},
+		{StatusAvailable, StatusLocated, true},
+		{StatusLocated, StatusLocated, true},
+		{StatusInStock, StatusAvailable, true},
+		{StatusInTransit, StatusLocated, false},
+		{StatusInRepair, StatusAvailable, false},
+		{StatusOnLoan, StatusLocated, false},
+		{StatusRetired, StatusAvailable, false},
+		{StatusAvailable, StatusInTransit, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanRelocateTo(tt.to))
+		})
+	}
+}
+
+func TestRelocationsDoNotWidenLifecycle(t *testing.T) {
+	assert.False(t, StatusLocated.CanTransitionTo(StatusAvailable))
+	assert.False(t, StatusAvailable.CanTransitionTo(StatusLocated))
+
+	err := ValidateAssetRelocation(3, StatusInRepair, StatusAvailable)
+	var transitionErr *custom_error.InvalidStatusTransitionError
+	assert.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, 3, transitionErr.ResourceID)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"time"
 	"warehouse/pkg/metadata"
 )
 
@@ -12,34 +13,42 @@ const (
 )
 
 type Asset struct {
-	ID       int             `json:"id" db:"asset_id"`
-	Serial   *string         `json:"serial" db:"item_serial"`
-	Location Location        `json:"location,omitempty"`
-	Category ItemCategory    `json:"category"`
-	Status   metadata.Status `json:"status"`
-	PyrCode  string          `json:"pyrcode"`
-	Origin   metadata.Origin `json:"origin"`
+	ID         int              `json:"id" db:"asset_id"`
+	Serial     *string          `json:"serial" db:"item_serial"`
+	Location   Location         `json:"location,omitempty"`
+	Category   ItemCategory     `json:"category"`
+	Status     metadata.Status  `json:"status"`
+	PyrCode    string           `json:"pyrcode"`
+	Origin     metadata.Origin  `json:"origin"`
+	Holder     *AssetHolder     `json:"holder,omitempty" db:"-"`
+	Attributes AssetAttributes  `json:"attributes,omitempty"`
+	Retirement *AssetRetirement `json:"retirement,omitempty"`
 }
 
 type FlatAssetRecord struct {
-	ID                    int            `db:"asset_id"`
-	Serial                sql.NullString `db:"item_serial"`
-	Status                string         `db:"status"`
-	Origin                string         `db:"origin"`
-	PyrCode               sql.NullString `db:"pyr_code"`
-	LocationId            int            `db:"location_id"`
-	LocationName          string         `db:"location_name"`
-	LocationPavilion      sql.NullString `db:"location_pavilion"`
-	CategoryId            int            `db:"category_id"`
-	CategoryType          string         `db:"category_type"`
-	CategoryLabel         string         `db:"category_label"`
-	CategoryPyrId         string         `db:"category_pyr_id"`
-	CategoryEquipmentType string         `db:"category_equipment_type"`
+	ID                    int             `db:"asset_id"`
+	Serial                sql.NullString  `db:"item_serial"`
+	Status                string          `db:"status"`
+	Origin                string          `db:"origin"`
+	PyrCode               sql.NullString  `db:"pyr_code"`
+	LocationId            int             `db:"location_id"`
+	LocationName          string          `db:"location_name"`
+	LocationPavilion      sql.NullString  `db:"location_pavilion"`
+	CategoryId            int             `db:"category_id"`
+	CategoryType          string          `db:"category_type"`
+	CategoryLabel         string          `db:"category_label"`
+	CategoryPyrId         string          `db:"category_pyr_id"`
+	CategoryEquipmentType string          `db:"category_equipment_type"`
+	Attributes            AssetAttributes `db:"attributes"`
+	RetiredAt             *time.Time      `db:"retired_at"`
+	RetirementReason      sql.NullString  `db:"retirement_reason"`
+	RetirementNotes       *string         `db:"retirement_notes"`
+	RetiredByID           *int            `db:"retired_by_id"`
 }
 
 func (fa *FlatAssetRecord) TransformToAsset() Asset {
 	status, _ := metadata.NewStatus(fa.Status)
-	origin, _ := metadata.NewOrigin(fa.Origin)
+	origin := metadata.Origin(fa.Origin)
 
 	var serial *string
 	if fa.Serial.Valid {
@@ -51,6 +60,16 @@ func (fa *FlatAssetRecord) TransformToAsset() Asset {
 		pavilion = &fa.LocationPavilion.String
 	}
 
+	var retirement *AssetRetirement
+	if fa.RetiredAt != nil {
+		retirement = &AssetRetirement{
+			Reason:      fa.RetirementReason.String,
+			RetiredAt:   *fa.RetiredAt,
+			RetiredByID: fa.RetiredByID,
+			Notes:       fa.RetirementNotes,
+		}
+	}
+
 	return Asset{
 		ID:      fa.ID,
 		Serial:  serial,
@@ -69,6 +88,8 @@ func (fa *FlatAssetRecord) TransformToAsset() Asset {
 			PyrID: fa.CategoryPyrId,
 			Type:  fa.CategoryEquipmentType,
 		},
+		Attributes: fa.Attributes,
+		Retirement: retirement,
 	}
 }
 
@@ -78,3 +99,17 @@ func (a *Asset) CreateLogView() AuditLog {
 		ResourceType: "asset",
 	}
 }
+
+type AssetStatusChange struct {
+	AssetID int             `json:"asset_id"`
+	From    metadata.Status `json:"from"`
+	To      metadata.Status `json:"to"`
+}
+
+// AssetStatusAtLocation returns status of an asset resting in given location, warehouse stock is available, anything else is located
+func AssetStatusAtLocation(locationID int) metadata.Status {
+	if locationID == DefaultEquipmentLocationID {
+		return metadata.StatusAvailable
+	}
+	return metadata.StatusLocated
+}
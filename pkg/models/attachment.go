@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	AttachmentResourceAsset              = "asset"
+	AttachmentResourceTransfer           = "transfer"
+	AttachmentResourceServiceDeskRequest = "service_desk_request"
+)
+
+const (
+	AttachmentKindDocument      = "document"
+	AttachmentKindDamage        = "damage"
+	AttachmentKindDeliveryProof = "delivery_proof"
+	AttachmentKindInvoice       = "invoice"
+)
+
+type Attachment struct {
+	ID           int       `json:"id" db:"id"`
+	ResourceType string    `json:"resource_type" db:"resource_type"`
+	ResourceID   int       `json:"resource_id" db:"resource_id"`
+	Kind         string    `json:"kind" db:"kind"`
+	Filename     string    `json:"filename" db:"filename"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	Size         int64     `json:"size" db:"size"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	ThumbnailKey *string   `json:"-" db:"thumbnail_key"`
+	HasThumbnail bool      `json:"has_thumbnail" db:"-"`
+	MinRole      string    `json:"min_role" db:"min_role"`
+	UploadedByID *int      `json:"uploaded_by_id,omitempty" db:"uploaded_by_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+func (a *Attachment) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   a.ID,
+		ResourceType: "attachment",
+	}
+}
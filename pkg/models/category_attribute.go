@@ -0,0 +1,204 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type AttributeType string
+
+const (
+	AttributeTypeString  AttributeType = "string"
+	AttributeTypeNumber  AttributeType = "number"
+	AttributeTypeBoolean AttributeType = "boolean"
+	AttributeTypeEnum    AttributeType = "enum"
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// AttributeOptions lists allowed values of an enum attribute, stored as JSONB
+type AttributeOptions []string
+
+func (o AttributeOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal([]string(o))
+	return string(b), err
+}
+
+func (o *AttributeOptions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*o = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(o))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(o))
+	default:
+		return fmt.Errorf("unsupported attribute options type %T", src)
+	}
+}
+
+// AssetAttributes holds custom attribute values of an asset, stored as JSONB
+type AssetAttributes map[string]interface{}
+
+func (a AssetAttributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]interface{}(a))
+	return string(b), err
+}
+
+func (a *AssetAttributes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*map[string]interface{})(a))
+	case string:
+		return json.Unmarshal([]byte(v), (*map[string]interface{})(a))
+	default:
+		return fmt.Errorf("unsupported asset attributes type %T", src)
+	}
+}
+
+// CategoryAttribute describes a single custom attribute of assets in a category
+type CategoryAttribute struct {
+	ID         int              `json:"id,omitempty" db:"id"`
+	CategoryID int              `json:"category_id,omitempty" db:"item_category_id"`
+	Key        string           `json:"key" db:"key" binding:"required"`
+	Label      string           `json:"label" db:"label" binding:"required"`
+	Type       AttributeType    `json:"type" db:"type" binding:"required,oneof=string number boolean enum"`
+	Required   bool             `json:"required" db:"required"`
+	Options    AttributeOptions `json:"options,omitempty" db:"options"`
+	Position   int              `json:"position" db:"position"`
+}
+
+func (a *CategoryAttribute) Validate() error {
+	if !attributeKeyPattern.MatchString(a.Key) {
+		return fmt.Errorf("attribute key %q must be snake_case", a.Key)
+	}
+	if a.Type == AttributeTypeEnum && len(a.Options) == 0 {
+		return fmt.Errorf("enum attribute %q requires options", a.Key)
+	}
+	if a.Type != AttributeTypeEnum && len(a.Options) > 0 {
+		return fmt.Errorf("only enum attributes can define options")
+	}
+	return nil
+}
+
+// AttributeValidationError collects per-attribute errors
+type AttributeValidationError struct {
+	Errors map[string]string `json:"errors"`
+}
+
+func (e *AttributeValidationError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key+": "+e.Errors[key])
+	}
+	return "invalid attributes: " + strings.Join(messages, "; ")
+}
+
+// ValidateAttributes checks values against the category schema and returns them normalized to their types.
+// With requireAll unset missing required attributes are accepted (e.g. placeholder assets).
+func ValidateAttributes(schema []CategoryAttribute, values map[string]interface{}, requireAll bool) (map[string]interface{}, error) {
+	normalized := map[string]interface{}{}
+	errs := map[string]string{}
+
+	known := make(map[string]CategoryAttribute, len(schema))
+	for _, attr := range schema {
+		known[attr.Key] = attr
+	}
+
+	for key, value := range values {
+		attr, ok := known[key]
+		if !ok {
+			errs[key] = "nieznany atrybut"
+			continue
+		}
+		if value == nil {
+			continue
+		}
+
+		v, err := attr.normalize(value)
+		if err != nil {
+			errs[key] = err.Error()
+			continue
+		}
+		normalized[key] = v
+	}
+
+	if requireAll {
+		for _, attr := range schema {
+			if _, ok := normalized[attr.Key]; attr.Required && !ok {
+				if _, failed := errs[attr.Key]; !failed {
+					errs[attr.Key] = "atrybut jest wymagany"
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &AttributeValidationError{Errors: errs}
+	}
+	return normalized, nil
+}
+
+func (a *CategoryAttribute) normalize(value interface{}) (interface{}, error) {
+	switch a.Type {
+	case AttributeTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("wartość musi być liczbą")
+	case AttributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("wartość musi być typu logicznego")
+	case AttributeTypeEnum:
+		s, ok := value.(string)
+		if ok {
+			for _, option := range a.Options {
+				if option == s {
+					return s, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("dozwolone wartości: %s", strings.Join(a.Options, ", "))
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("wartość musi być tekstem")
+		}
+		return s, nil
+	}
+}
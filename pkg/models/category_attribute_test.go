@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func laptopSchema() []CategoryAttribute {
+	return []CategoryAttribute{
+		{Key: "ram", Label: "RAM (GB)", Type: AttributeTypeNumber, Required: true},
+		{Key: "os", Label: "System", Type: AttributeTypeEnum, Options: AttributeOptions{"windows", "linux", "macos"}},
+		{Key: "charger", Label: "Ładowarka", Type: AttributeTypeBoolean},
+		{Key: "notes", Label: "Uwagi", Type: AttributeTypeString},
+	}
+}
+
+func TestValidateAttributes(t *testing.T) {
+	values, err := ValidateAttributes(laptopSchema(), map[string]interface{}{
+		"ram":     "16",
+		"os":      "linux",
+		"charger": true,
+		"notes":   nil,
+	}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ram": 16.0, "os": "linux", "charger": true}, values)
+}
+
+func TestValidateAttributesErrors(t *testing.T) {
+	_, err := ValidateAttributes(laptopSchema(), map[string]interface{}{
+		"os":      "dos",
+		"charger": "maybe",
+		"color":   "red",
+	}, true)
+
+	var validationErr *AttributeValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Errors, 4)
+	assert.Contains(t, validationErr.Errors, "ram")
+	assert.Contains(t, validationErr.Errors, "color")
+}
+
+func TestValidateAttributesWithoutRequired(t *testing.T) {
+	values, err := ValidateAttributes(laptopSchema(), map[string]interface{}{}, false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestCategoryAttributeValidate(t *testing.T) {
+	assert.NoError(t, (&CategoryAttribute{Key: "lamp_hours", Type: AttributeTypeNumber}).Validate())
+	assert.Error(t, (&CategoryAttribute{Key: "Lamp Hours", Type: AttributeTypeNumber}).Validate())
+	assert.Error(t, (&CategoryAttribute{Key: "os", Type: AttributeTypeEnum}).Validate())
+	assert.Error(t, (&CategoryAttribute{Key: "ram", Type: AttributeTypeNumber, Options: AttributeOptions{"1"}}).Validate())
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// InventorySnapshot is the content of a location reconstructed from asset history and the stock ledger
+type InventorySnapshot struct {
+	LocationID int             `json:"location_id"`
+	At         time.Time       `json:"at"`
+	Assets     []SnapshotAsset `json:"assets"`
+	Stock      []SnapshotStock `json:"stock"`
+}
+
+// SnapshotAsset is an asset as it was at the snapshot time, category and code are missing for deleted assets
+type SnapshotAsset struct {
+	AssetID       int     `json:"asset_id" db:"item_id"`
+	PyrCode       *string `json:"pyr_code,omitempty" db:"pyr_code"`
+	CategoryLabel *string `json:"category_label,omitempty" db:"category_label"`
+	Status        *string `json:"status,omitempty" db:"status"`
+}
+
+type SnapshotStock struct {
+	CategoryID    int     `json:"category_id" db:"item_category_id"`
+	CategoryLabel string  `json:"category_label" db:"category_label"`
+	Origin        *string `json:"origin,omitempty" db:"origin"`
+	Quantity      int     `json:"quantity" db:"quantity"`
+}
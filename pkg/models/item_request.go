@@ -1,20 +1,22 @@
 package models
 
 type ItemRequest struct {
-	ID         int     `json:"id"`
-	Serial     *string `json:"serial" binding:"omitempty"`
-	LocationId int     `json:"location_id" default:"1"`
-	Status     string  `json:"status"`
-	CategoryId int     `json:"category_id" binding:"required"`
-	Origin     string  `json:"origin"`
+	ID         int             `json:"id"`
+	Serial     *string         `json:"serial" binding:"omitempty"`
+	LocationId int             `json:"location_id" default:"1"`
+	Status     string          `json:"status"`
+	CategoryId int             `json:"category_id" binding:"required"`
+	Origin     string          `json:"origin"`
+	Attributes AssetAttributes `json:"attributes"`
 }
 
 type BulkItemRequest struct {
-	Serials    []*string `json:"serials" binding:"omitempty,min=1"`
-	LocationId int       `json:"location_id" default:"1"`
-	Status     string    `json:"status"`
-	CategoryId int       `json:"category_id" binding:"required"`
-	Origin     string    `json:"origin"`
+	Serials    []*string       `json:"serials" binding:"omitempty,min=1"`
+	LocationId int             `json:"location_id" default:"1"`
+	Status     string          `json:"status"`
+	CategoryId int             `json:"category_id" binding:"required"`
+	Origin     string          `json:"origin"`
+	Attributes AssetAttributes `json:"attributes"`
 }
 
 type PatchItemCategoryRequest struct {
@@ -33,9 +35,37 @@ type CreateAssetRequest struct {
 }
 
 type EmergencyAssetRequest struct {
-	Quantity   int    `json:"quantity" binding:"required,min=1"`
-	LocationId int    `json:"location_id" default:"1"`
-	Status     string `json:"status"`
-	CategoryId int    `json:"category_id" binding:"required"`
-	Origin     string `json:"origin"`
+	Quantity   int             `json:"quantity" binding:"required,min=1"`
+	LocationId int             `json:"location_id" default:"1"`
+	Status     string          `json:"status"`
+	CategoryId int             `json:"category_id" binding:"required"`
+	Origin     string          `json:"origin"`
+	Attributes AssetAttributes `json:"attributes"`
+}
+
+type ReplaceCategoryAttributesRequest struct {
+	Attributes []CategoryAttribute `json:"attributes" binding:"dive"`
+}
+
+type PatchAssetAttributesRequest struct {
+	ID         int                    `uri:"id" binding:"required"`
+	Attributes map[string]interface{} `json:"attributes" binding:"required"`
+}
+
+type ReissuePyrCodesRequest struct {
+	AssetIDs   []int `json:"asset_ids"`
+	CategoryID int   `json:"category_id"`
+}
+
+type PyrCodeReissue struct {
+	AssetID    int    `json:"asset_id"`
+	OldPyrCode string `json:"old_pyr_code"`
+	NewPyrCode string `json:"new_pyr_code"`
+}
+
+// PyrCodeSuggestion is a close match offered when a typed PYR code does not resolve exactly
+type PyrCodeSuggestion struct {
+	AssetID  int    `json:"asset_id" db:"id"`
+	PyrCode  string `json:"pyr_code" db:"pyr_code"`
+	Distance int    `json:"distance" db:"-"`
 }
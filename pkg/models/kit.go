@@ -0,0 +1,48 @@
+package models
+
+const (
+	KitWarningStageDispatch = "dispatch"
+	KitWarningStageDelivery = "delivery"
+)
+
+// KitComponent is an asset attached to a kit parent
+type KitComponent struct {
+	KitID         int     `json:"-" db:"kit_item_id"`
+	KitPyrCode    *string `json:"-" db:"kit_pyr_code"`
+	AssetID       int     `json:"asset_id" db:"item_id"`
+	PyrCode       *string `json:"pyr_code,omitempty" db:"pyr_code"`
+	CategoryLabel string  `json:"category_label" db:"category_label"`
+	Status        string  `json:"status" db:"status"`
+	LocationID    int     `json:"location_id" db:"location_id"`
+}
+
+// KitStockComponent is a stock quantity which always travels with a kit parent
+type KitStockComponent struct {
+	KitID         int    `json:"-" db:"kit_item_id"`
+	CategoryID    int    `json:"category_id" db:"item_category_id"`
+	CategoryLabel string `json:"category_label,omitempty" db:"category_label"`
+	Quantity      int    `json:"quantity" db:"quantity"`
+}
+
+type Kit struct {
+	Asset           Asset               `json:"asset"`
+	Components      []KitComponent      `json:"components"`
+	StockComponents []KitStockComponent `json:"stock_components"`
+}
+
+func (k *Kit) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   k.Asset.ID,
+		ResourceType: "kit",
+	}
+}
+
+// KitWarning reports a kit which does not travel complete in a transfer
+type KitWarning struct {
+	KitID         int                 `json:"kit_id"`
+	PyrCode       string              `json:"pyr_code"`
+	Stage         string              `json:"stage"`
+	ParentMissing bool                `json:"parent_missing,omitempty"`
+	MissingAssets []KitComponent      `json:"missing_assets,omitempty"`
+	MissingStocks []KitStockComponent `json:"missing_stocks,omitempty"`
+}
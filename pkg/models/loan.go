@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// AssetHolder is the person currently holding a checked out asset
+type AssetHolder struct {
+	LoanID        int       `json:"loan_id" db:"id"`
+	UserID        *int      `json:"user_id,omitempty" db:"user_id"`
+	Username      *string   `json:"username,omitempty" db:"username"`
+	Fullname      *string   `json:"fullname,omitempty" db:"fullname"`
+	RecipientName *string   `json:"recipient_name,omitempty" db:"recipient_name"`
+	DueAt         time.Time `json:"due_at" db:"due_at"`
+}
+
+type AssetLoan struct {
+	ID             int        `json:"id" db:"id"`
+	AssetID        int        `json:"asset_id" db:"item_id"`
+	PyrCode        *string    `json:"pyr_code,omitempty" db:"pyr_code"`
+	UserID         *int       `json:"user_id,omitempty" db:"user_id"`
+	Username       *string    `json:"username,omitempty" db:"username"`
+	Fullname       *string    `json:"fullname,omitempty" db:"fullname"`
+	RecipientName  *string    `json:"recipient_name,omitempty" db:"recipient_name"`
+	Notes          *string    `json:"notes,omitempty" db:"notes"`
+	CheckedOutByID *int       `json:"checked_out_by_id,omitempty" db:"checked_out_by_id"`
+	CheckedOutAt   time.Time  `json:"checked_out_at" db:"checked_out_at"`
+	DueAt          time.Time  `json:"due_at" db:"due_at"`
+	ReturnedByID   *int       `json:"returned_by_id,omitempty" db:"returned_by_id"`
+	ReturnedAt     *time.Time `json:"returned_at,omitempty" db:"returned_at"`
+}
+
+func (l *AssetLoan) IsOverdue(now time.Time) bool {
+	return l.ReturnedAt == nil && now.After(l.DueAt)
+}
+
+func (l *AssetLoan) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   l.ID,
+		ResourceType: "loan",
+	}
+}
@@ -7,4 +7,13 @@ type Location struct {
 	Name     string  `json:"name" db:"name"`
 	Pavilion *string `json:"pavilion" db:"pavilion"`
 	Details  *string `json:"details" db:"details"`
+	// Coordinates and radius in meters used to check where transfers get delivered
+	Latitude       *float64 `json:"latitude,omitempty" db:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude      *float64 `json:"longitude,omitempty" db:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	GeofenceRadius *int     `json:"geofence_radius,omitempty" db:"geofence_radius" binding:"omitempty,gt=0"`
+}
+
+// HasCoordinates reports whether the location can be used for a geofence check
+func (l *Location) HasCoordinates() bool {
+	return l.Latitude != nil && l.Longitude != nil
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Origin is the source an item came from, rental companies are flagged with IsRental
+type Origin struct {
+	ID           int       `json:"id" db:"id"`
+	Slug         string    `json:"slug" db:"slug"`
+	Name         string    `json:"name" db:"name"`
+	IsRental     bool      `json:"is_rental" db:"is_rental"`
+	ContactName  *string   `json:"contact_name,omitempty" db:"contact_name"`
+	ContactEmail *string   `json:"contact_email,omitempty" db:"contact_email"`
+	ContactPhone *string   `json:"contact_phone,omitempty" db:"contact_phone"`
+	Notes        *string   `json:"notes,omitempty" db:"notes"`
+	Active       bool      `json:"active" db:"active"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+func (o *Origin) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   o.ID,
+		ResourceType: "origin",
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+const (
+	RentalStatusOpen     = "open"
+	RentalStatusReturned = "returned"
+)
+
+const (
+	RentalReturnModeRetire = "retire"
+	RentalReturnModeMove   = "move"
+)
+
+// RentalContract groups equipment rented from an origin company which has to be returned by the due date
+type RentalContract struct {
+	ID          int                   `json:"id" db:"id"`
+	Origin      string                `json:"origin" db:"origin"`
+	Reference   *string               `json:"reference,omitempty" db:"reference"`
+	RentedFrom  time.Time             `json:"rented_from" db:"rented_from"`
+	DueDate     time.Time             `json:"due_date" db:"due_date"`
+	Status      string                `json:"status" db:"status"`
+	Notes       *string               `json:"notes,omitempty" db:"notes"`
+	CreatedByID *int                  `json:"created_by_id,omitempty" db:"created_by_id"`
+	CreatedAt   time.Time             `json:"created_at" db:"created_at"`
+	ReturnedAt  *time.Time            `json:"returned_at,omitempty" db:"returned_at"`
+	Overdue     bool                  `json:"overdue" db:"-"`
+	Assets      []RentalContractAsset `json:"assets,omitempty" db:"-"`
+	Stocks      []RentalContractStock `json:"stocks,omitempty" db:"-"`
+}
+
+func (rc *RentalContract) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   rc.ID,
+		ResourceType: "rental_contract",
+	}
+}
+
+// RentalContractAsset is a checklist entry of a rented asset, it is ready once the asset rests in the warehouse
+type RentalContractAsset struct {
+	ContractID    int        `json:"contract_id" db:"contract_id"`
+	AssetID       int        `json:"asset_id" db:"item_id"`
+	PyrCode       *string    `json:"pyr_code,omitempty" db:"pyr_code"`
+	CategoryLabel string     `json:"category_label" db:"category_label"`
+	Status        string     `json:"status" db:"status"`
+	LocationID    int        `json:"location_id" db:"location_id"`
+	LocationName  string     `json:"location_name" db:"location_name"`
+	ReturnedAt    *time.Time `json:"returned_at,omitempty" db:"returned_at"`
+	Ready         bool       `json:"ready" db:"-"`
+}
+
+// RentalContractStock is a checklist entry of rented stock, InWarehouse is the quantity of the origin's stock in the warehouse
+type RentalContractStock struct {
+	ContractID       int    `json:"contract_id" db:"contract_id"`
+	CategoryID       int    `json:"category_id" db:"item_category_id"`
+	CategoryLabel    string `json:"category_label" db:"category_label"`
+	Quantity         int    `json:"quantity" db:"quantity"`
+	ReturnedQuantity int    `json:"returned_quantity" db:"returned_quantity"`
+	InWarehouse      int    `json:"in_warehouse" db:"in_warehouse"`
+}
+
+func (s RentalContractStock) Outstanding() int {
+	return s.Quantity - s.ReturnedQuantity
+}
+
+// SupplierRentalStatus summarizes equipment which still has to be returned to an origin
+type SupplierRentalStatus struct {
+	Origin        string                `json:"origin"`
+	ContractIDs   []int                 `json:"contract_ids"`
+	NextDueDate   time.Time             `json:"next_due_date"`
+	Overdue       bool                  `json:"overdue"`
+	ReadyAssets   int                   `json:"ready_assets"`
+	MissingAssets []RentalContractAsset `json:"missing_assets"`
+	MissingStocks []RentalMissingStock  `json:"missing_stocks"`
+}
+
+type RentalMissingStock struct {
+	CategoryID    int    `json:"category_id"`
+	CategoryLabel string `json:"category_label"`
+	Outstanding   int    `json:"outstanding"`
+	InWarehouse   int    `json:"in_warehouse"`
+	Missing       int    `json:"missing"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	RepairStatusOpen         = "open"
+	RepairStatusRepaired     = "repaired"
+	RepairStatusUnrepairable = "unrepairable"
+)
+
+type RepairTicket struct {
+	ID                   int        `json:"id" db:"id"`
+	AssetID              int        `json:"asset_id" db:"item_id"`
+	PyrCode              *string    `json:"pyr_code,omitempty" db:"pyr_code"`
+	Status               string     `json:"status" db:"status"`
+	Vendor               *string    `json:"vendor,omitempty" db:"vendor"`
+	Cost                 *float64   `json:"cost,omitempty" db:"cost"`
+	Notes                *string    `json:"notes,omitempty" db:"notes"`
+	ExpectedReturnDate   *time.Time `json:"expected_return_date,omitempty" db:"expected_return_date"`
+	ServiceDeskRequestID *int       `json:"service_desk_request_id,omitempty" db:"service_desk_request_id"`
+	CreatedByID          *int       `json:"created_by_id,omitempty" db:"created_by_id"`
+	OpenedAt             time.Time  `json:"opened_at" db:"opened_at"`
+	ClosedAt             *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+func (rt *RepairTicket) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   rt.ID,
+		ResourceType: "repair",
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusFulfilled = "fulfilled"
+	ReservationStatusCancelled = "cancelled"
+	// ReservationStatusExpired is never stored, an active reservation past its expiry is reported with it
+	ReservationStatusExpired = "expired"
+)
+
+const (
+	ReservationConflictAssetUnavailable = "asset_unavailable"
+	ReservationConflictAssetDoubleBook  = "asset_double_booked"
+	ReservationConflictStockShortage    = "stock_shortage"
+)
+
+// Reservation holds assets and stock for a planned transfer until it is fulfilled, cancelled or expires
+type Reservation struct {
+	ID               int                   `json:"id" db:"id"`
+	FromLocationID   int                   `json:"from_location_id" db:"from_location_id"`
+	FromLocationName string                `json:"from_location_name" db:"from_location_name"`
+	ToLocationID     int                   `json:"location_id" db:"to_location_id"`
+	ToLocationName   string                `json:"location_name" db:"to_location_name"`
+	PlannedAt        time.Time             `json:"planned_at" db:"planned_at"`
+	ExpiresAt        time.Time             `json:"expires_at" db:"expires_at"`
+	Status           string                `json:"status" db:"status"`
+	TransferID       *int                  `json:"transfer_id,omitempty" db:"transfer_id"`
+	Notes            *string               `json:"notes,omitempty" db:"notes"`
+	CreatedByID      *int                  `json:"created_by_id,omitempty" db:"created_by_id"`
+	CreatedAt        time.Time             `json:"created_at" db:"created_at"`
+	Assets           []ReservationAsset    `json:"assets" db:"-"`
+	Stocks           []ReservationStock    `json:"stocks" db:"-"`
+	Conflicts        []ReservationConflict `json:"conflicts,omitempty" db:"-"`
+}
+
+// IsHolding reports whether the reservation still blocks its assets and stock
+func (r *Reservation) IsHolding(now time.Time) bool {
+	return r.Status == ReservationStatusActive && r.ExpiresAt.After(now)
+}
+
+// ReportedStatus replaces the stored status of an active reservation past its expiry with expired
+func (r *Reservation) ReportedStatus(now time.Time) string {
+	if r.Status == ReservationStatusActive && !r.ExpiresAt.After(now) {
+		return ReservationStatusExpired
+	}
+	return r.Status
+}
+
+func (r *Reservation) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   r.ID,
+		ResourceType: "reservation",
+	}
+}
+
+// ReservationAsset is a reserved asset with its current location and status
+type ReservationAsset struct {
+	ReservationID int     `json:"-" db:"reservation_id"`
+	AssetID       int     `json:"id" db:"item_id"`
+	PyrCode       *string `json:"pyr_code,omitempty" db:"pyr_code"`
+	CategoryLabel string  `json:"category_label" db:"category_label"`
+	LocationID    int     `json:"location_id" db:"location_id"`
+	Status        string  `json:"status" db:"status"`
+}
+
+// ReservationStock is a reserved stock quantity with the current state of its stock row, a removed row has no ID
+type ReservationStock struct {
+	ReservationID   int    `json:"-" db:"reservation_id"`
+	StockItemID     *int   `json:"id,omitempty" db:"stock_item_id"`
+	CategoryID      int    `json:"category_id" db:"item_category_id"`
+	CategoryLabel   string `json:"category_label" db:"category_label"`
+	Quantity        int    `json:"quantity" db:"quantity"`
+	StockLocationID *int   `json:"-" db:"stock_location_id"`
+	StockQuantity   int    `json:"-" db:"stock_quantity"`
+}
+
+// ReservationConflict is a promise that cannot be kept, it lists every reservation involved
+type ReservationConflict struct {
+	Type           string `json:"type"`
+	AssetID        *int   `json:"asset_id,omitempty"`
+	StockItemID    *int   `json:"stock_item_id,omitempty"`
+	CategoryID     *int   `json:"category_id,omitempty"`
+	Reserved       int    `json:"reserved,omitempty"`
+	Available      int    `json:"available,omitempty"`
+	ReservationIDs []int  `json:"reservation_ids"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const (
+	RetirementReasonLost             = "lost"
+	RetirementReasonStolen           = "stolen"
+	RetirementReasonBroken           = "broken"
+	RetirementReasonReturnedToOrigin = "returned_to_origin"
+)
+
+// AssetRetirement describes why and when an asset was taken out of the inventory
+type AssetRetirement struct {
+	Reason      string    `json:"reason"`
+	RetiredAt   time.Time `json:"retired_at"`
+	RetiredByID *int      `json:"retired_by_id,omitempty"`
+	Notes       *string   `json:"notes,omitempty"`
+}
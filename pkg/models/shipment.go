@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	ShipmentStatusInTransit = "in_transit"
+	ShipmentStatusCompleted = "completed"
+	ShipmentStatusCancelled = "cancelled"
+)
+
+// Statuses of legs without a transfer, a started leg reports the status of its transfer
+const (
+	ShipmentLegPlanned   = "planned"
+	ShipmentLegCancelled = "cancelled"
+)
+
+// Shipment moves assets and stock to the destination through intermediate locations, one transfer per leg.
+// A leg starts once the previous one is completed.
+type Shipment struct {
+	ID                    int           `json:"id" db:"id"`
+	FromLocationID        int           `json:"from_location_id" db:"from_location_id"`
+	FromLocationName      string        `json:"from_location_name" db:"from_location_name"`
+	ToLocationID          int           `json:"location_id" db:"to_location_id"`
+	ToLocationName        string        `json:"location_name" db:"to_location_name"`
+	Status                string        `json:"status" db:"status"`
+	RequiresDeliveryPhoto bool          `json:"requires_delivery_photo" db:"requires_delivery_photo"`
+	Notes                 *string       `json:"notes,omitempty" db:"notes"`
+	CreatedByID           *int          `json:"created_by_id,omitempty" db:"created_by_id"`
+	CreatedAt             time.Time     `json:"created_at" db:"created_at"`
+	CompletedAt           *time.Time    `json:"completed_at,omitempty" db:"completed_at"`
+	Legs                  []ShipmentLeg `json:"legs" db:"-"`
+	// CurrentLeg is the first leg not completed yet, ETA is the expected arrival at its end
+	CurrentLeg *int       `json:"current_leg,omitempty" db:"-"`
+	ETA        *time.Time `json:"eta,omitempty" db:"-"`
+}
+
+func (s *Shipment) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   s.ID,
+		ResourceType: "shipment",
+	}
+}
+
+type ShipmentLeg struct {
+	ID               int        `json:"-" db:"id"`
+	ShipmentID       int        `json:"-" db:"shipment_id"`
+	Number           int        `json:"leg" db:"leg_number"`
+	FromLocationID   int        `json:"from_location_id" db:"from_location_id"`
+	FromLocationName string     `json:"from_location_name" db:"from_location_name"`
+	ToLocationID     int        `json:"location_id" db:"to_location_id"`
+	ToLocationName   string     `json:"location_name" db:"to_location_name"`
+	ETA              *time.Time `json:"eta,omitempty" db:"eta"`
+	TransferID       *int       `json:"transfer_id,omitempty" db:"transfer_id"`
+	TransferStatus   *string    `json:"-" db:"transfer_status"`
+	Status           string     `json:"status" db:"-"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// Reasons chosen by users for manual quantity changes
+const (
+	AdjustmentReasonCountCorrection = "count_correction"
+	AdjustmentReasonDamaged         = "damaged"
+	AdjustmentReasonFound           = "found"
+	AdjustmentReasonLost            = "lost"
+)
+
+// Reasons recorded automatically by the flows that move stock
+const (
+	AdjustmentReasonOpening     = "opening"
+	AdjustmentReasonCreated     = "created"
+	AdjustmentReasonRemoved     = "removed"
+	AdjustmentReasonRelocated   = "relocated"
+	AdjustmentReasonTransfer    = "transfer"
+	AdjustmentReasonStocktake   = "stocktake"
+	AdjustmentReasonImport      = "import"
+	AdjustmentReasonConsumption = "consumption"
+	AdjustmentReasonRental      = "rental"
+	AdjustmentReasonSplit       = "split"
+	AdjustmentReasonMerge       = "merge"
+)
+
+const (
+	AdjustmentStatusPending  = "pending"
+	AdjustmentStatusApplied  = "applied"
+	AdjustmentStatusRejected = "rejected"
+)
+
+// StockAdjustment is a ledger entry of a stock quantity change, only applied entries count towards the quantity
+type StockAdjustment struct {
+	ID            int        `json:"id" db:"id"`
+	StockItemID   *int       `json:"stock_item_id,omitempty" db:"stock_item_id"`
+	CategoryID    int        `json:"category_id" db:"item_category_id"`
+	CategoryLabel string     `json:"category_label" db:"category_label"`
+	LocationID    int        `json:"location_id" db:"location_id"`
+	LocationName  string     `json:"location_name" db:"location_name"`
+	Origin        *string    `json:"origin,omitempty" db:"origin"`
+	Delta         int        `json:"delta" db:"delta"`
+	Reason        string     `json:"reason" db:"reason"`
+	Status        string     `json:"status" db:"status"`
+	Notes         *string    `json:"notes,omitempty" db:"notes"`
+	RequestedByID *int       `json:"requested_by_id,omitempty" db:"requested_by_id"`
+	RequestedAt   time.Time  `json:"requested_at" db:"requested_at"`
+	ReviewedByID  *int       `json:"reviewed_by_id,omitempty" db:"reviewed_by_id"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNotes   *string    `json:"review_notes,omitempty" db:"review_notes"`
+	AppliedAt     *time.Time `json:"applied_at,omitempty" db:"applied_at"`
+	// CounterpartStockItemID is the other row of a split or merge
+	CounterpartStockItemID *int `json:"counterpart_stock_item_id,omitempty" db:"counterpart_stock_item_id"`
+}
+
+func (a *StockAdjustment) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   a.ID,
+		ResourceType: "stock_adjustment",
+	}
+}
+
+// StockHistoryEntry is an applied ledger entry with the quantity of the stock row right after it
+type StockHistoryEntry struct {
+	AdjustmentID  int       `json:"adjustment_id" db:"adjustment_id"`
+	LocationID    int       `json:"location_id" db:"location_id"`
+	LocationName  string    `json:"location_name" db:"location_name"`
+	Origin        *string   `json:"origin,omitempty" db:"origin"`
+	Delta         int       `json:"delta" db:"delta"`
+	Reason        string    `json:"reason" db:"reason"`
+	QuantityAfter int       `json:"quantity_after" db:"quantity_after"`
+	RequestedByID *int      `json:"requested_by_id,omitempty" db:"requested_by_id"`
+	AppliedAt     time.Time `json:"applied_at" db:"applied_at"`
+}
+
+// StockLedgerDiscrepancy is a stock row whose quantity differs from the sum of its applied ledger entries
+type StockLedgerDiscrepancy struct {
+	StockItemID    int    `json:"stock_item_id" db:"stock_item_id"`
+	CategoryID     int    `json:"category_id" db:"item_category_id"`
+	CategoryLabel  string `json:"category_label" db:"category_label"`
+	LocationID     int    `json:"location_id" db:"location_id"`
+	Quantity       int    `json:"quantity" db:"quantity"`
+	LedgerQuantity int    `json:"ledger_quantity" db:"ledger_quantity"`
+}
+
+// AdjustmentReasonAllows checks the direction of a manual change, damaged and lost stock can only go down, found only up
+func AdjustmentReasonAllows(reason string, delta int) bool {
+	switch reason {
+	case AdjustmentReasonDamaged, AdjustmentReasonLost:
+		return delta < 0
+	case AdjustmentReasonFound:
+		return delta > 0
+	}
+	return delta != 0
+}
+
+// RequiresApproval reports whether a manual change of given size has to wait for a moderator,
+// a non positive limit disables approvals
+func RequiresApproval(delta int, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+	if delta < 0 {
+		delta = -delta
+	}
+	return delta > limit
+}
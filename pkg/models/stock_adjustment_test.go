@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustmentReasonAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		reason   string
+		delta    int
+		expected bool
+	}{
+		{"Damaged decreases", AdjustmentReasonDamaged, -2, true},
+		{"Damaged cannot increase", AdjustmentReasonDamaged, 2, false},
+		{"Lost cannot increase", AdjustmentReasonLost, 1, false},
+		{"Found increases", AdjustmentReasonFound, 3, true},
+		{"Found cannot decrease", AdjustmentReasonFound, -3, false},
+		{"Count correction goes both ways", AdjustmentReasonCountCorrection, -5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AdjustmentReasonAllows(tt.reason, tt.delta))
+		})
+	}
+}
+
+func TestRequiresApproval(t *testing.T) {
+	tests := []struct {
+		name     string
+		delta    int
+		limit    int
+		expected bool
+	}{
+		{"Approvals disabled", 100, 0, false},
+		{"Within limit", 10, 10, false},
+		{"Increase above limit", 11, 10, true},
+		{"Decrease above limit", -11, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RequiresApproval(tt.delta, tt.limit))
+		})
+	}
+}
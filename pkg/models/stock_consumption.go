@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	ConsumptionGroupByCategory = "category"
+	ConsumptionGroupByDay      = "day"
+)
+
+// StockConsumption records stock that was used up rather than moved
+type StockConsumption struct {
+	ID                   int       `json:"id" db:"id"`
+	StockItemID          *int      `json:"stock_item_id,omitempty" db:"stock_item_id"`
+	CategoryID           int       `json:"category_id" db:"item_category_id"`
+	CategoryLabel        string    `json:"category_label" db:"category_label"`
+	LocationID           int       `json:"location_id" db:"location_id"`
+	LocationName         string    `json:"location_name" db:"location_name"`
+	Origin               *string   `json:"origin,omitempty" db:"origin"`
+	Quantity             int       `json:"quantity" db:"quantity"`
+	Reason               string    `json:"reason" db:"reason"`
+	UsedAtLocationID     *int      `json:"used_at_location_id,omitempty" db:"used_at_location_id"`
+	ServiceDeskRequestID *int      `json:"service_desk_request_id,omitempty" db:"service_desk_request_id"`
+	ConsumedByID         *int      `json:"consumed_by_id,omitempty" db:"consumed_by_id"`
+	ConsumedAt           time.Time `json:"consumed_at" db:"consumed_at"`
+}
+
+func (c *StockConsumption) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   c.ID,
+		ResourceType: "stock_consumption",
+	}
+}
+
+// ConsumptionReportRow sums consumption of a category, Day is set only when grouping per day
+type ConsumptionReportRow struct {
+	Day           *time.Time `json:"day,omitempty" db:"day"`
+	CategoryID    int        `json:"category_id" db:"item_category_id"`
+	CategoryLabel string     `json:"category_label" db:"category_label"`
+	Quantity      int        `json:"quantity" db:"quantity"`
+	Entries       int        `json:"entries" db:"entries"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// StockThreshold is a minimum quantity of a stock category, without a location it applies to the total in all locations
+type StockThreshold struct {
+	ID            int       `json:"id" db:"id"`
+	CategoryID    int       `json:"category_id" db:"item_category_id"`
+	CategoryLabel string    `json:"category_label" db:"category_label"`
+	LocationID    *int      `json:"location_id,omitempty" db:"location_id"`
+	LocationName  *string   `json:"location_name,omitempty" db:"location_name"`
+	MinQuantity   int       `json:"min_quantity" db:"min_quantity"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (t *StockThreshold) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   t.ID,
+		ResourceType: "stock_threshold",
+	}
+}
+
+// StockLevel compares the current quantity with a threshold
+type StockLevel struct {
+	ThresholdID   int     `json:"threshold_id" db:"threshold_id"`
+	CategoryID    int     `json:"category_id" db:"item_category_id"`
+	CategoryLabel string  `json:"category_label" db:"category_label"`
+	LocationID    *int    `json:"location_id,omitempty" db:"location_id"`
+	LocationName  *string `json:"location_name,omitempty" db:"location_name"`
+	MinQuantity   int     `json:"min_quantity" db:"min_quantity"`
+	Quantity      int     `json:"quantity" db:"quantity"`
+}
+
+func (l StockLevel) IsBelowMinimum() bool {
+	return l.Quantity < l.MinQuantity
+}
+
+// CrossedMinimum reports whether taking decreased units out is what pushed the level below its minimum
+func (l StockLevel) CrossedMinimum(decreased int) bool {
+	return l.IsBelowMinimum() && l.Quantity+decreased >= l.MinQuantity
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockLevelCrossedMinimum(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  int
+		decreased int
+		expected  bool
+	}{
+		{"Still above minimum", 10, 5, false},
+		{"Dropped below minimum", 4, 3, true},
+		{"Dropped from exactly minimum", 4, 1, true},
+		{"Was already below minimum", 2, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := StockLevel{MinQuantity: 5, Quantity: tt.quantity}
+			assert.Equal(t, tt.expected, level.CrossedMinimum(tt.decreased))
+		})
+	}
+}
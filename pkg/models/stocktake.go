@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	StocktakeStatusOpen    = "open"
+	StocktakeStatusClosed  = "closed"
+	StocktakeStatusApplied = "applied"
+)
+
+type Stocktake struct {
+	ID          int        `json:"id" db:"id"`
+	LocationID  int        `json:"location_id" db:"location_id"`
+	Status      string     `json:"status" db:"status"`
+	StartedByID *int       `json:"started_by_id,omitempty" db:"started_by_id"`
+	StartedAt   time.Time  `json:"started_at" db:"started_at"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	AppliedByID *int       `json:"applied_by_id,omitempty" db:"applied_by_id"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty" db:"applied_at"`
+}
+
+func (s *Stocktake) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   s.ID,
+		ResourceType: "stocktake",
+	}
+}
@@ -9,18 +9,60 @@ type Transfer struct {
 	FromLocation Location `json:"from_location"`
 	ToLocation   Location `json:"to_location"`
 	// ItemCollection       []interface{} `json:"items,omitempty"`
-	AssetsCollection     []Asset           `json:"assets,omitempty"`
-	StockItemsCollection []StockItem       `json:"stock_items,omitempty"`
-	TransferDate         time.Time         `json:"transfer_date"`
-	Status               string            `json:"status"`
-	Users                []User            `json:"users,omitempty"`
-	DeliveryLocation     *DeliveryLocation `json:"delivery_location,omitempty"`
+	AssetsCollection      []Asset             `json:"assets,omitempty"`
+	StockItemsCollection  []StockItem         `json:"stock_items,omitempty"`
+	TransferDate          time.Time           `json:"transfer_date"`
+	Status                string              `json:"status"`
+	Users                 []User              `json:"users,omitempty"`
+	DeliveryLocation      *DeliveryLocation   `json:"delivery_location,omitempty"`
+	KitWarnings           []KitWarning        `json:"kit_warnings,omitempty"`
+	RequiresDeliveryPhoto bool                `json:"requires_delivery_photo"`
+	AssetLines            []TransferAssetLine `json:"asset_lines,omitempty"`
+	StockLines            []TransferStockLine `json:"stock_lines,omitempty"`
+	ReturnOfTransferID    *int                `json:"return_of_transfer_id,omitempty"`
 }
 
 type DeliveryLocation struct {
 	Lat       float64   `json:"lat"`
 	Lng       float64   `json:"lng"`
 	Timestamp time.Time `json:"timestamp"`
+	// Distance from the destination in meters, known only for destinations with coordinates
+	Distance        *int `json:"distance,omitempty"`
+	OutsideGeofence bool `json:"outside_geofence"`
+}
+
+// Delivery states of transfer lines, missing lines wait to be confirmed later or written off
+const (
+	TransferLineInTransit  = "in_transit"
+	TransferLineMissing    = "missing"
+	TransferLineCompleted  = "completed"
+	TransferLineWrittenOff = "written_off"
+	TransferLineCancelled  = "cancelled"
+)
+
+type TransferAssetLine struct {
+	AssetID    int        `json:"id" db:"item_id"`
+	PyrCode    *string    `json:"pyr_code,omitempty" db:"pyr_code"`
+	Status     string     `json:"status" db:"status"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// TransferStockLine is a stock quantity sent with a transfer, a line is completed once nothing is outstanding
+type TransferStockLine struct {
+	ID                 int     `json:"line_id" db:"id"`
+	StockID            *int    `json:"stock_id,omitempty" db:"stock_id"`
+	CategoryID         int     `json:"category_id" db:"item_category_id"`
+	CategoryLabel      string  `json:"category_label" db:"category_label"`
+	Origin             *string `json:"origin,omitempty" db:"origin"`
+	Quantity           int     `json:"quantity" db:"quantity"`
+	ReceivedQuantity   int     `json:"received_quantity" db:"received_quantity"`
+	WrittenOffQuantity int     `json:"written_off_quantity" db:"written_off_quantity"`
+	Status             string  `json:"status" db:"status"`
+}
+
+// Outstanding is the quantity neither received nor written off
+func (l *TransferStockLine) Outstanding() int {
+	return l.Quantity - l.ReceivedQuantity - l.WrittenOffQuantity
 }
 
 type DeliveryLocationRequest struct {
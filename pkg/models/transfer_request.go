@@ -10,12 +10,17 @@ type AssetItemRequest struct {
 }
 
 type TransferRequest struct {
-	TransferID          int
-	FromLocationID      int                `json:"from_location_id" binding:"required"`
-	LocationID          int                `json:"location_id" binding:"required"`
-	AssetItemCollection []AssetItemRequest `json:"assets"`
-	StockItemCollection []StockItemRequest `json:"stocks"`
-	Users               []TransferUser     `json:"users,omitempty"`
+	TransferID           int
+	FromLocationID       int                `json:"from_location_id" binding:"required"`
+	LocationID           int                `json:"location_id" binding:"required"`
+	AssetItemCollection  []AssetItemRequest `json:"assets"`
+	StockItemCollection  []StockItemRequest `json:"stocks"`
+	Users                []TransferUser     `json:"users,omitempty"`
+	RequireDeliveryPhoto bool               `json:"require_delivery_photo"`
+	// ReservationID is the reservation the transfer fulfils, its holds do not block the transfer
+	ReservationID *int `json:"reservation_id"`
+	// ReturnOfTransferID is the transfer whose items this one brings back
+	ReturnOfTransferID *int `json:"-"`
 }
 
 type RetrieveTransferListQuery struct {
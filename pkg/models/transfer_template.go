@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// TransferTemplate is a recurring packing list, lines ask for quantities of categories rather than specific items
+type TransferTemplate struct {
+	ID                  int                    `json:"id" db:"id"`
+	Name                string                 `json:"name" db:"name"`
+	DefaultLocationID   *int                   `json:"default_location_id,omitempty" db:"default_location_id"`
+	DefaultLocationName *string                `json:"default_location_name,omitempty" db:"default_location_name"`
+	Notes               *string                `json:"notes,omitempty" db:"notes"`
+	CreatedByID         *int                   `json:"created_by_id,omitempty" db:"created_by_id"`
+	CreatedAt           time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time              `json:"updated_at" db:"updated_at"`
+	Lines               []TransferTemplateLine `json:"lines" db:"-"`
+}
+
+func (t *TransferTemplate) CreateLogView() AuditLog {
+	return AuditLog{
+		ResourceID:   t.ID,
+		ResourceType: "transfer_template",
+	}
+}
+
+type TransferTemplateLine struct {
+	TemplateID    int    `json:"-" db:"template_id"`
+	CategoryID    int    `json:"category_id" db:"item_category_id"`
+	CategoryLabel string `json:"category_label" db:"category_label"`
+	CategoryType  string `json:"category_type" db:"category_type"`
+	Quantity      int    `json:"quantity" db:"quantity"`
+}
+
+// TransferDraft is a transfer picked from a template. It is not stored, the client owns it until Transfer,
+// edited or not, is sent to POST /transfers like any other transfer request.
+type TransferDraft struct {
+	TemplateID int                     `json:"template_id"`
+	Transfer   TransferRequest         `json:"transfer"`
+	Shortages  []TransferDraftShortage `json:"shortages"`
+}
+
+// TransferDraftShortage is a template line the source location could not fill
+type TransferDraftShortage struct {
+	CategoryID    int    `json:"category_id"`
+	CategoryLabel string `json:"category_label"`
+	Requested     int    `json:"requested"`
+	Picked        int    `json:"picked"`
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"errors"
+	"log"
+)
+
+// Notification is a channel agnostic message, Data carries structured details for machine consumers
+type Notification struct {
+	Kind    string                 `json:"kind"`
+	Title   string                 `json:"title"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// Multi delivers a notification to every notifier and joins their errors
+type Multi []Notifier
+
+func (m Multi) Notify(notification Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LogNotifier writes notifications to the application log, used when no other channel is configured
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(notification Notification) error {
+	log.Printf("[%s] %s: %s", notification.Kind, notification.Title, notification.Message)
+	return nil
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(Notification{Kind: "low_stock", Title: "Niski stan", Data: map[string]interface{}{"quantity": 2}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "low_stock", received.Kind)
+	assert.Equal(t, float64(2), received.Data["quantity"])
+}
+
+func TestWebhookNotifierErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	assert.Error(t, NewWebhookNotifier(server.URL).Notify(Notification{Kind: "low_stock"}))
+}
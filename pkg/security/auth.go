@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 	"warehouse/internal/repository"
 	"warehouse/pkg/models"
@@ -86,3 +87,12 @@ func GetUserIDFromToken(c *gin.Context) (string, error) {
 
 	return userID, nil
 }
+
+func GetUserIDFromContext(c *gin.Context) (int, error) {
+	userID, err := GetUserIDFromToken(c)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(userID)
+}
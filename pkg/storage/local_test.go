@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	s := NewLocalStorage(t.TempDir())
+
+	assert.NoError(t, s.Save("asset/1/photo.jpg", strings.NewReader("content")))
+
+	r, err := s.Open("asset/1/photo.jpg")
+	assert.NoError(t, err)
+	data, _ := io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "content", string(data))
+
+	assert.NoError(t, s.Delete("asset/1/photo.jpg"))
+	_, err = s.Open("asset/1/photo.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, s.Delete("asset/1/photo.jpg"))
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	s := NewLocalStorage(t.TempDir())
+
+	assert.Error(t, s.Save("../outside.txt", strings.NewReader("x")))
+	assert.Error(t, s.Save("/etc/passwd", strings.NewReader("x")))
+	_, err := s.Open("a/../../outside.txt")
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps binary objects under opaque slash separated keys
+type Storage interface {
+	Save(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// NewFromEnv builds storage selected by ATTACHMENTS_STORAGE, local filesystem is the default
+func NewFromEnv() (Storage, error) {
+	switch driver := os.Getenv("ATTACHMENTS_STORAGE"); driver {
+	case "", "local":
+		root := os.Getenv("ATTACHMENTS_DIR")
+		if root == "" {
+			root = "./data/attachments"
+		}
+		return NewLocalStorage(root), nil
+	default:
+		return nil, fmt.Errorf("unsupported attachments storage: %s", driver)
+	}
+}